@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	// the image is built from scratch and has no zoneinfo for user timezones
+	_ "time/tzdata"
 
 	"github.com/charmbracelet/log"
 	"github.com/joho/godotenv"
@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// defaultRange — дней в отчётах, если from не задан
+	defaultRange        = 30
+	defaultTolerancePct = 10 // как calorieTolerancePct в параметрах плато
+)
+
+// GetAdherence reports logging consistency and target hits over [from, to],
+// built on the same daily points as GetSeries. The plan clamp keeps the latest days.
+func (s *service) GetAdherence(ctx context.Context, userId string, from, to time.Time, tolerancePct float64, loc *time.Location) (*AdherenceReport, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if tolerancePct == 0 {
+		tolerancePct = defaultTolerancePct
+	}
+	if tolerancePct < 0 || tolerancePct > 50 {
+		return nil, errors.New("tolerancePct must be in (0, 50]")
+	}
+	from, to = dateOf(from), dateOf(to)
+	if to.Before(from) {
+		return nil, errors.New("from must not be after to")
+	}
+
+	planType := s.getPlanType(ctx, userId)
+	allowed, clamped := reportDays(planType, int(to.Sub(from).Hours()/24)+1)
+	from = to.AddDate(0, 0, -allowed+1)
+
+	days, err := s.days(ctx, userId, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AdherenceReport{
+		From:         from.Format("2006-01-02"),
+		To:           to.Format("2006-01-02"),
+		PlanType:     planType,
+		Clamped:      clamped,
+		TolerancePct: tolerancePct,
+		Days:         len(days),
+	}
+	var overall, weekdays, weekends adherenceAcc
+	streak := 0
+	for _, d := range days {
+		overall.add(d, tolerancePct)
+		if wd := d.Date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			weekends.add(d, tolerancePct)
+		} else {
+			weekdays.add(d, tolerancePct)
+		}
+		if !d.Logged {
+			streak = 0
+			continue
+		}
+		res.LoggedDays++
+		streak++
+		res.LongestStreak = max(res.LongestStreak, streak)
+	}
+	res.CurrentStreak = currentStreak(days, dateOf(time.Now().In(loc)))
+	if res.Days > 0 {
+		res.LoggedPct = pct(res.LoggedDays, res.Days)
+	}
+	res.Overall, res.Weekdays, res.Weekends = overall.stats(), weekdays.stats(), weekends.stats()
+	return res, nil
+}
+
+// currentStreak counts logged days back from the end of the range; today does not
+// break the streak while it is still being logged.
+func currentStreak(days []Day, today time.Time) int {
+	i := len(days) - 1
+	if i >= 0 && !days[i].Logged && days[i].Date.Equal(today) {
+		i--
+	}
+	n := 0
+	for ; i >= 0 && days[i].Logged; i-- {
+		n++
+	}
+	return n
+}
+
+type adherenceAcc struct {
+	days, logged, targetDays, calGood, protGood int
+	calories, protein, balance                  float64
+}
+
+// add counts a day; only logged days with targets are compared against them.
+func (a *adherenceAcc) add(d Day, tolerancePct float64) {
+	a.days++
+	if !d.Logged {
+		return
+	}
+	a.logged++
+	if d.Target == nil || d.Budget == nil || *d.Budget <= 0 {
+		return
+	}
+	a.targetDays++
+	a.calories += d.Calories
+	a.protein += d.Protein
+	a.balance += d.Calories - *d.Budget
+	tol := tolerancePct / 100
+	if d.Calories >= (1-tol)**d.Budget && d.Calories <= (1+tol)**d.Budget {
+		a.calGood++
+	}
+	if d.Target.Protein > 0 && d.Protein >= d.Target.Protein {
+		a.protGood++
+	}
+}
+
+func (a *adherenceAcc) stats() AdherenceStats {
+	res := AdherenceStats{Days: a.days, LoggedDays: a.logged, TargetDays: a.targetDays, CaloriesInTolerance: a.calGood, ProteinHitDays: a.protGood}
+	if a.targetDays == 0 {
+		return res
+	}
+	n := float64(a.targetDays)
+	calPct, protPct := pct(a.calGood, a.targetDays), pct(a.protGood, a.targetDays)
+	avgCal, avgProt, avgBal := round1(a.calories/n), round1(a.protein/n), round1(a.balance/n)
+	res.CaloriesInTolPct, res.ProteinHitPct = &calPct, &protPct
+	res.AvgCalories, res.AvgProtein, res.AvgBalance = &avgCal, &avgProt, &avgBal
+	return res
+}
+
+func pct(part, total int) float64 {
+	return round1(float64(part) / float64(total) * 100)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
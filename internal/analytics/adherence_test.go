@@ -0,0 +1,35 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCurrentStreak(t *testing.T) {
+	today := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+	days := func(logged ...bool) []Day {
+		res := make([]Day, len(logged))
+		for i, l := range logged {
+			res[i] = Day{Date: today.AddDate(0, 0, i-len(logged)+1), Logged: l}
+		}
+		return res
+	}
+	tests := []struct {
+		name string
+		days []Day
+		want int
+	}{
+		{"empty", nil, 0},
+		{"logged today", days(false, true, true, true), 3},
+		{"today not logged yet", days(true, false, true, true, false), 2},
+		{"yesterday missed", days(true, true, false, false), 0},
+		{"range ends before today", []Day{{Date: today.AddDate(0, 0, -2), Logged: true}, {Date: today.AddDate(0, 0, -1)}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := currentStreak(tt.days, today); got != tt.want {
+				t.Errorf("currentStreak() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
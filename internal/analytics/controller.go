@@ -2,6 +2,7 @@ package analytics
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
     "os"
     "strconv"
@@ -24,23 +25,111 @@ func NewController() *Controller { return &Controller{service: NewService()} }
 func (c *Controller) RegisterRoutes(router chi.Router) {
     router.Route("/analytics", func(r chi.Router) {
         r.Get("/series", c.GetSeries)
+        r.Get("/summary", c.GetDaySummary)
+        r.Get("/adherence", c.GetAdherence)
+        r.Get("/meals", c.GetMeals)
+        r.Get("/foods", c.GetTopFoods)
     })
     logger.Info("╔═════ Analytics")
-    logger.Info("║    GET /series?end=&days=")
+    logger.Info("║    GET /series?end=&days=&granularity=day|week|month")
+    logger.Info("║    GET /summary?date=")
+    logger.Info("║    GET /adherence?from=&to=&tolerancePct=")
+    logger.Info("║    GET /meals?from=&to=")
+    logger.Info("║    GET /foods?from=&to=&groupBy=template|name&sortBy=calories|protein|fat|carbs&limit=")
     logger.Info("╚═════")
 }
 
 func (c *Controller) GetSeries(w http.ResponseWriter, r *http.Request) {
     u, ok := auth.UserFromContext(r.Context())
     if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    loc, err := userLocation(u.Timezone)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
     endStr := r.URL.Query().Get("end")
     daysStr := r.URL.Query().Get("days")
-    end := time.Now()
+    end := time.Now().In(loc)
     if endStr != "" { if t, err := time.Parse("2006-01-02", endStr); err == nil { end = t } }
     days := 7
     if daysStr != "" { if v, err := strconv.Atoi(daysStr); err == nil { days = v } }
-    res, err := c.service.GetSeries(r.Context(), u.Id, end, days)
+    res, err := c.service.GetSeries(r.Context(), u.Id, end, days, r.URL.Query().Get("granularity"), loc)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) GetDaySummary(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context())
+    if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    loc, err := userLocation(u.Timezone)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    day := time.Now().In(loc)
+    if s := r.URL.Query().Get("date"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { day = t } }
+    res, err := c.service.GetDaySummary(r.Context(), u.Id, day, loc)
     if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
     w.WriteHeader(http.StatusOK)
     _ = json.NewEncoder(w).Encode(res)
 }
+
+// GetAdherence — по умолчанию последние 30 дней.
+func (c *Controller) GetAdherence(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context())
+    if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    loc, err := userLocation(u.Timezone)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    from, to := dateRange(r, loc)
+    var tol float64
+    if s := r.URL.Query().Get("tolerancePct"); s != "" {
+        v, err := strconv.ParseFloat(s, 64)
+        if err != nil { http.Error(w, "invalid tolerancePct", http.StatusBadRequest); return }
+        tol = v
+    }
+    res, err := c.service.GetAdherence(r.Context(), u.Id, from, to, tol, loc)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+// GetMeals — распределение макросов и время приёмов пищи, по умолчанию за 30 дней.
+func (c *Controller) GetMeals(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context())
+    if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    loc, err := userLocation(u.Timezone)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    from, to := dateRange(r, loc)
+    res, err := c.service.GetMeals(r.Context(), u.Id, from, to, loc)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+// GetTopFoods — продукты с наибольшим вкладом в калории или макросы.
+func (c *Controller) GetTopFoods(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context())
+    if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    loc, err := userLocation(u.Timezone)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    from, to := dateRange(r, loc)
+    q := r.URL.Query()
+    limit := 0
+    if s := q.Get("limit"); s != "" { if v, err := strconv.Atoi(s); err == nil { limit = v } }
+    res, err := c.service.GetTopFoods(r.Context(), u.Id, from, to, q.Get("groupBy"), q.Get("sortBy"), limit, loc)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+// dateRange reads from/to; by default the last 30 days ending today in loc.
+func dateRange(r *http.Request, loc *time.Location) (from, to time.Time) {
+    to = time.Now().In(loc)
+    if s := r.URL.Query().Get("to"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { to = t } }
+    from = to.AddDate(0, 0, -defaultRange+1)
+    if s := r.URL.Query().Get("from"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { from = t } }
+    return from, to
+}
+
+// userLocation returns the timezone saved in the profile, the same one the diary and targets use.
+func userLocation(saved string) (*time.Location, error) {
+    if saved == "" { return time.UTC, nil }
+    loc, err := time.LoadLocation(saved)
+    if err != nil { return nil, errors.New("unknown timezone in profile") }
+    return loc, nil
+}
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/database"
+)
+
+const (
+	topFoodsDefaultLimit = 20
+	topFoodsMaxLimit     = 100
+)
+
+type foodRow struct {
+	Key        string  `db:"key"`
+	Name       string  `db:"name"`
+	TemplateId *int64  `db:"template_id"`
+	Entries    int     `db:"entries"`
+	Days       int     `db:"days"`
+	Calories   float64 `db:"calories"`
+	Protein    float64 `db:"protein"`
+	Fat        float64 `db:"fat"`
+	Carbs      float64 `db:"carbs"`
+}
+
+// GetTopFoods ranks foods by their contribution to calories or a macro over [from, to].
+// Water entries are skipped. Products store only calories and macros, so sugar and
+// sodium cannot be ranked.
+func (s *service) GetTopFoods(ctx context.Context, userId string, from, to time.Time, groupBy, sortBy string, limit int, loc *time.Location) (*TopFoodsReport, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if groupBy == "" {
+		groupBy = GroupByTemplate
+	}
+	if groupBy != GroupByTemplate && groupBy != GroupByName {
+		return nil, errors.New("groupBy must be template or name")
+	}
+	if sortBy == "" {
+		sortBy = "calories"
+	}
+	value, ok := map[string]func(Macros) float64{
+		"calories": func(m Macros) float64 { return m.Calories },
+		"protein":  func(m Macros) float64 { return m.Protein },
+		"fat":      func(m Macros) float64 { return m.Fat },
+		"carbs":    func(m Macros) float64 { return m.Carbs },
+	}[sortBy]
+	if !ok {
+		return nil, errors.New("sortBy must be calories, protein, fat or carbs")
+	}
+	if limit <= 0 {
+		limit = topFoodsDefaultLimit
+	}
+	limit = min(limit, topFoodsMaxLimit)
+	from, to = dateOf(from), dateOf(to)
+	if to.Before(from) {
+		return nil, errors.New("from must not be after to")
+	}
+	planType := s.getPlanType(ctx, userId)
+	allowed, clamped := reportDays(planType, int(to.Sub(from).Hours()/24)+1)
+	from = to.AddDate(0, 0, -allowed+1)
+
+	rows, err := s.foodTotals(ctx, userId, from, to, groupBy, loc)
+	if err != nil {
+		return nil, err
+	}
+	agg, err := s.dailyTotals(ctx, userId, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &TopFoodsReport{
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		Timezone:   loc.String(),
+		PlanType:   planType,
+		Clamped:    clamped,
+		GroupBy:    groupBy,
+		SortBy:     sortBy,
+		LoggedDays: len(agg),
+		Foods:      []FoodContribution{},
+	}
+	foods := make([]FoodContribution, len(rows))
+	for i, r := range rows {
+		foods[i] = FoodContribution{Key: r.Key, Name: r.Name, TemplateId: r.TemplateId, Entries: r.Entries, Days: r.Days,
+			Totals: Macros{Calories: r.Calories, Protein: r.Protein, Fat: r.Fat, Carbs: r.Carbs}}
+		res.Totals = addMacros(res.Totals, foods[i].Totals)
+	}
+	sort.SliceStable(foods, func(i, j int) bool { return value(foods[i].Totals) > value(foods[j].Totals) })
+	if len(foods) > limit {
+		foods = foods[:limit]
+	}
+	for i := range foods {
+		f := &foods[i]
+		if res.LoggedDays > 0 {
+			f.DaysPct = pct(f.Days, res.LoggedDays)
+		}
+		f.Share = Macros{
+			Calories: share(f.Totals.Calories, res.Totals.Calories),
+			Protein:  share(f.Totals.Protein, res.Totals.Protein),
+			Fat:      share(f.Totals.Fat, res.Totals.Fat),
+			Carbs:    share(f.Totals.Carbs, res.Totals.Carbs),
+		}
+		f.Totals = Macros{Calories: round1(f.Totals.Calories), Protein: round1(f.Totals.Protein), Fat: round1(f.Totals.Fat), Carbs: round1(f.Totals.Carbs)}
+	}
+	res.Foods = foods
+	res.Totals = Macros{Calories: round1(res.Totals.Calories), Protein: round1(res.Totals.Protein), Fat: round1(res.Totals.Fat), Carbs: round1(res.Totals.Carbs)}
+	return res, nil
+}
+
+// foodTotals sums products per food: by catalog template when there is one,
+// otherwise by the lower-cased name with collapsed spaces.
+func (s *service) foodTotals(ctx context.Context, userId string, from, to time.Time, groupBy string, loc *time.Location) ([]foodRow, error) {
+	res := []foodRow{}
+	err := s.db.SelectContext(ctx, &res, `
+        SELECT CASE WHEN $5 = 'template' AND template_id IS NOT NULL THEN 'template:' || template_id
+                    ELSE 'name:' || lower(btrim(regexp_replace(name, '\s+', ' ', 'g'))) END AS key,
+               (array_agg(name ORDER BY created_at DESC))[1] AS name,
+               CASE WHEN $5 = 'template' THEN MAX(template_id) END AS template_id,
+               COUNT(*) AS entries,
+               COUNT(DISTINCT `+database.LocalDate("created_at", "$4")+`) AS days,
+               COALESCE(SUM(calories),0)::float AS calories,
+               COALESCE(SUM(protein),0)::float AS protein,
+               COALESCE(SUM(fat),0)::float AS fat,
+               COALESCE(SUM(carbs),0)::float AS carbs
+        FROM products
+        WHERE user_id=$1 AND NOT is_water
+          AND `+database.LocalDays("created_at", "$2::date", "$3::date", "$4")+`
+        GROUP BY key`, userId, from, to, loc.String(), groupBy)
+	return res, err
+}
+
+func share(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return round1(part / total * 100)
+}
@@ -0,0 +1,171 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/database"
+	"github.com/jourloy/nutri-backend/internal/fit"
+	"github.com/jourloy/nutri-backend/internal/lib"
+)
+
+// энергия макронутриентов, ккал/г
+const (
+	kcalPerProtein = 4
+	kcalPerFat     = 9
+	kcalPerCarbs   = 4
+)
+
+// productEntry — запись о продукте с локальным временем добавления.
+type productEntry struct {
+	At       time.Time `db:"at"`
+	Calories float64   `db:"calories"`
+	Protein  float64   `db:"protein"`
+	Fat      float64   `db:"fat"`
+	Carbs    float64   `db:"carbs"`
+}
+
+// GetMeals reports the macro energy split and meal timing over [from, to].
+// Meal times are product timestamps, so they are as accurate as the user's logging habits.
+func (s *service) GetMeals(ctx context.Context, userId string, from, to time.Time, loc *time.Location) (*MealReport, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	from, to = dateOf(from), dateOf(to)
+	if to.Before(from) {
+		return nil, errors.New("from must not be after to")
+	}
+	planType := s.getPlanType(ctx, userId)
+	allowed, clamped := reportDays(planType, int(to.Sub(from).Hours()/24)+1)
+	from = to.AddDate(0, 0, -allowed+1)
+
+	entries, err := s.productEntries(ctx, userId, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.fitService.ResolveTargets(ctx, userId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MealReport{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Timezone: loc.String(),
+		PlanType: planType,
+		Clamped:  clamped,
+		ByHour:   make([]HourCalories, 24),
+		Days:     []MealDay{},
+	}
+	var total, target Macros
+	var firsts, lasts []float64
+	var windowSum, caloriesSum float64
+	var first, last time.Time
+	var day Macros
+	flush := func() {
+		d := &res.Days[len(res.Days)-1]
+		d.Calories = round1(day.Calories)
+		d.Split = macroSplit(day)
+		d.FirstMeal, d.LastMeal = first.Format("15:04"), last.Format("15:04")
+		d.WindowMin = int(last.Sub(first).Minutes())
+		if t, ok := targets[d.Date]; ok {
+			d.TargetSplit = macroSplit(targetMacros(t))
+			target = addMacros(target, targetMacros(t))
+		}
+		firsts = append(firsts, clockMinutes(first))
+		lasts = append(lasts, clockMinutes(last))
+		windowSum += float64(d.WindowMin)
+	}
+	for _, e := range entries {
+		key := e.At.Format("2006-01-02")
+		if len(res.Days) == 0 || res.Days[len(res.Days)-1].Date != key {
+			if len(res.Days) > 0 {
+				flush()
+			}
+			res.Days = append(res.Days, MealDay{Date: key})
+			day, first = Macros{}, e.At
+		}
+		res.Days[len(res.Days)-1].Entries++
+		last = e.At
+		m := Macros{Calories: e.Calories, Protein: e.Protein, Fat: e.Fat, Carbs: e.Carbs}
+		day, total = addMacros(day, m), addMacros(total, m)
+		res.ByHour[e.At.Hour()].Calories += e.Calories
+		caloriesSum += e.Calories
+	}
+	for h := range res.ByHour {
+		res.ByHour[h].Hour = h
+	}
+	if len(res.Days) == 0 {
+		return res, nil
+	}
+	flush()
+
+	n := float64(len(res.Days))
+	res.LoggedDays = len(res.Days)
+	res.Split = macroSplit(total)
+	res.TargetSplit = macroSplit(target)
+	res.AvgFirstMeal, res.AvgLastMeal = lib.ClockString(circularMean(firsts)), lib.ClockString(circularMean(lasts))
+	avgWindow := round1(windowSum / n)
+	res.AvgWindowMin = &avgWindow
+	for h := range res.ByHour {
+		if caloriesSum > 0 {
+			res.ByHour[h].Pct = round1(res.ByHour[h].Calories / caloriesSum * 100)
+		}
+		res.ByHour[h].Calories = round1(res.ByHour[h].Calories / n)
+	}
+	return res, nil
+}
+
+// productEntries returns products of the range ordered by local time; water is skipped.
+func (s *service) productEntries(ctx context.Context, userId string, from, to time.Time, loc *time.Location) ([]productEntry, error) {
+	res := []productEntry{}
+	err := s.db.SelectContext(ctx, &res, `
+        SELECT created_at AT TIME ZONE 'UTC' AT TIME ZONE $4 AS at,
+               COALESCE(calories,0)::float AS calories,
+               COALESCE(protein,0)::float AS protein,
+               COALESCE(fat,0)::float AS fat,
+               COALESCE(carbs,0)::float AS carbs
+        FROM products
+        WHERE user_id=$1 AND NOT is_water
+          AND `+database.LocalDays("created_at", "$2::date", "$3::date", "$4")+`
+        ORDER BY at`, userId, from, to, loc.String())
+	return res, err
+}
+
+// macroSplit returns the share of energy of each macro; nil when there is no energy.
+func macroSplit(m Macros) *MacroSplit {
+	p, f, c := m.Protein*kcalPerProtein, m.Fat*kcalPerFat, m.Carbs*kcalPerCarbs
+	sum := p + f + c
+	if sum <= 0 {
+		return nil
+	}
+	return &MacroSplit{Protein: round1(p / sum * 100), Fat: round1(f / sum * 100), Carbs: round1(c / sum * 100)}
+}
+
+func targetMacros(t fit.Targets) Macros {
+	return Macros{Calories: t.Calories, Protein: t.Protein, Fat: t.Fat, Carbs: t.Carbs}
+}
+
+func addMacros(a, b Macros) Macros {
+	return Macros{Calories: a.Calories + b.Calories, Protein: a.Protein + b.Protein, Fat: a.Fat + b.Fat, Carbs: a.Carbs + b.Carbs}
+}
+
+func clockMinutes(t time.Time) float64 {
+	return float64(t.Hour()*60 + t.Minute())
+}
+
+// circularMean averages times of day on the 24-hour circle, so 23:30 and 00:30 give 00:00, not 12:00.
+func circularMean(minutes []float64) float64 {
+	var x, y float64
+	for _, m := range minutes {
+		a := m / lib.MinutesPerDay * 2 * math.Pi
+		x, y = x+math.Cos(a), y+math.Sin(a)
+	}
+	m := math.Atan2(y, x) / (2 * math.Pi) * lib.MinutesPerDay
+	if m < 0 {
+		m += lib.MinutesPerDay
+	}
+	return m
+}
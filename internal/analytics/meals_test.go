@@ -0,0 +1,47 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jourloy/nutri-backend/internal/lib"
+)
+
+func TestMacroSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Macros
+		want *MacroSplit
+	}{
+		{"split by energy", Macros{Protein: 100, Fat: 50, Carbs: 200}, &MacroSplit{Protein: 24.2, Fat: 27.3, Carbs: 48.5}},
+		{"fat only", Macros{Fat: 10}, &MacroSplit{Fat: 100}},
+		{"no energy", Macros{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := macroSplit(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("macroSplit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircularMean(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes []float64
+		want    string
+	}{
+		{"around midnight", []float64{23*60 + 30, 30}, "00:00"},
+		{"late evening and night", []float64{23 * 60, 90}, "00:15"},
+		{"morning", []float64{8 * 60, 9 * 60}, "08:30"},
+		{"single", []float64{12 * 60}, "12:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lib.ClockString(circularMean(tt.minutes)); got != tt.want {
+				t.Errorf("circularMean() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,172 @@
 package analytics
 
-import "time"
+import (
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/fit"
+)
 
 type Day struct {
-    Date     time.Time `json:"date"`
-    Calories float64   `json:"calories"`
-    Protein  float64   `json:"protein"`
-    Fat      float64   `json:"fat"`
-    Carbs    float64   `json:"carbs"`
+    Date     time.Time    `json:"date"`
+    Calories float64      `json:"calories"`
+    Protein  float64      `json:"protein"`
+    Fat      float64      `json:"fat"`
+    Carbs    float64      `json:"carbs"`
+    Target   *fit.Targets `json:"target,omitempty"` // цели, действовавшие в этот день
+    Exercise float64      `json:"exercise"`         // калории тренировок
+    Budget   *float64     `json:"budget,omitempty"` // бюджет калорий: цель, плюс тренировки при exerciseAddBack
+    Logged   bool         `json:"logged"`           // в этот день есть записи о питании
 }
 
+const (
+    GranularityDay   = "day"
+    GranularityWeek  = "week"
+    GranularityMonth = "month"
+)
+
 type SeriesResponse struct {
-    Days          []Day  `json:"days"`
-    AllowedDays   int    `json:"allowedDays"`
-    Clamped       bool   `json:"clamped"`
-    PlanType      string `json:"planType"`
-    RangeStart    string `json:"rangeStart"`
-    RangeEnd      string `json:"rangeEnd"`
+    Days          []Day    `json:"days"`
+    AllowedDays   int      `json:"allowedDays"`
+    Clamped       bool     `json:"clamped"`
+    PlanType      string   `json:"planType"`
+    RangeStart    string   `json:"rangeStart"`
+    RangeEnd      string   `json:"rangeEnd"`
+    Granularity   string   `json:"granularity"`
+    Timezone      string   `json:"timezone"`
+    Buckets       []Bucket `json:"buckets,omitempty"` // для week и month
+}
+
+// Bucket — ISO-неделя или календарный месяц ряда.
+type Bucket struct {
+    Start      string  `json:"start"`      // понедельник или первое число месяца
+    End        string  `json:"end"`        // воскресенье или последнее число месяца
+    Partial    bool    `json:"partial"`    // период обрезан границами диапазона
+    Days       int     `json:"days"`       // дней периода внутри диапазона
+    LoggedDays int     `json:"loggedDays"` // дней с записями о питании
+    Sum        Macros  `json:"sum"`
+    Avg        Macros  `json:"avg"` // среднее по дням с записями
+    Exercise   float64 `json:"exercise"`
+    Target     *Macros `json:"target,omitempty"` // средняя дневная цель
+}
+
+// DaySummary — съедено за день против целей, действовавших в этот день.
+type DaySummary struct {
+    Date            string       `json:"date"`
+    Consumed        Day          `json:"consumed"`
+    Target          *fit.Targets `json:"target,omitempty"`
+    Exercise        float64      `json:"exercise"`
+    ExerciseAddBack bool         `json:"exerciseAddBack"`
+    Budget          *float64     `json:"budget,omitempty"`
+    Remaining       *Macros      `json:"remaining,omitempty"`
+}
+
+type Macros struct {
+    Calories float64 `json:"calories"`
+    Protein  float64 `json:"protein"`
+    Fat      float64 `json:"fat"`
+    Carbs    float64 `json:"carbs"`
+}
+
+// AdherenceReport — насколько стабильно пользователь ведёт дневник и попадает в цели.
+type AdherenceReport struct {
+    From          string         `json:"from"`
+    To            string         `json:"to"`
+    PlanType      string         `json:"planType"`
+    Clamped       bool           `json:"clamped"`
+    TolerancePct  float64        `json:"tolerancePct"`
+    Days          int            `json:"days"`
+    LoggedDays    int            `json:"loggedDays"`
+    LoggedPct     float64        `json:"loggedPct"`
+    LongestStreak int            `json:"longestStreak"` // дней подряд с записями
+    CurrentStreak int            `json:"currentStreak"`
+    Overall       AdherenceStats `json:"overall"`
+    Weekdays      AdherenceStats `json:"weekdays"` // пн–пт
+    Weekends      AdherenceStats `json:"weekends"` // сб–вс
+}
+
+// AdherenceStats считается по дням с записями и целью.
+type AdherenceStats struct {
+    Days                int      `json:"days"`
+    LoggedDays          int      `json:"loggedDays"`
+    TargetDays          int      `json:"targetDays"`          // дни с записями и целью
+    CaloriesInTolerance int      `json:"caloriesInTolerance"` // калории в пределах ±tolerancePct от бюджета
+    CaloriesInTolPct    *float64 `json:"caloriesInTolerancePct,omitempty"`
+    ProteinHitDays      int      `json:"proteinHitDays"` // белок не ниже цели
+    ProteinHitPct       *float64 `json:"proteinHitPct,omitempty"`
+    AvgCalories         *float64 `json:"avgCalories,omitempty"`
+    AvgProtein          *float64 `json:"avgProtein,omitempty"`
+    AvgBalance          *float64 `json:"avgBalance,omitempty"` // съедено минус бюджет: < 0 — дефицит
 }
 
+// MacroSplit — доля энергии из белков, жиров и углеводов, %.
+type MacroSplit struct {
+    Protein float64 `json:"protein"`
+    Fat     float64 `json:"fat"`
+    Carbs   float64 `json:"carbs"`
+}
+
+// MealDay — распределение и время приёмов пищи за день (по времени записей).
+type MealDay struct {
+    Date        string      `json:"date"`
+    Calories    float64     `json:"calories"`
+    Entries     int         `json:"entries"`
+    Split       *MacroSplit `json:"split,omitempty"`
+    TargetSplit *MacroSplit `json:"targetSplit,omitempty"`
+    FirstMeal   string      `json:"firstMeal"` // HH:MM
+    LastMeal    string      `json:"lastMeal"`
+    WindowMin   int         `json:"windowMin"` // пищевое окно от первой до последней записи
+}
+
+type HourCalories struct {
+    Hour     int     `json:"hour"`
+    Calories float64 `json:"calories"` // в среднем за день с записями
+    Pct      float64 `json:"pct"`      // доля калорий периода
+}
+
+// MealReport — распределение макросов и время приёмов пищи за период.
+type MealReport struct {
+    From         string         `json:"from"`
+    To           string         `json:"to"`
+    Timezone     string         `json:"timezone"`
+    PlanType     string         `json:"planType"`
+    Clamped      bool           `json:"clamped"`
+    LoggedDays   int            `json:"loggedDays"`
+    Split        *MacroSplit    `json:"split,omitempty"`
+    TargetSplit  *MacroSplit    `json:"targetSplit,omitempty"`
+    AvgFirstMeal string         `json:"avgFirstMeal,omitempty"`
+    AvgLastMeal  string         `json:"avgLastMeal,omitempty"`
+    AvgWindowMin *float64       `json:"avgWindowMin,omitempty"`
+    ByHour       []HourCalories `json:"byHour"`
+    Days         []MealDay      `json:"days"` // только дни с записями
+}
+
+const (
+    GroupByTemplate = "template" // шаблон каталога, для своих продуктов — нормализованное название
+    GroupByName     = "name"
+)
+
+// FoodContribution — вклад продукта в итоги периода.
+type FoodContribution struct {
+    Key        string  `json:"key"`
+    Name       string  `json:"name"` // последнее использованное название
+    TemplateId *int64  `json:"templateId,omitempty"`
+    Entries    int     `json:"entries"`
+    Days       int     `json:"days"`    // дней, когда продукт записан
+    DaysPct    float64 `json:"daysPct"` // доля дней с записями
+    Totals     Macros  `json:"totals"`
+    Share      Macros  `json:"share"` // % от итогов периода
+}
+
+// TopFoodsReport — откуда берутся калории и макросы за период.
+type TopFoodsReport struct {
+    From       string             `json:"from"`
+    To         string             `json:"to"`
+    Timezone   string             `json:"timezone"`
+    PlanType   string             `json:"planType"`
+    Clamped    bool               `json:"clamped"`
+    GroupBy    string             `json:"groupBy"`
+    SortBy     string             `json:"sortBy"`
+    LoggedDays int                `json:"loggedDays"`
+    Totals     Macros             `json:"totals"`
+    Foods      []FoodContribution `json:"foods"`
+}
@@ -2,52 +2,257 @@ package analytics
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/jourloy/nutri-backend/internal/database"
+	"github.com/jourloy/nutri-backend/internal/fit"
 )
 
 type Service interface {
-	GetSeries(ctx context.Context, userId string, end time.Time, days int) (*SeriesResponse, error)
+	GetSeries(ctx context.Context, userId string, end time.Time, days int, granularity string, loc *time.Location) (*SeriesResponse, error)
+	GetDaySummary(ctx context.Context, userId string, day time.Time, loc *time.Location) (*DaySummary, error)
+	GetAdherence(ctx context.Context, userId string, from, to time.Time, tolerancePct float64, loc *time.Location) (*AdherenceReport, error)
+	GetMeals(ctx context.Context, userId string, from, to time.Time, loc *time.Location) (*MealReport, error)
+	GetTopFoods(ctx context.Context, userId string, from, to time.Time, groupBy, sortBy string, limit int, loc *time.Location) (*TopFoodsReport, error)
 }
 
-type service struct{ db *sqlx.DB }
+type service struct {
+	db         *sqlx.DB
+	fitService fit.Service
+}
 
-func NewService() Service { return &service{db: database.Database} }
+func NewService() Service { return &service{db: database.Database, fitService: fit.NewService()} }
 
-func (s *service) GetSeries(ctx context.Context, userId string, end time.Time, days int) (*SeriesResponse, error) {
+// GetSeries returns daily points over the plan-clamped range and, for week and month
+// granularity, buckets aligned to ISO weeks or calendar months. Days are split in loc.
+func (s *service) GetSeries(ctx context.Context, userId string, end time.Time, days int, granularity string, loc *time.Location) (*SeriesResponse, error) {
 	if days <= 0 {
 		days = 30
 	}
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	if granularity != GranularityDay && granularity != GranularityWeek && granularity != GranularityMonth {
+		return nil, errors.New("granularity must be day, week or month")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
 	// Plan gating
 	planType := s.getPlanType(ctx, userId)
+	allowed, clamped := allowedDays(planType, days)
+
+	// Compute range
+	endDay := dateOf(end)
+	startDay := endDay.AddDate(0, 0, -allowed+1)
+
+	res, err := s.days(ctx, userId, startDay, endDay, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SeriesResponse{
+		Days:        res,
+		AllowedDays: allowed,
+		Clamped:     clamped,
+		PlanType:    planType,
+		RangeStart:  startDay.Format("2006-01-02"),
+		RangeEnd:    endDay.Format("2006-01-02"),
+		Granularity: granularity,
+		Timezone:    loc.String(),
+	}
+	if granularity != GranularityDay {
+		out.Buckets = buckets(res, granularity)
+	}
+	return out, nil
+}
+
+// allowedDays clamps the requested range to the plan: START sees only the last week.
+func allowedDays(planType string, days int) (int, bool) {
+	if (planType == "START" || planType == "start") && days > 7 {
+		return 7, true
+	}
+	return days, false
+}
+
+// maxReportDays — самый длинный диапазон отчётов, как у fit GetTargets.
+const maxReportDays = 366
 
-	allowed := days
-	clamped := false
-	if planType == "START" || planType == "start" {
-		if days > 7 {
-			allowed = 7
-			clamped = true
+// reportDays clamps the range of a report to the plan and to maxReportDays.
+func reportDays(planType string, days int) (int, bool) {
+	allowed, clamped := allowedDays(planType, days)
+	if allowed > maxReportDays {
+		return maxReportDays, true
+	}
+	return allowed, clamped
+}
+
+// days builds one point per day of [startDay, endDay] with consumed totals,
+// the targets valid on that day, exercise and the calorie budget.
+func (s *service) days(ctx context.Context, userId string, startDay, endDay time.Time, loc *time.Location) ([]Day, error) {
+	agg, err := s.dailyTotals(ctx, userId, startDay, endDay, loc)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.fitService.ResolveTargets(ctx, userId, startDay, endDay)
+	if err != nil {
+		return nil, err
+	}
+	exercise, err := s.dailyExercise(ctx, userId, startDay, endDay)
+	if err != nil {
+		return nil, err
+	}
+	addBack := s.exerciseAddBack(ctx, userId)
+	// fill missing days
+	res := []Day{}
+	for day := startDay; !day.After(endDay); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		v, ok := agg[key]
+		if !ok {
+			v = Day{Date: day}
+		}
+		v.Logged = ok
+		v.Exercise = exercise[key]
+		if t, ok := targets[key]; ok {
+			v.Target = &t
+			v.Budget = budget(t.Calories, v.Exercise, addBack)
 		}
+		res = append(res, v)
 	}
+	return res, nil
+}
 
-	// Compute range
-	endDay := end.Truncate(24 * time.Hour)
-	startDay := endDay.AddDate(0, 0, -allowed+1)
+// bucketStart returns the Monday of the ISO week or the first day of the month.
+func bucketStart(day time.Time, granularity string) time.Time {
+	if granularity == GranularityMonth {
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
 
-	// Query aggregates from products
+// buckets groups daily points; averages are taken over days with logged food only,
+// so a week with two forgotten days is not shown as a deficit.
+func buckets(days []Day, granularity string) []Bucket {
+	res := []Bucket{}
+	var targetDays int
+	flush := func() {
+		b := &res[len(res)-1]
+		if b.LoggedDays > 0 {
+			n := float64(b.LoggedDays)
+			b.Avg = Macros{Calories: b.Sum.Calories / n, Protein: b.Sum.Protein / n, Fat: b.Sum.Fat / n, Carbs: b.Sum.Carbs / n}
+		}
+		if targetDays > 0 {
+			n := float64(targetDays)
+			b.Target.Calories, b.Target.Protein, b.Target.Fat, b.Target.Carbs = b.Target.Calories/n, b.Target.Protein/n, b.Target.Fat/n, b.Target.Carbs/n
+		}
+	}
+	for _, d := range days {
+		start := bucketStart(d.Date, granularity)
+		if len(res) == 0 || res[len(res)-1].Start != start.Format("2006-01-02") {
+			if len(res) > 0 {
+				flush()
+			}
+			end := start.AddDate(0, 0, 6)
+			if granularity == GranularityMonth {
+				end = start.AddDate(0, 1, -1)
+			}
+			res = append(res, Bucket{Start: start.Format("2006-01-02"), End: end.Format("2006-01-02")})
+			targetDays = 0
+		}
+		b := &res[len(res)-1]
+		b.Days++
+		b.Exercise += d.Exercise
+		if d.Logged {
+			b.LoggedDays++
+			b.Sum.Calories += d.Calories
+			b.Sum.Protein += d.Protein
+			b.Sum.Fat += d.Fat
+			b.Sum.Carbs += d.Carbs
+		}
+		if d.Target != nil {
+			if b.Target == nil {
+				b.Target = &Macros{}
+			}
+			b.Target.Calories += d.Target.Calories
+			b.Target.Protein += d.Target.Protein
+			b.Target.Fat += d.Target.Fat
+			b.Target.Carbs += d.Target.Carbs
+			targetDays++
+		}
+	}
+	if len(res) > 0 {
+		flush()
+		// the range edges usually cut the first and the last period
+		for i := range res {
+			s, _ := time.Parse("2006-01-02", res[i].Start)
+			e, _ := time.Parse("2006-01-02", res[i].End)
+			res[i].Partial = res[i].Days < int(e.Sub(s).Hours()/24)+1
+		}
+	}
+	return res
+}
+
+// dateOf drops the time of day keeping the calendar date of t's location.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetDaySummary returns consumed totals of a day against the targets valid on that day.
+func (s *service) GetDaySummary(ctx context.Context, userId string, day time.Time, loc *time.Location) (*DaySummary, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	day = dateOf(day)
+	key := day.Format("2006-01-02")
+
+	agg, err := s.dailyTotals(ctx, userId, day, day, loc)
+	if err != nil {
+		return nil, err
+	}
+	consumed, ok := agg[key]
+	if !ok {
+		consumed = Day{Date: day}
+	}
+	target, err := s.fitService.GetTargetsForDay(ctx, userId, day)
+	if err != nil {
+		return nil, err
+	}
+
+	exercise, err := s.dailyExercise(ctx, userId, day, day)
+	if err != nil {
+		return nil, err
+	}
+	consumed.Exercise = exercise[key]
+
+	res := &DaySummary{Date: key, Consumed: consumed, Target: target, Exercise: consumed.Exercise, ExerciseAddBack: s.exerciseAddBack(ctx, userId)}
+	if target != nil {
+		res.Budget = budget(target.Calories, res.Exercise, res.ExerciseAddBack)
+		res.Remaining = &Macros{
+			Calories: *res.Budget - consumed.Calories,
+			Protein:  target.Protein - consumed.Protein,
+			Fat:      target.Fat - consumed.Fat,
+			Carbs:    target.Carbs - consumed.Carbs,
+		}
+	}
+	return res, nil
+}
+
+// dailyTotals aggregates products per day of loc (key — YYYY-MM-DD);
+// created_at is stored in UTC.
+func (s *service) dailyTotals(ctx context.Context, userId string, startDay, endDay time.Time, loc *time.Location) (map[string]Day, error) {
 	rows, err := s.db.QueryxContext(ctx, `
-        SELECT created_at::date AS d,
+        SELECT `+database.LocalDate("created_at", "$4")+` AS d,
                COALESCE(SUM(calories),0)::float,
                COALESCE(SUM(protein),0)::float,
                COALESCE(SUM(fat),0)::float,
                COALESCE(SUM(carbs),0)::float
         FROM products
-        WHERE user_id=$1 AND created_at::date >= $2::date AND created_at::date <= $3::date
+        WHERE user_id=$1 AND `+database.LocalDays("created_at", "$2::date", "$3::date", "$4")+`
         GROUP BY d
-        ORDER BY d`, userId, startDay, endDay)
+        ORDER BY d`, userId, startDay, endDay, loc.String())
 	if err != nil {
 		return nil, err
 	}
@@ -61,26 +266,46 @@ func (s *service) GetSeries(ctx context.Context, userId string, end time.Time, d
 		key := d.Date.Format("2006-01-02")
 		agg[key] = d
 	}
-	// fill missing days
-	res := make([]Day, 0, allowed)
-	for i := 0; i < allowed; i++ {
-		day := startDay.AddDate(0, 0, i)
-		key := day.Format("2006-01-02")
-		if v, ok := agg[key]; ok {
-			res = append(res, v)
-		} else {
-			res = append(res, Day{Date: day})
+	return agg, rows.Err()
+}
+
+// dailyExercise sums workout calories per day (key — YYYY-MM-DD).
+func (s *service) dailyExercise(ctx context.Context, userId string, startDay, endDay time.Time) (map[string]float64, error) {
+	rows, err := s.db.QueryxContext(ctx, `
+        SELECT logged_at, COALESCE(SUM(calories),0)::float
+        FROM body_workouts
+        WHERE user_id=$1 AND logged_at >= $2::date AND logged_at <= $3::date
+        GROUP BY logged_at`, userId, startDay, endDay)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := map[string]float64{}
+	for rows.Next() {
+		var d time.Time
+		var v float64
+		if err := rows.Scan(&d, &v); err != nil {
+			return nil, err
 		}
+		res[d.Format("2006-01-02")] = v
 	}
+	return res, rows.Err()
+}
 
-	return &SeriesResponse{
-		Days:        res,
-		AllowedDays: allowed,
-		Clamped:     clamped,
-		PlanType:    planType,
-		RangeStart:  startDay.Format("2006-01-02"),
-		RangeEnd:    endDay.Format("2006-01-02"),
-	}, nil
+// exerciseAddBack reports whether workout calories are added to the user's daily budget.
+func (s *service) exerciseAddBack(ctx context.Context, userId string) bool {
+	var v bool
+	_ = s.db.GetContext(ctx, &v, `SELECT exercise_add_back FROM fit_profiles WHERE user_id=$1 AND deleted_at IS NULL LIMIT 1`, userId)
+	return v
+}
+
+// budget — калории на день: цель плюс тренировки, если их добавляют к бюджету.
+func budget(target, exercise float64, addBack bool) *float64 {
+	v := target
+	if addBack {
+		v += exercise
+	}
+	return &v
 }
 
 func (s *service) getPlanType(ctx context.Context, userId string) string {
@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/fit"
+)
+
+func TestBuckets(t *testing.T) {
+	day := func(d int, logged bool, calories float64, target *fit.Targets) Day {
+		return Day{Date: time.Date(2025, 9, d, 0, 0, 0, 0, time.UTC), Calories: calories, Logged: logged, Target: target}
+	}
+	// пятница 5 — вторник 9 сентября; воскресенье забыто
+	days := []Day{
+		day(5, true, 1800, nil),
+		day(6, true, 2200, nil),
+		day(7, false, 0, nil),
+		day(8, true, 2000, &fit.Targets{Calories: 2100}),
+		day(9, false, 0, &fit.Targets{Calories: 1900}),
+	}
+	tests := []struct {
+		name        string
+		days        []Day
+		granularity string
+		want        []Bucket
+	}{
+		{
+			name:        "weeks average over logged days",
+			days:        days,
+			granularity: GranularityWeek,
+			want: []Bucket{
+				{Start: "2025-09-01", End: "2025-09-07", Partial: true, Days: 3, LoggedDays: 2, Sum: Macros{Calories: 4000}, Avg: Macros{Calories: 2000}},
+				{Start: "2025-09-08", End: "2025-09-14", Partial: true, Days: 2, LoggedDays: 1, Sum: Macros{Calories: 2000}, Avg: Macros{Calories: 2000}, Target: &Macros{Calories: 2000}},
+			},
+		},
+		{
+			name:        "month",
+			days:        days,
+			granularity: GranularityMonth,
+			want: []Bucket{
+				{Start: "2025-09-01", End: "2025-09-30", Partial: true, Days: 5, LoggedDays: 3, Sum: Macros{Calories: 6000}, Avg: Macros{Calories: 2000}, Target: &Macros{Calories: 2000}},
+			},
+		},
+		{
+			name:        "whole week",
+			days:        []Day{day(1, true, 1000, nil), day(2, false, 0, nil), day(3, false, 0, nil), day(4, false, 0, nil), day(5, false, 0, nil), day(6, false, 0, nil), day(7, false, 0, nil)},
+			granularity: GranularityWeek,
+			want: []Bucket{
+				{Start: "2025-09-01", End: "2025-09-07", Days: 7, LoggedDays: 1, Sum: Macros{Calories: 1000}, Avg: Macros{Calories: 1000}},
+			},
+		},
+		{
+			name:        "empty",
+			granularity: GranularityWeek,
+			want:        []Bucket{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buckets(tt.days, tt.granularity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buckets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
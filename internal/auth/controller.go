@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 
@@ -34,6 +35,8 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
 		r.Post("/refresh", c.Refresh)
 		r.Post("/me", c.Me)
 		r.Post("/view/updates", c.IncreaseViewUpdates)
+		r.Put("/locale", c.UpdateLocale)
+		r.Put("/timezone", c.UpdateTimezone)
 		r.Delete("/me", c.DeleteMe)
 	})
 
@@ -43,6 +46,8 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
 	logger.Info("║   POST /refresh")
 	logger.Info("║   POST /me")
 	logger.Info("║   POST /view/updates")
+	logger.Info("║    PUT /locale")
+	logger.Info("║    PUT /timezone")
 	logger.Info("║ DELETE /me")
 	logger.Info("╚═════")
 }
@@ -146,6 +151,66 @@ func (c *Controller) IncreaseViewUpdates(w http.ResponseWriter, r *http.Request)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+func (c *Controller) UpdateLocale(w http.ResponseWriter, r *http.Request) {
+	u, ok := UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.UpdateLocale(context.Background(), u.Id, body.Locale)
+	if errors.Is(err, ErrUserNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Warn("Update locale failed", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) UpdateTimezone(w http.ResponseWriter, r *http.Request) {
+	u, ok := UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.UpdateTimezone(context.Background(), u.Id, body.Timezone)
+	if errors.Is(err, ErrUserNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Warn("Update timezone failed", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
 func (c *Controller) Me(w http.ResponseWriter, r *http.Request) {
 	u, ok := UserFromContext(r.Context())
 	if !ok {
@@ -38,6 +38,8 @@ type Service interface {
 	Login(body LoginData) (*LoginResponse, error)
 	Refresh(refreshToken string) (*LoginResponse, error)
 	IncreaseViewUpdates(ctx context.Context, uid string) (*user.User, error)
+	UpdateLocale(ctx context.Context, uid string, locale string) (*user.User, error)
+	UpdateTimezone(ctx context.Context, uid string, timezone string) (*user.User, error)
 	Delete(id string) error
 }
 
@@ -226,6 +228,42 @@ func (s *service) IncreaseViewUpdates(ctx context.Context, uid string) (*user.Us
 	return s.userService.IncreaseViewUpdates(context.Background(), uid)
 }
 
+// ErrUserNotFound is returned when the user to update no longer exists.
+var ErrUserNotFound = errors.New("user not found")
+
+// UpdateLocale accepts only supported locales, so template names can always be looked up.
+func (s *service) UpdateLocale(ctx context.Context, uid string, locale string) (*user.User, error) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if locale == "" {
+		return nil, errors.New("locale is required")
+	}
+	if !user.IsLocale(locale) {
+		return nil, errors.New("locale must be one of: " + strings.Join(user.Locales, ", "))
+	}
+	u, err := s.userService.UpdateLocale(ctx, uid, locale)
+	if err == nil && u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
+// UpdateTimezone accepts an IANA name (Europe/Moscow); it sets day boundaries of the diary,
+// targets and analytics. "Local" is the server's zone and unknown to Postgres, so it is rejected.
+func (s *service) UpdateTimezone(ctx context.Context, uid string, timezone string) (*user.User, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		return nil, errors.New("timezone is required")
+	}
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return nil, errors.New("unknown timezone")
+	}
+	u, err := s.userService.UpdateTimezone(ctx, uid, timezone)
+	if err == nil && u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
 func (s *service) Delete(id string) error {
 	_, err := s.userService.DeleteUser(context.Background(), id)
 	return err
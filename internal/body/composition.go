@@ -0,0 +1,151 @@
+package body
+
+import (
+    "context"
+    "math"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/fit"
+)
+
+const (
+    BodyFatMeasured = "measured"
+    BodyFatNavy     = "navy"
+    BodyFatBMI      = "bmi"
+)
+
+// weights further than this from the measurement date are not matched
+const compositionWeightMaxGap = 7 * 24 * time.Hour
+
+// Composition — body-fat estimates and lean/fat mass for one date.
+type Composition struct {
+    Weight        *float64 `json:"weight,omitempty"`
+    BodyFat       *float64 `json:"bodyFat,omitempty"`       // лучшая доступная оценка
+    BodyFatSource string   `json:"bodyFatSource,omitempty"` // measured | navy | bmi
+    NavyBodyFat   *float64 `json:"navyBodyFat,omitempty"`
+    BmiBodyFat    *float64 `json:"bmiBodyFat,omitempty"`
+    LeanMass      *float64 `json:"leanMass,omitempty"`
+    FatMass       *float64 `json:"fatMass,omitempty"`
+}
+
+type CompositionPoint struct {
+    Date string `json:"date"`
+    Composition
+}
+
+// NavyBodyFat — формула ВМС США (обхваты и рост в см). nil, если обхватов не хватает.
+func NavyBodyFat(male bool, height float64, waist, neck, hips *float64) *float64 {
+    if height <= 0 || waist == nil || neck == nil { return nil }
+    var bf float64
+    if male {
+        if *waist-*neck <= 0 { return nil }
+        bf = 495/(1.0324-0.19077*math.Log10(*waist-*neck)+0.15456*math.Log10(height)) - 450
+    } else {
+        if hips == nil || *waist+*hips-*neck <= 0 { return nil }
+        bf = 495/(1.29579-0.35004*math.Log10(*waist+*hips-*neck)+0.22100*math.Log10(height)) - 450
+    }
+    return validBodyFat(bf)
+}
+
+// BmiBodyFat — оценка по ИМТ и возрасту (Deurenberg).
+func BmiBodyFat(male bool, age int64, height, weight float64) *float64 {
+    if height <= 0 || weight <= 0 || age <= 0 { return nil }
+    bmi := weight / math.Pow(height/100, 2)
+    sex := 0.0
+    if male { sex = 1 }
+    return validBodyFat(1.20*bmi + 0.23*float64(age) - 10.8*sex - 5.4)
+}
+
+func validBodyFat(v float64) *float64 {
+    if math.IsNaN(v) || v < 2 || v > 70 { return nil }
+    v = math.Round(v*10) / 10
+    return &v
+}
+
+// composeMeasurement picks the best body-fat value (measured > navy > bmi) and derives lean/fat mass.
+func composeMeasurement(m Measurement, weight *float64, f *fit.FitProfile) *Composition {
+    c := &Composition{Weight: weight}
+    if f != nil {
+        male := fit.IsMale(f.Gender)
+        c.NavyBodyFat = NavyBodyFat(male, float64(f.Height), m.Waist, m.Neck, m.Hips)
+        if weight != nil { c.BmiBodyFat = BmiBodyFat(male, f.Age, float64(f.Height), *weight) }
+    }
+    switch {
+    case m.BodyFat != nil:
+        c.BodyFat, c.BodyFatSource = m.BodyFat, BodyFatMeasured
+    case c.NavyBodyFat != nil:
+        c.BodyFat, c.BodyFatSource = c.NavyBodyFat, BodyFatNavy
+    case c.BmiBodyFat != nil:
+        c.BodyFat, c.BodyFatSource = c.BmiBodyFat, BodyFatBMI
+    }
+    if c.BodyFat != nil && weight != nil {
+        fat := round2(*weight * *c.BodyFat / 100)
+        lean := round2(*weight - fat)
+        c.FatMass, c.LeanMass = &fat, &lean
+    }
+    return c
+}
+
+// nearestWeight returns the weight logged closest to day (weights are sorted by date).
+func nearestWeight(ws []Weight, day time.Time) *float64 {
+    var best *float64
+    bestGap := compositionWeightMaxGap + time.Nanosecond
+    for i := range ws {
+        gap := ws[i].LoggedAt.Sub(day)
+        if gap < 0 { gap = -gap }
+        if gap < bestGap { bestGap = gap; best = &ws[i].Value }
+    }
+    return best
+}
+
+// withComposition fills Composition for each measurement using the profile and nearby weights.
+func (s *service) withComposition(ctx context.Context, userId string, list []Measurement) ([]Measurement, error) {
+    if len(list) == 0 { return list, nil }
+    f, err := s.fitService.GetFitProfileByUser(userId)
+    if err != nil { return nil, err }
+    from := list[0].LoggedAt.Add(-compositionWeightMaxGap)
+    to := list[len(list)-1].LoggedAt.Add(compositionWeightMaxGap)
+    ws, err := s.repo.GetWeights(ctx, userId, &from, &to)
+    if err != nil { return nil, err }
+    for i := range list {
+        list[i].Composition = composeMeasurement(list[i], nearestWeight(ws, list[i].LoggedAt), f)
+    }
+    return list, nil
+}
+
+// hasBodyFat reports whether the measurement gives a body-fat value on its own (measured or navy).
+func hasBodyFat(m Measurement, f *fit.FitProfile) bool {
+    if m.BodyFat != nil { return true }
+    return f != nil && NavyBodyFat(fit.IsMale(f.Gender), float64(f.Height), m.Waist, m.Neck, m.Hips) != nil
+}
+
+// compositionSeries builds one point per weigh-in day (the day's last weight), carrying
+// the latest measured or navy body fat forward; without one the BMI estimate is used.
+// Both lists are sorted by date.
+func compositionSeries(ws []Weight, ms []Measurement, f *fit.FitProfile) []CompositionPoint {
+    res := make([]CompositionPoint, 0, len(ws))
+    var carried Measurement
+    j := 0
+    for i := range ws {
+        for ; j < len(ms) && !ms[j].LoggedAt.After(ws[i].LoggedAt); j++ {
+            if hasBodyFat(ms[j], f) { carried = ms[j] }
+        }
+        p := CompositionPoint{Date: ws[i].LoggedAt.Format("2006-01-02"), Composition: *composeMeasurement(carried, &ws[i].Value, f)}
+        if n := len(res); n > 0 && res[n-1].Date == p.Date { res[n-1] = p; continue }
+        res = append(res, p)
+    }
+    return res
+}
+
+// GetComposition returns lean/fat mass over the weight log in [from, to].
+func (s *service) GetComposition(ctx context.Context, userId string, from, to *time.Time) ([]CompositionPoint, error) {
+    ws, err := s.repo.GetWeights(ctx, userId, from, to)
+    if err != nil { return nil, err }
+    if len(ws) == 0 { return []CompositionPoint{}, nil }
+    f, err := s.fitService.GetFitProfileByUser(userId)
+    if err != nil { return nil, err }
+    // measurements before the range still supply the body fat carried into it
+    ms, err := s.repo.GetMeasurements(ctx, userId, nil, &ws[len(ws)-1].LoggedAt)
+    if err != nil { return nil, err }
+    return compositionSeries(ws, ms, f), nil
+}
@@ -0,0 +1,58 @@
+package body
+
+import (
+    "testing"
+    "time"
+)
+
+func fptr(v float64) *float64 { return &v }
+
+func TestNavyBodyFat(t *testing.T) {
+    tests := []struct {
+        name              string
+        male              bool
+        height            float64
+        waist, neck, hips *float64
+        want              *float64
+    }{
+        {"male", true, 180, fptr(90), fptr(40), nil, fptr(18.4)},
+        {"female", false, 165, fptr(75), fptr(33), fptr(100), fptr(29.4)},
+        {"no neck", true, 180, fptr(90), nil, nil, nil},
+        {"female without hips", false, 165, fptr(75), fptr(33), nil, nil},
+        {"waist not above neck", true, 180, fptr(40), fptr(40), nil, nil},
+        {"no height", true, 0, fptr(90), fptr(40), nil, nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := NavyBodyFat(tt.male, tt.height, tt.waist, tt.neck, tt.hips)
+            if (got == nil) != (tt.want == nil) { t.Fatalf("NavyBodyFat() = %v, want %v", got, tt.want) }
+            if got != nil && *got != *tt.want { t.Errorf("NavyBodyFat() = %v, want %v", *got, *tt.want) }
+        })
+    }
+}
+
+func TestCompositionSeries(t *testing.T) {
+    d := func(day, hour int) time.Time { return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC) }
+    ws := []Weight{{Value: 81, LoggedAt: d(1, 8)}, {Value: 80, LoggedAt: d(2, 8)}, {Value: 79, LoggedAt: d(2, 20)}, {Value: 78, LoggedAt: d(4, 8)}}
+    ms := []Measurement{
+        {BodyFat: fptr(20), LoggedAt: d(2, 9)},
+        {Waist: fptr(90), LoggedAt: d(3, 8)}, // no body fat on its own: the 20 % is kept
+    }
+    got := compositionSeries(ws, ms, nil)
+    want := []struct {
+        date    string
+        bodyFat  *float64
+        fatMass *float64
+    }{
+        {"2025-03-01", nil, nil},
+        {"2025-03-02", fptr(20), fptr(15.8)},
+        {"2025-03-04", fptr(20), fptr(15.6)},
+    }
+    if len(got) != len(want) { t.Fatalf("compositionSeries() = %d points, want %d", len(got), len(want)) }
+    for i, w := range want {
+        p := got[i]
+        if p.Date != w.date { t.Errorf("point %d date = %s, want %s", i, p.Date, w.date) }
+        if (p.BodyFat == nil) != (w.bodyFat == nil) || p.BodyFat != nil && *p.BodyFat != *w.bodyFat { t.Errorf("point %d bodyFat = %v, want %v", i, p.BodyFat, w.bodyFat) }
+        if (p.FatMass == nil) != (w.fatMass == nil) || p.FatMass != nil && *p.FatMass != *w.fatMass { t.Errorf("point %d fatMass = %v, want %v", i, p.FatMass, w.fatMass) }
+    }
+}
@@ -3,6 +3,8 @@ package body
 import (
     "context"
     "encoding/json"
+    "errors"
+    "fmt"
     "net/http"
     "os"
     "strconv"
@@ -11,6 +13,7 @@ import (
     "github.com/charmbracelet/log"
     "github.com/go-chi/chi/v5"
 
+    "github.com/jourloy/nutri-backend/internal/database"
     "github.com/jourloy/nutri-backend/internal/auth"
 )
 
@@ -30,12 +33,14 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
         r.Delete("/weight/{id}", c.DeleteWeight)
         r.Get("/weights", c.GetWeights)
         r.Get("/weight/latest", c.GetLatestWeight)
+        r.Get("/weight/trend", c.GetWeightTrend)
         // measurements
         r.Post("/measure", c.CreateMeasurement)
         r.Put("/measure", c.UpdateMeasurement)
         r.Delete("/measure/{id}", c.DeleteMeasurement)
         r.Get("/measures", c.GetMeasurements)
         r.Get("/measure/latest", c.GetLatestMeasurement)
+        r.Get("/composition", c.GetComposition)
         // plateau
         r.Get("/plateau", c.GetPlateau)
         r.Post("/plateau/evaluate", c.EvaluatePlateau)
@@ -45,8 +50,36 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
         r.Put("/activity", c.UpdateActivity)
         r.Delete("/activity/{id}", c.DeleteActivity)
         r.Get("/activity", c.GetActivity)
+        // sleep sessions
+        r.Post("/sleep", c.CreateSleep)
+        r.Put("/sleep", c.UpdateSleep)
+        r.Delete("/sleep/{id}", c.DeleteSleep)
+        r.Get("/sleep", c.GetSleep)
+        r.Get("/sleep/stats", c.GetSleepStats)
+        // workouts
+        r.Post("/workout", c.CreateWorkout)
+        r.Put("/workout", c.UpdateWorkout)
+        r.Delete("/workout/{id}", c.DeleteWorkout)
+        r.Get("/workouts", c.GetWorkouts)
+        // health app import
+        r.Post("/import", c.ImportHealth)
+        r.Post("/import/scale", c.ImportScale)
         // plateau history
         r.Get("/plateau/history", c.GetPlateauHistory)
+        r.Post("/plateau/{id}/recommendations/{code}/accept", c.AcceptRecommendation)
+        // plateau params
+        r.Get("/plateau/params", c.GetPlateauParams)
+        r.Put("/plateau/params", c.SetUserPlateauParams)
+        r.Delete("/plateau/params", c.ResetUserPlateauParams)
+        r.Put("/plateau/params/global", c.SetGlobalPlateauParams)
+        r.Put("/plateau/params/plan/{planId}", c.SetPlanPlateauParams)
+        // adaptive expenditure
+        r.Get("/expenditure", c.GetExpenditure)
+        r.Post("/expenditure/apply", c.ApplyExpenditure)
+        // goal forecast
+        r.Get("/forecast", c.GetForecast)
+        // safety guardrails
+        r.Get("/guardrails", c.GetGuardrails)
     })
 
     logger.Info("╔═════ BodyTracking")
@@ -55,18 +88,41 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
     logger.Info("║ DELETE /weight/{id}")
     logger.Info("║    GET /weights?from=&to=")
     logger.Info("║    GET /weight/latest")
+    logger.Info("║    GET /weight/trend?from=&to=")
     logger.Info("║   POST /measure")
     logger.Info("║    PUT /measure")
     logger.Info("║ DELETE /measure/{id}")
     logger.Info("║    GET /measures?from=&to=")
     logger.Info("║    GET /measure/latest")
+    logger.Info("║    GET /composition?from=&to=")
     logger.Info("║    GET /plateau")
     logger.Info("║   POST /plateau/evaluate")
     logger.Info("║   POST /activity")
     logger.Info("║    PUT /activity")
     logger.Info("║ DELETE /activity/{id}")
     logger.Info("║    GET /activity?from=&to=")
+    logger.Info("║   POST /sleep")
+    logger.Info("║    PUT /sleep")
+    logger.Info("║ DELETE /sleep/{id}")
+    logger.Info("║    GET /sleep?from=&to=")
+    logger.Info("║    GET /sleep/stats?from=&to=")
+    logger.Info("║   POST /workout")
+    logger.Info("║    PUT /workout")
+    logger.Info("║ DELETE /workout/{id}")
+    logger.Info("║    GET /workouts?from=&to=")
+    logger.Info("║   POST /import (multipart: file, source=apple|google, tz=)")
+    logger.Info("║   POST /import/scale (multipart: file, preset=, mapping=, aggregate=first|last|avg, overwrite=, dryRun=, tz=)")
     logger.Info("║    GET /plateau/history?from=&to=")
+    logger.Info("║   POST /plateau/{id}/recommendations/{code}/accept")
+    logger.Info("║    GET /plateau/params")
+    logger.Info("║    PUT /plateau/params")
+    logger.Info("║ DELETE /plateau/params")
+    logger.Info("║    PUT /plateau/params/global")
+    logger.Info("║    PUT /plateau/params/plan/{planId}")
+    logger.Info("║    GET /expenditure")
+    logger.Info("║   POST /expenditure/apply")
+    logger.Info("║    GET /forecast")
+    logger.Info("║    GET /guardrails")
     logger.Info("╚═════")
 }
 
@@ -118,26 +174,53 @@ func (c *Controller) GetLatestWeight(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
 }
 
+func (c *Controller) GetWeightTrend(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var from, to *time.Time
+    if s := r.URL.Query().Get("from"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { from = &t } }
+    if s := r.URL.Query().Get("to"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { to = &t } }
+    res, err := c.service.GetWeightTrend(context.Background(), u.Id, from, to)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
 // ===== Measurements =====
+type measurementBody struct {
+    Id       int64    `json:"id"`
+    Chest    *float64 `json:"chest"`
+    Waist    *float64 `json:"waist"`
+    Hips     *float64 `json:"hips"`
+    Neck     *float64 `json:"neck"`
+    Arm      *float64 `json:"arm"`
+    Thigh    *float64 `json:"thigh"`
+    Calf     *float64 `json:"calf"`
+    BodyFat  *float64 `json:"bodyFat"`
+    LoggedAt *string  `json:"loggedAt"`
+}
+
+func (b measurementBody) empty() bool {
+    return b.Chest == nil && b.Waist == nil && b.Hips == nil && b.Neck == nil && b.Arm == nil && b.Thigh == nil && b.Calf == nil && b.BodyFat == nil
+}
+
 func (c *Controller) CreateMeasurement(w http.ResponseWriter, r *http.Request) {
     u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
-    var body struct { Chest *float64 `json:"chest"`; Waist *float64 `json:"waist"`; Hips *float64 `json:"hips"`; LoggedAt *string `json:"loggedAt"` }
+    var body measurementBody
     if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
-    if body.Chest == nil && body.Waist == nil && body.Hips == nil { http.Error(w, "at least one of chest/waist/hips required", http.StatusBadRequest); return }
+    if body.empty() { http.Error(w, "at least one measurement required", http.StatusBadRequest); return }
     when := time.Now()
     if body.LoggedAt != nil && *body.LoggedAt != "" { if t, err := time.Parse("2006-01-02", *body.LoggedAt); err == nil { when = t } }
-    res, err := c.service.CreateMeasurement(context.Background(), MeasurementCreate{UserId: u.Id, Chest: body.Chest, Waist: body.Waist, Hips: body.Hips, LoggedAt: when})
+    res, err := c.service.CreateMeasurement(context.Background(), MeasurementCreate{UserId: u.Id, Chest: body.Chest, Waist: body.Waist, Hips: body.Hips, Neck: body.Neck, Arm: body.Arm, Thigh: body.Thigh, Calf: body.Calf, BodyFat: body.BodyFat, LoggedAt: when})
     if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
     w.WriteHeader(http.StatusCreated); _ = json.NewEncoder(w).Encode(res)
 }
 
 func (c *Controller) UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
     u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
-    var body struct { Id int64 `json:"id"`; Chest *float64 `json:"chest"`; Waist *float64 `json:"waist"`; Hips *float64 `json:"hips"`; LoggedAt *string `json:"loggedAt"` }
+    var body measurementBody
     if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
     when := time.Now()
     if body.LoggedAt != nil && *body.LoggedAt != "" { if t, err := time.Parse("2006-01-02", *body.LoggedAt); err == nil { when = t } }
-    res, err := c.service.UpdateMeasurement(context.Background(), Measurement{Id: body.Id, UserId: u.Id, Chest: body.Chest, Waist: body.Waist, Hips: body.Hips, LoggedAt: when})
+    res, err := c.service.UpdateMeasurement(context.Background(), Measurement{Id: body.Id, UserId: u.Id, Chest: body.Chest, Waist: body.Waist, Hips: body.Hips, Neck: body.Neck, Arm: body.Arm, Thigh: body.Thigh, Calf: body.Calf, BodyFat: body.BodyFat, LoggedAt: when})
     if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
     w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
 }
@@ -167,6 +250,16 @@ func (c *Controller) GetLatestMeasurement(w http.ResponseWriter, r *http.Request
     w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
 }
 
+func (c *Controller) GetComposition(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var from, to *time.Time
+    if s := r.URL.Query().Get("from"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { from = &t } }
+    if s := r.URL.Query().Get("to"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { to = &t } }
+    res, err := c.service.GetComposition(context.Background(), u.Id, from, to)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
 // ===== Plateau =====
 func (c *Controller) GetPlateau(w http.ResponseWriter, r *http.Request) {
     u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
@@ -218,6 +311,182 @@ func (c *Controller) GetActivity(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
 }
 
+// ===== Sleep sessions =====
+type sleepBody struct {
+    Id      int64   `json:"id"`
+    StartAt string  `json:"startAt"` // RFC3339 или YYYY-MM-DDTHH:MM, местное время
+    EndAt   string  `json:"endAt"`
+    Quality *int    `json:"quality"`
+    IsNap   bool    `json:"isNap"`
+    Note    *string `json:"note"`
+}
+
+// parseLocalTime keeps the wall clock of the given time: bedtimes are compared as local times.
+func parseLocalTime(v string) (time.Time, error) {
+    for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+        if t, err := time.Parse(layout, v); err == nil {
+            return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+        }
+    }
+    return time.Time{}, fmt.Errorf("invalid time %q", v)
+}
+
+func (b sleepBody) create(userId string) (SleepSessionCreate, error) {
+    start, err := parseLocalTime(b.StartAt); if err != nil { return SleepSessionCreate{}, err }
+    end, err := parseLocalTime(b.EndAt); if err != nil { return SleepSessionCreate{}, err }
+    return SleepSessionCreate{UserId: userId, StartAt: start, EndAt: end, Quality: b.Quality, IsNap: b.IsNap, Note: b.Note}, nil
+}
+
+func (c *Controller) CreateSleep(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var body sleepBody
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    in, err := body.create(u.Id); if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    res, err := c.service.CreateSleep(context.Background(), in)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusCreated); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) UpdateSleep(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var body sleepBody
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    in, err := body.create(u.Id); if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    res, err := c.service.UpdateSleep(context.Background(), SleepSession{Id: body.Id, UserId: u.Id, StartAt: in.StartAt, EndAt: in.EndAt, Quality: in.Quality, IsNap: in.IsNap, Note: in.Note})
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) DeleteSleep(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    idStr := chi.URLParam(r, "id"); if idStr == "" { http.Error(w, "missing id", http.StatusBadRequest); return }
+    id, err := strconv.ParseInt(idStr, 10, 64); if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if err := c.service.DeleteSleep(context.Background(), id, u.Id); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) GetSleep(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var from, to *time.Time
+    if s := r.URL.Query().Get("from"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { from = &t } }
+    if s := r.URL.Query().Get("to"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { to = &t } }
+    res, err := c.service.GetSleep(context.Background(), u.Id, from, to)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// GetSleepStats — по умолчанию последние 14 дней.
+func (c *Controller) GetSleepStats(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    to, err := database.UserToday(r.Context(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    if s := r.URL.Query().Get("to"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { to = t } }
+    from := to.AddDate(0, 0, -13)
+    if s := r.URL.Query().Get("from"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { from = t } }
+    res, err := c.service.GetSleepStats(context.Background(), u.Id, from, to)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// ===== Workouts =====
+type workoutBody struct {
+    Id          int64    `json:"id"`
+    Type        string   `json:"type"`
+    DurationMin int      `json:"durationMin"`
+    Intensity   string   `json:"intensity"`
+    DistanceKm  *float64 `json:"distanceKm"`
+    Calories    *float64 `json:"calories"` // с часов/трекера; без него калории оцениваются по MET
+    LoggedAt    *string  `json:"loggedAt"`
+}
+
+func (b workoutBody) create(userId string) WorkoutCreate {
+    when := time.Now(); if b.LoggedAt != nil && *b.LoggedAt != "" { if t, err := time.Parse("2006-01-02", *b.LoggedAt); err == nil { when = t } }
+    w := WorkoutCreate{UserId: userId, Type: b.Type, DurationMin: b.DurationMin, Intensity: b.Intensity, DistanceKm: b.DistanceKm, LoggedAt: when}
+    if b.Calories != nil { w.Calories = *b.Calories }
+    return w
+}
+
+func (c *Controller) CreateWorkout(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var body workoutBody
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    res, err := c.service.CreateWorkout(context.Background(), body.create(u.Id))
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusCreated); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) UpdateWorkout(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var body workoutBody
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    in := body.create(u.Id)
+    res, err := c.service.UpdateWorkout(context.Background(), Workout{Id: body.Id, UserId: u.Id, Type: in.Type, DurationMin: in.DurationMin, Intensity: in.Intensity, DistanceKm: in.DistanceKm, Calories: in.Calories, LoggedAt: in.LoggedAt})
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) DeleteWorkout(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    idStr := chi.URLParam(r, "id"); if idStr == "" { http.Error(w, "missing id", http.StatusBadRequest); return }
+    id, err := strconv.ParseInt(idStr, 10, 64); if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if err := c.service.DeleteWorkout(context.Background(), id, u.Id); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) GetWorkouts(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var from, to *time.Time
+    if s := r.URL.Query().Get("from"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { from = &t } }
+    if s := r.URL.Query().Get("to"); s != "" { if t, err := time.Parse("2006-01-02", s); err == nil { to = &t } }
+    res, err := c.service.GetWorkouts(context.Background(), u.Id, from, to)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// ===== Health app import =====
+func (c *Controller) ImportHealth(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+    file, header, err := r.FormFile("file")
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    defer file.Close()
+    source := r.FormValue("source")
+    if source != "" && source != ImportSourceApple && source != ImportSourceGoogle { http.Error(w, "source must be apple or google", http.StatusBadRequest); return }
+    loc := time.UTC
+    if tz := r.FormValue("tz"); tz != "" {
+        l, err := time.LoadLocation(tz); if err != nil { http.Error(w, "unknown tz", http.StatusBadRequest); return }
+        loc = l
+    }
+    res, err := c.service.ImportHealth(r.Context(), u.Id, source, header.Filename, file, header.Size, loc)
+    if err != nil { logger.Error("Error import health data", "error", err); http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// ImportScale — CSV умных весов; dryRun=true только показывает, что будет записано.
+func (c *Controller) ImportScale(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    r.Body = http.MaxBytesReader(w, r.Body, MaxScaleImportBytes+1<<20)
+    file, _, err := r.FormFile("file")
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    defer file.Close()
+    opts := ScaleImportOptions{Preset: r.FormValue("preset"), Aggregate: r.FormValue("aggregate")}
+    opts.Overwrite, _ = strconv.ParseBool(r.FormValue("overwrite"))
+    opts.DryRun, _ = strconv.ParseBool(r.FormValue("dryRun"))
+    if v := r.FormValue("mapping"); v != "" {
+        var m ScaleMapping
+        if err := json.Unmarshal([]byte(v), &m); err != nil { http.Error(w, "invalid mapping: "+err.Error(), http.StatusBadRequest); return }
+        opts.Mapping = &m
+    }
+    if tz := r.FormValue("tz"); tz != "" {
+        l, err := time.LoadLocation(tz); if err != nil { http.Error(w, "unknown tz", http.StatusBadRequest); return }
+        opts.Loc = l
+    }
+    res, err := c.service.ImportScale(r.Context(), u.Id, file, opts)
+    if errors.Is(err, ErrScaleInput) { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if err != nil { logger.Error("Error import scale csv", "error", err); http.Error(w, err.Error(), http.StatusInternalServerError); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
 func (c *Controller) GetPlateauHistory(w http.ResponseWriter, r *http.Request) {
     u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
     var from, to *time.Time
@@ -227,3 +496,85 @@ func (c *Controller) GetPlateauHistory(w http.ResponseWriter, r *http.Request) {
     if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
     w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
 }
+
+func (c *Controller) AcceptRecommendation(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64); if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    res, err := c.service.AcceptRecommendation(context.Background(), u.Id, id, chi.URLParam(r, "code"))
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// ===== Plateau params =====
+func (c *Controller) GetPlateauParams(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    res, err := c.service.ResolvePlateauParams(context.Background(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) SetUserPlateauParams(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    var body json.RawMessage
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if err := c.service.SetPlateauParams(context.Background(), ParamsScopeUser, nil, &u.Id, body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    res, err := c.service.ResolvePlateauParams(context.Background(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) ResetUserPlateauParams(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    if err := c.service.ResetUserPlateauParams(context.Background(), u.Id); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) SetGlobalPlateauParams(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    if !u.IsAdmin { http.Error(w, "forbidden", http.StatusForbidden); return }
+    var body json.RawMessage
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if err := c.service.SetPlateauParams(context.Background(), ParamsScopeGlobal, nil, nil, body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+}
+
+func (c *Controller) SetPlanPlateauParams(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    if !u.IsAdmin { http.Error(w, "forbidden", http.StatusForbidden); return }
+    planId, err := strconv.ParseInt(chi.URLParam(r, "planId"), 10, 64); if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    var body json.RawMessage
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    if err := c.service.SetPlateauParams(context.Background(), ParamsScopePlan, &planId, nil, body); err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK)
+}
+
+// ===== Adaptive expenditure =====
+func (c *Controller) GetExpenditure(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    res, err := c.service.EstimateExpenditure(context.Background(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+func (c *Controller) ApplyExpenditure(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    res, err := c.service.ApplyExpenditure(context.Background(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// ===== Goal forecast =====
+func (c *Controller) GetForecast(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    res, err := c.service.ForecastGoal(context.Background(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
+
+// ===== Safety guardrails =====
+func (c *Controller) GetGuardrails(w http.ResponseWriter, r *http.Request) {
+    u, ok := auth.UserFromContext(r.Context()); if !ok { http.Error(w, "unauthorized", http.StatusUnauthorized); return }
+    res, err := c.service.CheckGuardrails(context.Background(), u.Id)
+    if err != nil { http.Error(w, err.Error(), http.StatusBadRequest); return }
+    w.WriteHeader(http.StatusOK); _ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,129 @@
+package body
+
+import (
+    "context"
+    "errors"
+    "math"
+
+    "github.com/jourloy/nutri-backend/internal/database"
+    "github.com/jourloy/nutri-backend/internal/fit"
+)
+
+const (
+    expenditureWindowDays = 28
+    // minimal difference with the current target worth applying
+    expenditureMinChange = 50.0
+)
+
+const (
+    ConfidenceNone   = "none"
+    ConfidenceLow    = "low"
+    ConfidenceMedium = "medium"
+    ConfidenceHigh   = "high"
+)
+
+// ExpenditureEstimate — adaptive TDEE estimate: average logged intake corrected
+// by the energy equivalent of the smoothed weight change over the window.
+type ExpenditureEstimate struct {
+    WindowStart        string      `json:"windowStart"`
+    WindowEnd          string      `json:"windowEnd"`
+    WindowDays         int         `json:"windowDays"`
+    IntakeDays         int         `json:"intakeDays"`
+    WeightDays         int         `json:"weightDays"`
+    AvgIntake          float64     `json:"avgIntake"`
+    WeightChangeWeekly float64     `json:"weightChangeWeeklyKg"`
+    TDEE               *float64    `json:"tdee,omitempty"`
+    FormulaTDEE        *float64    `json:"formulaTdee,omitempty"`
+    Confidence         string      `json:"confidence"`
+    CurrentCalories    float64     `json:"currentCalories"`
+    Suggested          *fit.Macros `json:"suggested,omitempty"`
+    Reason             string      `json:"reason,omitempty"`
+}
+
+func (s *service) EstimateExpenditure(ctx context.Context, userId string) (*ExpenditureEstimate, error) {
+    // today's intake is incomplete — the window ends yesterday
+    today, err := database.UserToday(ctx, userId)
+    if err != nil { return nil, err }
+    end := today.AddDate(0, 0, -1)
+    start := end.AddDate(0, 0, -expenditureWindowDays+1)
+    res := &ExpenditureEstimate{WindowStart: start.Format("2006-01-02"), WindowEnd: end.Format("2006-01-02"), WindowDays: expenditureWindowDays, Confidence: ConfidenceNone}
+
+    f, err := s.fitService.GetFitProfileByUser(userId)
+    if err != nil { return nil, err }
+    if f != nil {
+        res.CurrentCalories = f.Calories
+        if calc, err := fit.Calculate(fit.ProfileInput(*f)); err == nil {
+            res.FormulaTDEE = &calc.TDEE
+        }
+    }
+
+    ws, err := s.repo.GetWeights(ctx, userId, &start, &end)
+    if err != nil { return nil, err }
+    intake, err := s.repo.GetDailyCalories(ctx, userId, start, end)
+    if err != nil { return nil, err }
+
+    var intakeSum float64
+    for _, v := range intake {
+        if v > 0 { intakeSum += v; res.IntakeDays++ }
+    }
+    res.WeightDays = len(ws)
+    if res.IntakeDays < 7 || res.WeightDays < 4 {
+        res.Reason = "Недостаточно данных: нужно минимум 7 дней питания и 4 взвешивания"
+        return res, nil
+    }
+    res.AvgIntake = intakeSum / float64(res.IntakeDays)
+
+    // smoothed weight against calendar day offsets (gaps keep their real distance)
+    xs := make([]float64, len(ws))
+    raw := make([]float64, len(ws))
+    for i, w := range ws {
+        xs[i] = w.LoggedAt.Sub(start).Hours() / 24
+        raw[i] = w.Value
+    }
+    slopePerDay := olsSlope(xs, ewma(raw, 0.2))
+    res.WeightChangeWeekly = slopePerDay * 7
+
+    tdee := math.Round(res.AvgIntake - slopePerDay*kcalPerKg)
+    res.TDEE = &tdee
+
+    switch {
+    case res.IntakeDays >= 21 && res.WeightDays >= 14:
+        res.Confidence = ConfidenceHigh
+    case res.IntakeDays >= 14 && res.WeightDays >= 8:
+        res.Confidence = ConfidenceMedium
+    default:
+        res.Confidence = ConfidenceLow
+    }
+
+    if f != nil {
+        calories := math.Round(tdee * (1 + fit.GoalAdjustment(f.Goal)))
+        protein, fat, carbs := fit.SplitMacros(calories, f.Weight, f.Goal)
+        res.Suggested = &fit.Macros{Calories: calories, Protein: protein, Fat: fat, Carbs: carbs}
+    }
+    return res, nil
+}
+
+// ApplyExpenditure applies the suggested targets of the current estimate to the fit profile.
+func (s *service) ApplyExpenditure(ctx context.Context, userId string) (*fit.FitProfile, error) {
+    est, err := s.EstimateExpenditure(ctx, userId)
+    if err != nil { return nil, err }
+    if est.Suggested == nil || est.Confidence == ConfidenceNone || est.Confidence == ConfidenceLow {
+        return nil, errors.New("not enough data for a reliable estimate")
+    }
+    return s.fitService.ApplyTargets(ctx, userId, *est.Suggested, fit.SourceAdaptive)
+}
+
+// applyAdaptiveTargets is the weekly job for users with adaptive targets enabled.
+func applyAdaptiveTargets(ctx context.Context, svc *service) {
+    ids, err := svc.fitService.GetAdaptiveUserIds(ctx)
+    if err != nil { bgLogger.Error("load adaptive users", "err", err); return }
+    for _, uid := range ids {
+        est, err := svc.EstimateExpenditure(ctx, uid)
+        if err != nil { bgLogger.Warn("estimate expenditure", "user", uid, "err", err); continue }
+        if est.Suggested == nil || (est.Confidence != ConfidenceMedium && est.Confidence != ConfidenceHigh) { continue }
+        if math.Abs(est.Suggested.Calories-est.CurrentCalories) < expenditureMinChange { continue }
+        if _, err := svc.fitService.ApplyTargets(ctx, uid, *est.Suggested, fit.SourceAdaptive); err != nil {
+            bgLogger.Warn("apply adaptive targets", "user", uid, "err", err)
+        }
+    }
+}
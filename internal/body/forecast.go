@@ -0,0 +1,147 @@
+package body
+
+import (
+    "context"
+    "errors"
+    "math"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/database"
+    "github.com/jourloy/nutri-backend/internal/fit"
+)
+
+const (
+    forecastWindowDays = 28
+    // safe weekly rates as a share of body weight
+    maxSafeLossPct = fit.MaxLossPctPerWeek
+    maxSafeGainPct = 0.5
+)
+
+const (
+    ScheduleAhead      = "ahead"
+    ScheduleOnTrack    = "on_track"
+    ScheduleBehind     = "behind"
+    ScheduleNoTrend    = "no_trend" // вес не движется к цели
+    ScheduleReached    = "reached"
+    ScheduleNoDeadline = "no_deadline"
+)
+
+// GoalForecast — progress towards the goal weight based on the smoothed trend.
+type GoalForecast struct {
+    CurrentWeight      float64  `json:"currentWeight"` // сглаженный вес
+    TargetWeight       float64  `json:"targetWeight"`
+    TargetDate         *string  `json:"targetDate,omitempty"`
+    RemainingKg        float64  `json:"remainingKg"`
+    TrendKgPerWeek     float64  `json:"trendKgPerWeek"`
+    ProjectedDate      *string  `json:"projectedDate,omitempty"`
+    WeeksToGoal        *float64 `json:"weeksToGoal,omitempty"`
+    Schedule           string   `json:"schedule"`
+    ScheduleDiffDays   *int     `json:"scheduleDiffDays,omitempty"` // >0 — раньше срока, <0 — позже
+    RequiredKgPerWeek  *float64 `json:"requiredKgPerWeek,omitempty"`
+    RequiredPctPerWeek *float64 `json:"requiredPctPerWeek,omitempty"`
+    Unsafe             bool     `json:"unsafe"`
+    Warnings           []string `json:"warnings"`
+}
+
+func (s *service) ForecastGoal(ctx context.Context, userId string) (*GoalForecast, error) {
+    f, err := s.fitService.GetFitProfileByUser(userId)
+    if err != nil { return nil, err }
+    if f == nil || f.TargetWeight == nil { return nil, errors.New("target weight is not set") }
+
+    today, err := database.UserToday(ctx, userId)
+    if err != nil { return nil, err }
+    start := today.AddDate(0, 0, -forecastWindowDays+1)
+    ws, err := s.repo.GetWeights(ctx, userId, &start, &today)
+    if err != nil { return nil, err }
+    if len(ws) < 3 { return nil, errors.New("not enough weight entries for a forecast") }
+
+    xs := make([]float64, len(ws))
+    raw := make([]float64, len(ws))
+    for i, w := range ws {
+        xs[i] = w.LoggedAt.Sub(start).Hours() / 24
+        raw[i] = w.Value
+    }
+    sm := ewma(raw, 0.2)
+    slopePerDay := olsSlope(xs, sm)
+    current := sm[len(sm)-1]
+
+    res := &GoalForecast{
+        CurrentWeight:  round2(current),
+        TargetWeight:   *f.TargetWeight,
+        RemainingKg:    round2(*f.TargetWeight - current),
+        TrendKgPerWeek: round2(slopePerDay * 7),
+        Schedule:       ScheduleNoTrend,
+        Warnings:       []string{},
+    }
+    if f.TargetDate != nil { d := f.TargetDate.Format("2006-01-02"); res.TargetDate = &d }
+
+    remaining := *f.TargetWeight - current
+    if math.Abs(remaining) < 0.1 {
+        res.Schedule = ScheduleReached
+        return res, nil
+    }
+
+    // проекция по текущему тренду — только если вес движется в сторону цели
+    var projected *time.Time
+    if slopePerDay != 0 && math.Signbit(slopePerDay) == math.Signbit(remaining) {
+        days := remaining / slopePerDay
+        weeks := round2(days / 7)
+        res.WeeksToGoal = &weeks
+        p := today.AddDate(0, 0, int(math.Ceil(days)))
+        projected = &p
+        ps := p.Format("2006-01-02")
+        res.ProjectedDate = &ps
+    } else {
+        res.Warnings = append(res.Warnings, "Вес не движется в сторону цели")
+    }
+
+    if f.TargetDate == nil {
+        if projected != nil { res.Schedule = ScheduleNoDeadline }
+        res.Unsafe = isUnsafeRate(slopePerDay*7, current, &res.Warnings)
+        return res, nil
+    }
+
+    deadline := f.TargetDate.Truncate(24 * time.Hour)
+    daysLeft := deadline.Sub(today).Hours() / 24
+    if daysLeft <= 0 {
+        res.Warnings = append(res.Warnings, "Срок достижения цели уже прошёл")
+    } else {
+        reqWeekly := remaining / daysLeft * 7
+        reqPct := math.Abs(reqWeekly) / current * 100
+        reqWeekly, reqPct = round2(reqWeekly), round2(reqPct)
+        res.RequiredKgPerWeek = &reqWeekly
+        res.RequiredPctPerWeek = &reqPct
+        res.Unsafe = isUnsafeRate(reqWeekly, current, &res.Warnings)
+    }
+
+    if projected != nil {
+        diff := int(deadline.Sub(*projected).Hours() / 24)
+        res.ScheduleDiffDays = &diff
+        switch {
+        case diff > 3:
+            res.Schedule = ScheduleAhead
+        case diff < -3:
+            res.Schedule = ScheduleBehind
+        default:
+            res.Schedule = ScheduleOnTrack
+        }
+    }
+    return res, nil
+}
+
+// isUnsafeRate checks a weekly rate (kg, signed) against safe limits and appends a warning.
+func isUnsafeRate(weekly, weight float64, warnings *[]string) bool {
+    if weight <= 0 { return false }
+    pct := math.Abs(weekly) / weight * 100
+    if weekly < 0 && pct > maxSafeLossPct {
+        *warnings = append(*warnings, "Темп снижения веса больше 1% в неделю — это небезопасно")
+        return true
+    }
+    if weekly > 0 && pct > maxSafeGainPct {
+        *warnings = append(*warnings, "Темп набора веса больше 0.5% в неделю — вероятен набор жира")
+        return true
+    }
+    return false
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,105 @@
+package body
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/database"
+    "github.com/jourloy/nutri-backend/internal/fit"
+    "github.com/jourloy/nutri-backend/internal/telegram"
+)
+
+const (
+    guardrailWindowDays = 21
+    // the loss is "sustained" when the recent part of the window is fast as well
+    guardrailRecentDays = 10
+    // logged days below this are treated as very low intake
+    lowIntakeKcal     = 800.0
+    lowIntakeDaysWarn = 2
+    lowIntakeLookback = 7
+    // one notification per warning code within this period
+    guardrailAlertCooldown = 7 * 24 * time.Hour
+)
+
+// GuardrailReport — safety checks over the recent weight trend and logged intake.
+type GuardrailReport struct {
+    WindowStart          string        `json:"windowStart"`
+    WindowEnd            string        `json:"windowEnd"`
+    LossPctPerWeek       *float64      `json:"lossPctPerWeek,omitempty"`       // за всё окно
+    RecentLossPctPerWeek *float64      `json:"recentLossPctPerWeek,omitempty"` // за последние дни окна
+    LowIntakeDays        []string      `json:"lowIntakeDays"`
+    Warnings             []fit.Warning `json:"warnings"`
+}
+
+func (s *service) CheckGuardrails(ctx context.Context, userId string) (*GuardrailReport, error) {
+    end, err := database.UserToday(ctx, userId)
+    if err != nil { return nil, err }
+    start := end.AddDate(0, 0, -guardrailWindowDays+1)
+    res := &GuardrailReport{WindowStart: start.Format("2006-01-02"), WindowEnd: end.Format("2006-01-02"), LowIntakeDays: []string{}, Warnings: []fit.Warning{}}
+
+    ws, err := s.repo.GetWeights(ctx, userId, &start, &end)
+    if err != nil { return nil, err }
+    full := lossPctPerWeek(ws, start)
+    recentStart := end.AddDate(0, 0, -guardrailRecentDays+1)
+    var recent []Weight
+    for _, w := range ws { if !w.LoggedAt.Before(recentStart) { recent = append(recent, w) } }
+    last := lossPctPerWeek(recent, recentStart)
+    res.LossPctPerWeek, res.RecentLossPctPerWeek = full, last
+    if full != nil && last != nil && *full > fit.MaxLossPctPerWeek && *last > fit.MaxLossPctPerWeek {
+        res.Warnings = append(res.Warnings, fit.Warning{
+            Code:    fit.WarningFastLoss,
+            Message: fmt.Sprintf("Вес снижается на %.1f%% в неделю — быстрее безопасных %.0f%%", *full, fit.MaxLossPctPerWeek),
+        })
+    }
+
+    // today's log is incomplete, so low intake is checked up to yesterday
+    intakeEnd := end.AddDate(0, 0, -1)
+    intakeStart := intakeEnd.AddDate(0, 0, -lowIntakeLookback+1)
+    intake, err := s.repo.GetDailyCalories(ctx, userId, intakeStart, intakeEnd)
+    if err != nil { return nil, err }
+    for d := intakeStart; !d.After(intakeEnd); d = d.AddDate(0, 0, 1) {
+        day := d.Format("2006-01-02")
+        if v := intake[day]; v > 0 && v < lowIntakeKcal { res.LowIntakeDays = append(res.LowIntakeDays, day) }
+    }
+    if len(res.LowIntakeDays) >= lowIntakeDaysWarn {
+        res.Warnings = append(res.Warnings, fit.Warning{
+            Code:    fit.WarningLowIntake,
+            Message: fmt.Sprintf("За последнюю неделю %d дн. с питанием меньше %.0f ккал", len(res.LowIntakeDays), lowIntakeKcal),
+        })
+    }
+    return res, nil
+}
+
+// lossPctPerWeek returns the smoothed weekly loss in % of body weight (nil with fewer than 4 weights).
+func lossPctPerWeek(ws []Weight, start time.Time) *float64 {
+    if len(ws) < 4 { return nil }
+    xs := make([]float64, len(ws))
+    raw := make([]float64, len(ws))
+    for i, w := range ws {
+        xs[i] = w.LoggedAt.Sub(start).Hours() / 24
+        raw[i] = w.Value
+    }
+    sm := ewma(raw, 0.2)
+    pct := round2(-olsSlope(xs, sm) * 7 / mean(sm) * 100)
+    return &pct
+}
+
+// notifyGuardrails sends new warnings to telegram for users who opted in.
+func notifyGuardrails(ctx context.Context, svc *service, tg telegram.Service, userId string) {
+    report, err := svc.CheckGuardrails(ctx, userId)
+    if err != nil || len(report.Warnings) == 0 { return }
+    tp, err := tg.GetByUserId(ctx, userId)
+    if err != nil || tp == nil || tp.TelegramId == nil || !tp.NotifyHealth { return }
+    for _, w := range report.Warnings {
+        sent, err := svc.repo.HasRecentGuardrailAlert(ctx, userId, w.Code, time.Now().Add(-guardrailAlertCooldown))
+        if err != nil || sent { continue }
+        if err := tg.SendMessage(ctx, *tp.TelegramId, "⚠️ "+w.Message); err != nil {
+            bgLogger.Warn("send guardrail alert", "user", userId, "err", err)
+            continue
+        }
+        if err := svc.repo.CreateGuardrailAlert(ctx, userId, w.Code); err != nil {
+            bgLogger.Warn("save guardrail alert", "user", userId, "err", err)
+        }
+    }
+}
@@ -0,0 +1,250 @@
+package body
+
+import (
+    "archive/zip"
+    "context"
+    "errors"
+    "io"
+    "path"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    ImportSourceApple  = "apple"
+    ImportSourceGoogle = "google"
+
+    WorkoutSourceImport = "import"
+
+    // MaxImportBytes limits the uploaded export file.
+    MaxImportBytes = 512 << 20
+    // maxImportEntryBytes limits a single unpacked archive entry (export.xml may be large).
+    maxImportEntryBytes = 2 << 30
+)
+
+// ImportStats — итоги импорта из приложения здоровья.
+type ImportStats struct {
+    Source          string `json:"source"`          // apple | google
+    Records         int    `json:"records"`         // разобранные записи
+    Skipped         int    `json:"skipped"`         // нераспознанные и некорректные записи
+    StepDays        int    `json:"stepDays"`
+    SleepDays       int    `json:"sleepDays"`
+    ActivityDays    int    `json:"activityDays"`    // обновлённые дни body_activity
+    Weights         int    `json:"weights"`         // обновлённые дни body_weights
+    Workouts        int    `json:"workouts"`        // новые тренировки
+    WorkoutsSkipped int    `json:"workoutsSkipped"` // уже импортированные или некорректные
+    From            string `json:"from,omitempty"`
+    To              string `json:"to,omitempty"`
+}
+
+// healthData — per-day values collected by a parser before they are stored.
+type healthData struct {
+    steps    map[string]map[string]float64 // day -> source -> steps
+    sleep    map[string]map[string]float64 // day -> source -> minutes
+    weights  map[string]datedWeight        // day -> latest reading
+    workouts []importedWorkout
+    records  int
+    skipped  int
+}
+
+type datedWeight struct {
+    at    time.Time
+    value float64
+}
+
+type importedWorkout struct {
+    externalId  string
+    kind        string
+    start       time.Time
+    durationMin float64
+    distanceKm  *float64
+    calories    float64
+}
+
+func newHealthData() *healthData {
+    return &healthData{steps: map[string]map[string]float64{}, sleep: map[string]map[string]float64{}, weights: map[string]datedWeight{}}
+}
+
+func (h *healthData) addSteps(day, source string, v float64) {
+    if h.steps[day] == nil { h.steps[day] = map[string]float64{} }
+    h.steps[day][source] += v
+}
+
+func (h *healthData) addSleep(day, source string, minutes float64) {
+    if h.sleep[day] == nil { h.sleep[day] = map[string]float64{} }
+    h.sleep[day][source] += minutes
+}
+
+func (h *healthData) addWeight(at time.Time, day string, kg float64) {
+    if kg < 20 || kg > 400 { h.skipped++; return }
+    if cur, ok := h.weights[day]; ok && cur.at.After(at) { return }
+    h.weights[day] = datedWeight{at: at, value: kg}
+}
+
+// maxBySource picks the largest per-source total: phone and watch report the same
+// steps and sleep, so summing sources would count them twice.
+func maxBySource(m map[string]float64) float64 {
+    var best float64
+    for _, v := range m { if v > best { best = v } }
+    return best
+}
+
+// ImportHealth parses an Apple Health export (export.xml or export.zip) or a Google
+// Takeout archive and upserts steps, sleep, weights and workouts per day.
+// loc is used for sources that store UTC timestamps (Google Fit).
+func (s *service) ImportHealth(ctx context.Context, userId, source, filename string, file io.ReaderAt, size int64, loc *time.Location) (*ImportStats, error) {
+    if loc == nil { loc = time.UTC }
+    var (
+        data *healthData
+        err  error
+    )
+    if strings.EqualFold(path.Ext(filename), ".xml") {
+        if source == "" { source = ImportSourceApple }
+        if source != ImportSourceApple { return nil, errors.New("xml file is only supported for apple health") }
+        data, err = parseAppleHealth(io.NewSectionReader(file, 0, size))
+    } else {
+        zr, zerr := zip.NewReader(file, size)
+        if zerr != nil { return nil, errors.New("file must be export.xml or a zip archive") }
+        if source == "" { source = detectImportSource(zr) }
+        switch source {
+        case ImportSourceApple:
+            data, err = parseAppleHealthZip(zr)
+        case ImportSourceGoogle:
+            data, err = parseGoogleTakeout(zr, loc)
+        default:
+            return nil, errors.New("unknown export format")
+        }
+    }
+    if err != nil { return nil, err }
+
+    stats := &ImportStats{Source: source, Records: data.records, Skipped: data.skipped}
+    if err := s.storeHealthData(ctx, userId, data, stats); err != nil { return stats, err }
+    return stats, nil
+}
+
+func detectImportSource(zr *zip.Reader) string {
+    for _, f := range zr.File {
+        if path.Base(f.Name) == "export.xml" { return ImportSourceApple }
+        if strings.Contains(f.Name, "Fit/") { return ImportSourceGoogle }
+    }
+    return ""
+}
+
+// openZipEntry opens an archive entry with a size limit against zip bombs.
+func openZipEntry(f *zip.File) (io.ReadCloser, error) {
+    rc, err := f.Open()
+    if err != nil { return nil, err }
+    return struct {
+        io.Reader
+        io.Closer
+    }{io.LimitReader(rc, maxImportEntryBytes), rc}, nil
+}
+
+// storeHealthData writes weights first, so workouts without device calories
+// are estimated with the imported weight. All rows are written in one transaction.
+func (s *service) storeHealthData(ctx context.Context, userId string, data *healthData, stats *ImportStats) error {
+    days := map[string]struct{}{}
+    err := s.repo.WithTx(ctx, func(repo Repository) error { return s.storeHealthRows(ctx, repo, userId, data, stats, days) })
+    if err != nil { return err }
+
+    if len(days) > 0 {
+        keys := make([]string, 0, len(days))
+        for d := range days { keys = append(keys, d) }
+        sort.Strings(keys)
+        stats.From, stats.To = keys[0], keys[len(keys)-1]
+    }
+    if stats.Weights > 0 { s.syncProfileWeight(ctx, userId) }
+    return nil
+}
+
+func (s *service) storeHealthRows(ctx context.Context, repo Repository, userId string, data *healthData, stats *ImportStats, days map[string]struct{}) error {
+
+    for day, w := range data.weights {
+        t, _ := time.Parse("2006-01-02", day)
+        if _, err := repo.CreateWeight(ctx, WeightCreate{UserId: userId, Value: round2(w.value), LoggedAt: t}); err != nil { return err }
+        stats.Weights++
+        days[day] = struct{}{}
+    }
+
+    activity := map[string]*ActivityCreate{}
+    for day, bySource := range data.steps {
+        v := int(maxBySource(bySource) + 0.5)
+        if v <= 0 { continue }
+        activityDay(activity, userId, day).Steps = &v
+        stats.StepDays++
+    }
+    for day, bySource := range data.sleep {
+        v := int(maxBySource(bySource) + 0.5)
+        if v <= 0 { continue }
+        activityDay(activity, userId, day).SleepMin = &v
+        stats.SleepDays++
+    }
+    for day, a := range activity {
+        if _, err := repo.CreateActivity(ctx, *a); err != nil { return err }
+        stats.ActivityDays++
+        days[day] = struct{}{}
+    }
+
+    weights, err := s.loadWeights(ctx, repo, userId)
+    if err != nil { return err }
+    for _, iw := range data.workouts {
+        ext := iw.externalId
+        w := WorkoutCreate{UserId: userId, Type: iw.kind, DurationMin: int(iw.durationMin + 0.5), Intensity: IntensityModerate,
+            DistanceKm: iw.distanceKm, Calories: iw.calories, LoggedAt: dayStart(iw.start), ExternalId: &ext}
+        if err := s.prepareWorkout(&w, weights); err != nil { stats.WorkoutsSkipped++; continue }
+        if iw.calories > 0 { w.Source = WorkoutSourceImport }
+        inserted, err := repo.ImportWorkout(ctx, w)
+        if err != nil { return err }
+        if !inserted { stats.WorkoutsSkipped++; continue }
+        stats.Workouts++
+        days[w.LoggedAt.Format("2006-01-02")] = struct{}{}
+    }
+    return nil
+}
+
+func activityDay(m map[string]*ActivityCreate, userId, day string) *ActivityCreate {
+    if a, ok := m[day]; ok { return a }
+    t, _ := time.Parse("2006-01-02", day)
+    a := &ActivityCreate{UserId: userId, LoggedAt: t}
+    m[day] = a
+    return a
+}
+
+// dayStart drops the time of day but keeps the calendar date of t's location.
+func dayStart(t time.Time) time.Time {
+    return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// workoutKind maps an exported activity name to a workoutMET key ("" — not a workout).
+func workoutKind(name string) string {
+    n := strings.ToLower(name)
+    switch {
+    case strings.Contains(n, "sleep"), n == "still", n == "in_vehicle", n == "unknown", n == "tilting":
+        return ""
+    case strings.Contains(n, "hiit"), strings.Contains(n, "highintensity"), strings.Contains(n, "high_intensity"):
+        return "hiit"
+    case strings.Contains(n, "walk"):
+        return "walking"
+    case strings.Contains(n, "hik"):
+        return "hiking"
+    case strings.Contains(n, "run"):
+        return "running"
+    case strings.Contains(n, "cycl"), strings.Contains(n, "biking"):
+        return "cycling"
+    case strings.Contains(n, "swim"):
+        return "swimming"
+    case strings.Contains(n, "row"):
+        return "rowing"
+    case strings.Contains(n, "elliptical"):
+        return "elliptical"
+    case strings.Contains(n, "strength"), strings.Contains(n, "weight"):
+        return "strength"
+    case strings.Contains(n, "yoga"), strings.Contains(n, "pilates"):
+        return "yoga"
+    case strings.Contains(n, "soccer"), strings.Contains(n, "football"), strings.Contains(n, "basketball"),
+        strings.Contains(n, "volleyball"), strings.Contains(n, "hockey"), strings.Contains(n, "handball"):
+        return "team_sports"
+    }
+    return "other"
+}
@@ -0,0 +1,184 @@
+package body
+
+import (
+    "archive/zip"
+    "encoding/xml"
+    "errors"
+    "io"
+    "path"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Apple Health export.xml (Экспорт всех данных о здоровье).
+const (
+    appleDateLayout = "2006-01-02 15:04:05 -0700"
+
+    appleStepCount = "HKQuantityTypeIdentifierStepCount"
+    appleBodyMass  = "HKQuantityTypeIdentifierBodyMass"
+    appleSleep     = "HKCategoryTypeIdentifierSleepAnalysis"
+    appleEnergy    = "HKQuantityTypeIdentifierActiveEnergyBurned"
+)
+
+type appleRecord struct {
+    Type       string `xml:"type,attr"`
+    SourceName string `xml:"sourceName,attr"`
+    Unit       string `xml:"unit,attr"`
+    Value      string `xml:"value,attr"`
+    StartDate  string `xml:"startDate,attr"`
+    EndDate    string `xml:"endDate,attr"`
+}
+
+type appleWorkout struct {
+    ActivityType      string  `xml:"workoutActivityType,attr"`
+    Duration          float64 `xml:"duration,attr"`
+    DurationUnit      string  `xml:"durationUnit,attr"`
+    TotalDistance     float64 `xml:"totalDistance,attr"`
+    TotalDistanceUnit string  `xml:"totalDistanceUnit,attr"`
+    TotalEnergy       float64 `xml:"totalEnergyBurned,attr"`
+    TotalEnergyUnit   string  `xml:"totalEnergyBurnedUnit,attr"`
+    StartDate         string  `xml:"startDate,attr"`
+    EndDate           string  `xml:"endDate,attr"`
+    Statistics        []struct {
+        Type string  `xml:"type,attr"`
+        Sum  float64 `xml:"sum,attr"`
+        Unit string  `xml:"unit,attr"`
+    } `xml:"WorkoutStatistics"`
+}
+
+func parseAppleHealthZip(zr *zip.Reader) (*healthData, error) {
+    for _, f := range zr.File {
+        if path.Base(f.Name) != "export.xml" { continue }
+        rc, err := openZipEntry(f)
+        if err != nil { return nil, err }
+        defer rc.Close()
+        return parseAppleHealth(rc)
+    }
+    return nil, errors.New("export.xml not found in archive")
+}
+
+// parseAppleHealth streams export.xml: the file is often hundreds of megabytes.
+func parseAppleHealth(r io.Reader) (*healthData, error) {
+    data := newHealthData()
+    dec := xml.NewDecoder(r)
+    seenRoot := false
+    for {
+        tok, err := dec.Token()
+        if err == io.EOF { break }
+        if err != nil { return nil, err }
+        el, ok := tok.(xml.StartElement)
+        if !ok { continue }
+        switch el.Name.Local {
+        case "HealthData":
+            seenRoot = true
+        case "Record":
+            var rec appleRecord
+            if err := dec.DecodeElement(&rec, &el); err != nil { return nil, err }
+            addAppleRecord(data, rec)
+        case "Workout":
+            var w appleWorkout
+            if err := dec.DecodeElement(&w, &el); err != nil { return nil, err }
+            addAppleWorkout(data, w)
+        }
+    }
+    if !seenRoot { return nil, errors.New("not an apple health export") }
+    return data, nil
+}
+
+func addAppleRecord(data *healthData, rec appleRecord) {
+    switch rec.Type {
+    case appleStepCount, appleBodyMass, appleSleep:
+    default:
+        return
+    }
+    data.records++
+    start, err1 := time.Parse(appleDateLayout, rec.StartDate)
+    end, err2 := time.Parse(appleDateLayout, rec.EndDate)
+    if err1 != nil || err2 != nil { data.skipped++; return }
+
+    switch rec.Type {
+    case appleStepCount:
+        v, err := strconv.ParseFloat(rec.Value, 64)
+        if err != nil || v < 0 { data.skipped++; return }
+        data.addSteps(start.Format("2006-01-02"), rec.SourceName, v)
+    case appleBodyMass:
+        v, err := strconv.ParseFloat(rec.Value, 64)
+        if err != nil { data.skipped++; return }
+        kg, ok := massToKg(v, rec.Unit)
+        if !ok { data.skipped++; return }
+        data.addWeight(start, start.Format("2006-01-02"), kg)
+    case appleSleep:
+        // InBed и Awake — не сон; остальные значения (Asleep, AsleepCore/Deep/REM) суммируются
+        if !strings.Contains(rec.Value, "Asleep") { return }
+        // сон относится к дню пробуждения
+        data.addSleep(end.Format("2006-01-02"), rec.SourceName, end.Sub(start).Minutes())
+    }
+}
+
+func addAppleWorkout(data *healthData, w appleWorkout) {
+    data.records++
+    kind := workoutKind(strings.TrimPrefix(w.ActivityType, "HKWorkoutActivityType"))
+    if kind == "" { return }
+    start, err := time.Parse(appleDateLayout, w.StartDate)
+    if err != nil { data.skipped++; return }
+
+    minutes := durationToMin(w.Duration, w.DurationUnit)
+    if minutes <= 0 {
+        if end, err := time.Parse(appleDateLayout, w.EndDate); err == nil { minutes = end.Sub(start).Minutes() }
+    }
+    iw := importedWorkout{externalId: "apple:" + w.StartDate + ":" + w.ActivityType, kind: kind, start: start, durationMin: minutes}
+    if w.TotalEnergy > 0 { iw.calories = energyToKcal(w.TotalEnergy, w.TotalEnergyUnit) }
+    if w.TotalDistance > 0 { if km, ok := distanceToKm(w.TotalDistance, w.TotalDistanceUnit); ok { iw.distanceKm = &km } }
+    // новые экспорты хранят итоги в WorkoutStatistics
+    for _, st := range w.Statistics {
+        switch {
+        case st.Type == appleEnergy && iw.calories == 0:
+            iw.calories = energyToKcal(st.Sum, st.Unit)
+        case strings.HasPrefix(st.Type, "HKQuantityTypeIdentifierDistance") && iw.distanceKm == nil:
+            if km, ok := distanceToKm(st.Sum, st.Unit); ok && km > 0 { iw.distanceKm = &km }
+        }
+    }
+    data.workouts = append(data.workouts, iw)
+}
+
+func massToKg(v float64, unit string) (float64, bool) {
+    switch strings.ToLower(unit) {
+    case "kg", "":
+        return v, true
+    case "lb", "lbs":
+        return v * 0.45359237, true
+    case "g":
+        return v / 1000, true
+    case "st":
+        return v * 6.35029318, true
+    }
+    return 0, false
+}
+
+func distanceToKm(v float64, unit string) (float64, bool) {
+    switch strings.ToLower(unit) {
+    case "km", "":
+        return v, true
+    case "m":
+        return v / 1000, true
+    case "mi":
+        return v * 1.609344, true
+    }
+    return 0, false
+}
+
+func durationToMin(v float64, unit string) float64 {
+    switch strings.ToLower(unit) {
+    case "s", "sec":
+        return v / 60
+    case "hr", "h":
+        return v * 60
+    }
+    return v
+}
+
+func energyToKcal(v float64, unit string) float64 {
+    if strings.EqualFold(unit, "kJ") { return v / 4.184 }
+    return v // kcal, Cal
+}
@@ -0,0 +1,141 @@
+package body
+
+import (
+    "archive/zip"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "io"
+    "path"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Google Takeout, папка Fit: дневные метрики (CSV), сессии и точки веса (JSON).
+
+type googleSession struct {
+    FitnessActivity string `json:"fitnessActivity"`
+    StartTime       string `json:"startTime"`
+    EndTime         string `json:"endTime"`
+    Duration        string `json:"duration"` // "1800.000s"
+    Aggregate       []struct {
+        MetricName string   `json:"metricName"`
+        FloatValue *float64 `json:"floatValue"`
+    } `json:"aggregate"`
+}
+
+type googleDataFile struct {
+    DataPoints []struct {
+        StartTimeNanos int64 `json:"startTimeNanos"`
+        FitValue       []struct {
+            Value struct {
+                FpVal *float64 `json:"fpVal"`
+            } `json:"value"`
+        } `json:"fitValue"`
+    } `json:"Data Points"`
+}
+
+func parseGoogleTakeout(zr *zip.Reader, loc *time.Location) (*healthData, error) {
+    data := newHealthData()
+    found := false
+    for _, f := range zr.File {
+        if !strings.Contains(f.Name, "Fit/") || f.FileInfo().IsDir() { continue }
+        dir, base := path.Base(path.Dir(f.Name)), path.Base(f.Name)
+        var parse func(io.Reader) error
+        switch {
+        case dir == "Daily activity metrics" && strings.HasSuffix(base, ".csv"):
+            parse = func(r io.Reader) error { return parseGoogleDailyMetrics(r, data) }
+        case dir == "All Sessions" && strings.HasSuffix(base, ".json"):
+            parse = func(r io.Reader) error { return parseGoogleSession(r, data, loc) }
+        case dir == "All Data" && strings.Contains(base, "com.google.weight") && strings.HasSuffix(base, ".json"):
+            parse = func(r io.Reader) error { return parseGoogleWeights(r, data, loc) }
+        default:
+            continue
+        }
+        found = true
+        rc, err := openZipEntry(f)
+        if err != nil { return nil, err }
+        err = parse(rc)
+        rc.Close()
+        if err != nil { return nil, errors.New(base + ": " + err.Error()) }
+    }
+    if !found { return nil, errors.New("no google fit data found in archive") }
+    return data, nil
+}
+
+// parseGoogleDailyMetrics reads the summary CSV with Date and Step count columns;
+// per-day interval files have no Date column and are skipped.
+func parseGoogleDailyMetrics(r io.Reader, data *healthData) error {
+    cr := csv.NewReader(r)
+    cr.FieldsPerRecord = -1
+    header, err := cr.Read()
+    if err == io.EOF { return nil }
+    if err != nil { return err }
+    dateCol, stepsCol := -1, -1
+    for i, h := range header {
+        switch strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")) {
+        case "Date":
+            dateCol = i
+        case "Step count":
+            stepsCol = i
+        }
+    }
+    if dateCol < 0 || stepsCol < 0 { return nil }
+    for {
+        row, err := cr.Read()
+        if err == io.EOF { return nil }
+        if err != nil { return err }
+        if len(row) <= dateCol || len(row) <= stepsCol || row[stepsCol] == "" { continue }
+        data.records++
+        day, err := time.Parse("2006-01-02", row[dateCol])
+        if err != nil { data.skipped++; continue }
+        v, err := strconv.ParseFloat(row[stepsCol], 64)
+        if err != nil || v < 0 { data.skipped++; continue }
+        data.addSteps(day.Format("2006-01-02"), "google_fit", v)
+    }
+}
+
+func parseGoogleSession(r io.Reader, data *healthData, loc *time.Location) error {
+    var s googleSession
+    if err := json.NewDecoder(r).Decode(&s); err != nil { return err }
+    data.records++
+    start, err1 := time.Parse(time.RFC3339, s.StartTime)
+    end, err2 := time.Parse(time.RFC3339, s.EndTime)
+    if err1 != nil || err2 != nil || !end.After(start) { data.skipped++; return nil }
+    start, end = start.In(loc), end.In(loc)
+
+    if strings.HasPrefix(s.FitnessActivity, "sleep") {
+        data.addSleep(end.Format("2006-01-02"), "google_fit", end.Sub(start).Minutes())
+        return nil
+    }
+    kind := workoutKind(s.FitnessActivity)
+    if kind == "" { return nil }
+
+    minutes := end.Sub(start).Minutes()
+    if d, err := time.ParseDuration(s.Duration); err == nil && d > 0 { minutes = d.Minutes() }
+    iw := importedWorkout{externalId: "google:" + s.StartTime + ":" + s.FitnessActivity, kind: kind, start: start, durationMin: minutes}
+    for _, a := range s.Aggregate {
+        if a.FloatValue == nil { continue }
+        switch a.MetricName {
+        case "com.google.calories.expended":
+            iw.calories = *a.FloatValue
+        case "com.google.distance.delta":
+            if km := *a.FloatValue / 1000; km > 0 { iw.distanceKm = &km }
+        }
+    }
+    data.workouts = append(data.workouts, iw)
+    return nil
+}
+
+func parseGoogleWeights(r io.Reader, data *healthData, loc *time.Location) error {
+    var f googleDataFile
+    if err := json.NewDecoder(r).Decode(&f); err != nil { return err }
+    for _, p := range f.DataPoints {
+        data.records++
+        if len(p.FitValue) == 0 || p.FitValue[0].Value.FpVal == nil || p.StartTimeNanos <= 0 { data.skipped++; continue }
+        at := time.Unix(0, p.StartTimeNanos).In(loc)
+        data.addWeight(at, at.Format("2006-01-02"), *p.FitValue[0].Value.FpVal)
+    }
+    return nil
+}
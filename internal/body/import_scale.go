@@ -0,0 +1,382 @@
+package body
+
+import (
+    "bytes"
+    "context"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/database"
+)
+
+// Импорт CSV умных весов (Zepp/Mi Fit, Withings, Garmin) и произвольного CSV по маппингу колонок.
+const (
+    ScalePresetZepp     = "zepp"
+    ScalePresetWithings = "withings"
+    ScalePresetGarmin   = "garmin"
+    ScalePresetCustom   = "custom"
+
+    AggregateFirst = "first"
+    AggregateLast  = "last"
+    AggregateAvg   = "avg"
+
+    // MaxScaleImportBytes limits the uploaded CSV; years of readings take a few megabytes.
+    MaxScaleImportBytes = 20 << 20
+    scalePreviewDays    = 50
+)
+
+// scaleFields — importable values: weight (kg), bodyFat (%) and circumferences (cm).
+var scaleFields = []string{"weight", "bodyFat", "chest", "waist", "hips", "neck", "arm", "thigh", "calf"}
+
+// ScaleMapping names the CSV columns; empty columns are not imported.
+type ScaleMapping struct {
+    Date       string `json:"date"`
+    Time       string `json:"time,omitempty"`       // отдельная колонка времени (Garmin)
+    DateFormat string `json:"dateFormat,omitempty"` // Go layout; пусто — распознаётся автоматически
+    Weight     string `json:"weight,omitempty"`
+    WeightUnit string `json:"weightUnit,omitempty"` // kg | lb; пусто — по суффиксу значения, иначе kg
+    BodyFat    string `json:"bodyFat,omitempty"`    // %
+    FatMass    string `json:"fatMass,omitempty"`    // масса жира, пересчитывается в % по весу
+    Chest      string `json:"chest,omitempty"`
+    Waist      string `json:"waist,omitempty"`
+    Hips       string `json:"hips,omitempty"`
+    Neck       string `json:"neck,omitempty"`
+    Arm        string `json:"arm,omitempty"`
+    Thigh      string `json:"thigh,omitempty"`
+    Calf       string `json:"calf,omitempty"`
+}
+
+type ScaleImportOptions struct {
+    Preset    string        // zepp | withings | garmin | custom; пусто — по заголовку
+    Mapping   *ScaleMapping // для custom
+    Aggregate string        // first | last | avg, по умолчанию last
+    Overwrite bool          // перезаписывать значения существующих дней
+    DryRun    bool
+    Loc       *time.Location // для меток времени со смещением; по умолчанию — пояс пользователя
+}
+
+// ScaleDay — значения дня после агрегации нескольких взвешиваний.
+type ScaleDay struct {
+    Date     string             `json:"date"`
+    Readings int                `json:"readings"`
+    Values   map[string]float64 `json:"values"`
+    Existing bool               `json:"existing"` // за день уже есть вес или замеры
+}
+
+type ScaleImportResult struct {
+    Preset       string     `json:"preset"`
+    Aggregate    string     `json:"aggregate"`
+    DryRun       bool       `json:"dryRun"`
+    Rows         int        `json:"rows"`
+    Skipped      int        `json:"skipped"` // строки без даты или значений
+    Days         int        `json:"days"`
+    Conflicts    int        `json:"conflicts"`    // дни, где уже были данные
+    Weights      int        `json:"weights"`      // записанные (или будущие при dryRun) дни веса
+    Measurements int        `json:"measurements"` // записанные дни замеров
+    From         string     `json:"from,omitempty"`
+    To           string     `json:"to,omitempty"`
+    Preview      []ScaleDay `json:"preview"` // первые дни
+}
+
+var scalePresets = map[string]ScaleMapping{
+    // Zepp Life / Mi Fit: BODY_*.csv
+    ScalePresetZepp: {Date: "time", Weight: "weight", WeightUnit: "kg", BodyFat: "fatRate"},
+    // Withings: weight.csv; единица веса указана в заголовке
+    ScalePresetWithings: {Date: "Date", Weight: "Weight (kg)", WeightUnit: "kg", FatMass: "Fat mass (kg)"},
+    // Garmin Connect: значения с единицами («80.5 kg», «20.1 %»)
+    ScalePresetGarmin: {Date: "Date", Time: "Time", Weight: "Weight", BodyFat: "Body Fat"},
+}
+
+var scaleDateLayouts = []string{
+    "2006-01-02 15:04:05-0700", "2006-01-02 15:04:05Z07:00", time.RFC3339, "2006-01-02T15:04:05",
+    "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02",
+    "Jan 2, 2006 3:04 PM", "Jan 2, 2006 15:04", "Jan 2, 2006", "2 Jan 2006", "2 Jan 2006 15:04",
+    "02.01.2006 15:04:05", "02.01.2006 15:04", "02.01.2006",
+}
+
+// ErrScaleInput marks errors of the uploaded file and options, as opposed to storage errors.
+var ErrScaleInput = errors.New("invalid scale import")
+
+func scaleInputError(err error) error { return fmt.Errorf("%w: %v", ErrScaleInput, err) }
+
+type scaleReading struct {
+    at     time.Time
+    values map[string]float64
+}
+
+// ImportScale reads a smart-scale CSV, aggregates readings per day and stores weights
+// and measurements (one row per day, as the unique indexes require) in one transaction.
+// Stamps with an offset are moved to opts.Loc, by default the user's saved timezone.
+func (s *service) ImportScale(ctx context.Context, userId string, r io.Reader, opts ScaleImportOptions) (*ScaleImportResult, error) {
+    if opts.Aggregate == "" { opts.Aggregate = AggregateLast }
+    if opts.Aggregate != AggregateFirst && opts.Aggregate != AggregateLast && opts.Aggregate != AggregateAvg {
+        return nil, scaleInputError(errors.New("aggregate must be first, last or avg"))
+    }
+    if opts.Loc == nil {
+        loc, err := database.UserLocation(ctx, userId)
+        if err != nil { return nil, err }
+        opts.Loc = loc
+    }
+    data, err := io.ReadAll(io.LimitReader(r, MaxScaleImportBytes+1))
+    if err != nil { return nil, scaleInputError(err) }
+    if len(data) > MaxScaleImportBytes { return nil, scaleInputError(errors.New("file is too large")) }
+
+    readings, res, err := parseScaleCSV(data, opts)
+    if err != nil { return nil, scaleInputError(err) }
+    days := aggregateScale(readings, opts.Aggregate)
+    res.Days = len(days)
+    if len(days) == 0 { return res, nil }
+    res.From, res.To = days[0].Date, days[len(days)-1].Date
+
+    from, _ := time.Parse("2006-01-02", res.From)
+    to, _ := time.Parse("2006-01-02", res.To)
+    existing, err := s.existingBodyDays(ctx, userId, from, to)
+    if err != nil { return nil, err }
+
+    writes := make([]scaleWrite, 0, len(days))
+    for i := range days {
+        d := &days[i]
+        ex := existing[d.Date]
+        d.Existing = ex.weight || ex.measurement
+        if d.Existing { res.Conflicts++ }
+        _, hasWeight := d.Values["weight"]
+        // без overwrite вес существующего дня не трогаем, замеры лишь дополняем
+        sw := scaleWrite{day: *d, weight: hasWeight && (!ex.weight || opts.Overwrite), measurement: len(d.Values) > 1 || (len(d.Values) == 1 && !hasWeight)}
+        if sw.weight { res.Weights++ }
+        if sw.measurement { res.Measurements++ }
+        writes = append(writes, sw)
+    }
+    if len(days) > scalePreviewDays { res.Preview = days[:scalePreviewDays] } else { res.Preview = days }
+    if opts.DryRun { return res, nil }
+
+    // a failure midway rolls back the whole file, so the import can simply be repeated
+    err = s.repo.WithTx(ctx, func(repo Repository) error {
+        for _, sw := range writes {
+            if err := storeScaleDay(ctx, repo, userId, sw, opts.Overwrite); err != nil { return err }
+        }
+        return nil
+    })
+    if err != nil { return nil, err }
+    if res.Weights > 0 { s.syncProfileWeight(ctx, userId) }
+    return res, nil
+}
+
+// scaleWrite — what is stored for one aggregated day.
+type scaleWrite struct {
+    day                 ScaleDay
+    weight, measurement bool
+}
+
+type existingDay struct {
+    weight, measurement bool
+}
+
+func (s *service) existingBodyDays(ctx context.Context, userId string, from, to time.Time) (map[string]existingDay, error) {
+    res := map[string]existingDay{}
+    ws, err := s.repo.GetWeights(ctx, userId, &from, &to)
+    if err != nil { return nil, err }
+    for _, w := range ws {
+        k := w.LoggedAt.Format("2006-01-02")
+        d := res[k]; d.weight = true; res[k] = d
+    }
+    ms, err := s.repo.GetMeasurements(ctx, userId, &from, &to)
+    if err != nil { return nil, err }
+    for _, m := range ms {
+        k := m.LoggedAt.Format("2006-01-02")
+        d := res[k]; d.measurement = true; res[k] = d
+    }
+    return res, nil
+}
+
+func storeScaleDay(ctx context.Context, repo Repository, userId string, sw scaleWrite, overwrite bool) error {
+    d := sw.day
+    day, _ := time.Parse("2006-01-02", d.Date)
+    if sw.weight {
+        if _, err := repo.CreateWeight(ctx, WeightCreate{UserId: userId, Value: round2(d.Values["weight"]), LoggedAt: day}); err != nil { return err }
+    }
+    if !sw.measurement { return nil }
+    get := func(k string) *float64 { if v, ok := d.Values[k]; ok { v = round2(v); return &v }; return nil }
+    m := MeasurementCreate{UserId: userId, Chest: get("chest"), Waist: get("waist"), Hips: get("hips"), Neck: get("neck"),
+        Arm: get("arm"), Thigh: get("thigh"), Calf: get("calf"), BodyFat: get("bodyFat"), LoggedAt: day}
+    return repo.MergeMeasurement(ctx, m, overwrite)
+}
+
+// parseScaleCSV detects the delimiter and preset and converts rows into readings.
+func parseScaleCSV(data []byte, opts ScaleImportOptions) ([]scaleReading, *ScaleImportResult, error) {
+    data = bytes.TrimPrefix(data, []byte("\ufeff"))
+    firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+    cr := csv.NewReader(bytes.NewReader(data))
+    cr.FieldsPerRecord = -1
+    cr.TrimLeadingSpace = true
+    if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) { cr.Comma = ';' }
+    header, err := cr.Read()
+    if err == io.EOF { return nil, nil, errors.New("file is empty") }
+    if err != nil { return nil, nil, err }
+    cols := map[string]int{}
+    for i, h := range header { cols[strings.ToLower(strings.TrimSpace(h))] = i }
+
+    preset := opts.Preset
+    if preset == "" && opts.Mapping != nil { preset = ScalePresetCustom }
+    if preset == "" { preset = detectScalePreset(cols) }
+    var m ScaleMapping
+    switch preset {
+    case ScalePresetCustom:
+        if opts.Mapping == nil { return nil, nil, errors.New("mapping is required for custom preset") }
+        m = *opts.Mapping
+    case ScalePresetZepp, ScalePresetGarmin, ScalePresetWithings:
+        m = scalePresets[preset]
+        if preset == ScalePresetWithings {
+            // Withings пишет единицы в заголовке: «Weight (lb)»
+            if _, ok := cols["weight (lb)"]; ok { m.Weight, m.WeightUnit, m.FatMass = "Weight (lb)", "lb", "Fat mass (lb)" }
+        }
+    case "":
+        return nil, nil, errors.New("unknown csv format, use a custom mapping")
+    default:
+        return nil, nil, errors.New("preset must be zepp, withings, garmin or custom")
+    }
+
+    col := func(name string) int {
+        if name == "" { return -1 }
+        if i, ok := cols[strings.ToLower(strings.TrimSpace(name))]; ok { return i }
+        return -1
+    }
+    dateCol, timeCol := col(m.Date), col(m.Time)
+    if dateCol < 0 { return nil, nil, errors.New("date column not found: " + m.Date) }
+    fieldCols := map[string]int{}
+    for field, name := range map[string]string{"weight": m.Weight, "bodyFat": m.BodyFat, "chest": m.Chest, "waist": m.Waist,
+        "hips": m.Hips, "neck": m.Neck, "arm": m.Arm, "thigh": m.Thigh, "calf": m.Calf} {
+        if name == "" { continue }
+        i := col(name)
+        if i < 0 { return nil, nil, errors.New("column not found: " + name) }
+        fieldCols[field] = i
+    }
+    fatMassCol, weightCol := col(m.FatMass), col(m.Weight)
+    if len(fieldCols) == 0 { return nil, nil, errors.New("mapping has no value columns") }
+
+    res := &ScaleImportResult{Preset: preset, Aggregate: opts.Aggregate, DryRun: opts.DryRun, Preview: []ScaleDay{}}
+    var readings []scaleReading
+    for {
+        row, err := cr.Read()
+        if err == io.EOF { break }
+        if err != nil { return nil, nil, err }
+        if len(row) == 1 && strings.TrimSpace(row[0]) == "" { continue }
+        res.Rows++
+        cell := func(i int) string { if i < 0 || i >= len(row) { return "" }; return strings.TrimSpace(row[i]) }
+        ds := cell(dateCol)
+        if timeCol >= 0 && cell(timeCol) != "" { ds += " " + cell(timeCol) }
+        at, ok := parseScaleTime(ds, m.DateFormat, opts.Loc)
+        if !ok { res.Skipped++; continue }
+
+        values := map[string]float64{}
+        for field, i := range fieldCols {
+            v, unit, ok := parseScaleValue(cell(i))
+            if !ok { continue }
+            if field == "weight" {
+                if unit == "" { unit = m.WeightUnit }
+                kg, ok := massToKg(v, unit)
+                if !ok || kg < 20 || kg > 400 { continue }
+                v = kg
+            }
+            if !scaleValueValid(field, v) { continue }
+            values[field] = v
+        }
+        if _, ok := values["bodyFat"]; !ok && fatMassCol >= 0 && weightCol >= 0 {
+            // масса жира и вес в одних единицах — процент от них не зависит
+            fm, _, ok1 := parseScaleValue(cell(fatMassCol))
+            w, _, ok2 := parseScaleValue(cell(weightCol))
+            if ok1 && ok2 && scaleValueValid("bodyFat", fm/w*100) { values["bodyFat"] = fm / w * 100 }
+        }
+        if len(values) == 0 { res.Skipped++; continue }
+        readings = append(readings, scaleReading{at: at, values: values})
+    }
+    return readings, res, nil
+}
+
+func detectScalePreset(cols map[string]int) string {
+    has := func(name string) bool { _, ok := cols[name]; return ok }
+    switch {
+    case has("time") && has("weight") && has("fatrate"):
+        return ScalePresetZepp
+    case has("date") && (has("weight (kg)") || has("weight (lb)")):
+        return ScalePresetWithings
+    case has("date") && has("weight") && has("body fat"):
+        return ScalePresetGarmin
+    }
+    return ""
+}
+
+// parseScaleTime returns the reading's local wall clock; stamps with an offset are moved to loc.
+func parseScaleTime(v, layout string, loc *time.Location) (time.Time, bool) {
+    if v == "" { return time.Time{}, false }
+    layouts := scaleDateLayouts
+    if layout != "" { layouts = []string{layout} }
+    for _, l := range layouts {
+        t, err := time.Parse(l, v)
+        if err != nil { continue }
+        if strings.Contains(l, "07") || strings.HasSuffix(l, "Z07:00") { t = t.In(loc) }
+        return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), true
+    }
+    return time.Time{}, false
+}
+
+// parseScaleValue parses "80.5", "80,5", "80.5 kg" or "20.1 %" and returns the unit suffix.
+func parseScaleValue(v string) (float64, string, bool) {
+    v = strings.TrimSpace(v)
+    if v == "" || v == "--" || v == "-" { return 0, "", false }
+    num, unit := v, ""
+    if i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' && r != '-' }); i > 0 {
+        num, unit = strings.TrimSpace(v[:i]), strings.ToLower(strings.TrimSpace(v[i:]))
+    }
+    if strings.Count(num, ",") == 1 && !strings.Contains(num, ".") { num = strings.Replace(num, ",", ".", 1) }
+    f, err := strconv.ParseFloat(num, 64)
+    if err != nil || f <= 0 { return 0, "", false }
+    if unit == "%" { unit = "" }
+    return f, unit, true
+}
+
+func scaleValueValid(field string, v float64) bool {
+    switch field {
+    case "weight":
+        return v >= 20 && v <= 400
+    case "bodyFat":
+        return v >= 2 && v <= 75
+    }
+    return v >= 10 && v <= 300 // обхваты, см
+}
+
+// aggregateScale groups readings by day and picks the first, last or average value of each field.
+func aggregateScale(readings []scaleReading, mode string) []ScaleDay {
+    sort.SliceStable(readings, func(i, j int) bool { return readings[i].at.Before(readings[j].at) })
+    var res []ScaleDay
+    var counts map[string]int
+    for _, r := range readings {
+        key := r.at.Format("2006-01-02")
+        if len(res) == 0 || res[len(res)-1].Date != key {
+            res = append(res, ScaleDay{Date: key, Values: map[string]float64{}})
+            counts = map[string]int{}
+        }
+        d := &res[len(res)-1]
+        d.Readings++
+        for _, f := range scaleFields {
+            v, ok := r.values[f]
+            if !ok { continue }
+            switch {
+            case mode == AggregateAvg:
+                d.Values[f] = (d.Values[f]*float64(counts[f]) + v) / float64(counts[f]+1)
+            case mode == AggregateLast, counts[f] == 0:
+                d.Values[f] = v
+            }
+            counts[f]++
+        }
+    }
+    for i := range res {
+        for f, v := range res[i].Values { res[i].Values[f] = round2(v) }
+    }
+    return res
+}
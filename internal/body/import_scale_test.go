@@ -0,0 +1,101 @@
+package body
+
+import (
+    "math"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestParseScaleCSV(t *testing.T) {
+    tests := []struct {
+        name     string
+        csv      string
+        opts     ScaleImportOptions
+        preset   string
+        rows     int
+        skipped  int
+        readings []map[string]float64
+        wantErr  bool
+    }{
+        {
+            name:    "zepp detected by header",
+            csv:     "time,weight,fatRate\n2025-09-01 07:00:00,80.5,20.1\n2025-09-01 20:00:00,81.5,--\nnodate,80,20\n",
+            preset:  ScalePresetZepp,
+            rows:    3,
+            skipped: 1,
+            readings: []map[string]float64{{"weight": 80.5, "bodyFat": 20.1}, {"weight": 81.5}},
+        },
+        {
+            name:     "garmin with units and semicolons",
+            csv:      "Date;Time;Weight;Body Fat\n2025-09-01;07:00;176.4 lbs;20.1 %\n",
+            preset:   ScalePresetGarmin,
+            rows:     1,
+            readings: []map[string]float64{{"weight": 80.01, "bodyFat": 20.1}},
+        },
+        {
+            name:     "withings in pounds derives body fat from fat mass",
+            csv:      "Date,Weight (lb),Fat mass (lb)\n2025-09-01 07:00:00,200,40\n",
+            preset:   ScalePresetWithings,
+            rows:     1,
+            readings: []map[string]float64{{"weight": 90.72, "bodyFat": 20}},
+        },
+        {
+            name:     "custom mapping",
+            csv:      "Дата;Вес;Талия\n01.09.2025;80,5;85\n02.09.2025;500;5\n",
+            opts:     ScaleImportOptions{Mapping: &ScaleMapping{Date: "Дата", DateFormat: "02.01.2006", Weight: "Вес", Waist: "Талия"}},
+            preset:   ScalePresetCustom,
+            rows:     2,
+            skipped:  1,
+            readings: []map[string]float64{{"weight": 80.5, "waist": 85}},
+        },
+        {name: "unknown header", csv: "a,b\n1,2\n", wantErr: true},
+        {name: "custom without mapping", csv: "a,b\n1,2\n", opts: ScaleImportOptions{Preset: ScalePresetCustom}, wantErr: true},
+        {name: "mapped column missing", csv: "date,kg\n2025-09-01,80\n", opts: ScaleImportOptions{Mapping: &ScaleMapping{Date: "date", Weight: "weight"}}, wantErr: true},
+        {name: "empty file", csv: "", wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if tt.opts.Loc == nil { tt.opts.Loc = time.UTC }
+            readings, res, err := parseScaleCSV([]byte(tt.csv), tt.opts)
+            if (err != nil) != tt.wantErr { t.Fatalf("parseScaleCSV() error = %v, wantErr %v", err, tt.wantErr) }
+            if tt.wantErr { return }
+            if res.Preset != tt.preset || res.Rows != tt.rows || res.Skipped != tt.skipped {
+                t.Errorf("result = %s rows %d skipped %d, want %s rows %d skipped %d", res.Preset, res.Rows, res.Skipped, tt.preset, tt.rows, tt.skipped)
+            }
+            if len(readings) != len(tt.readings) { t.Fatalf("readings = %d, want %d", len(readings), len(tt.readings)) }
+            for i, r := range readings {
+                got := map[string]float64{}
+                for k, v := range r.values { got[k] = math.Round(v*100) / 100 }
+                if !reflect.DeepEqual(got, tt.readings[i]) { t.Errorf("reading %d = %v, want %v", i, got, tt.readings[i]) }
+            }
+        })
+    }
+}
+
+func TestAggregateScale(t *testing.T) {
+    at := func(day, hour int) time.Time { return time.Date(2025, 9, day, hour, 0, 0, 0, time.UTC) }
+    readings := func() []scaleReading {
+        return []scaleReading{
+            {at: at(2, 7), values: map[string]float64{"weight": 79}},
+            {at: at(1, 20), values: map[string]float64{"weight": 81}},
+            {at: at(1, 7), values: map[string]float64{"weight": 80, "bodyFat": 20}},
+        }
+    }
+    tests := []struct {
+        mode string
+        day1 map[string]float64
+    }{
+        {AggregateFirst, map[string]float64{"weight": 80, "bodyFat": 20}},
+        {AggregateLast, map[string]float64{"weight": 81, "bodyFat": 20}},
+        {AggregateAvg, map[string]float64{"weight": 80.5, "bodyFat": 20}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.mode, func(t *testing.T) {
+            days := aggregateScale(readings(), tt.mode)
+            if len(days) != 2 || days[0].Date != "2025-09-01" || days[1].Date != "2025-09-02" { t.Fatalf("days = %+v", days) }
+            if days[0].Readings != 2 || !reflect.DeepEqual(days[0].Values, tt.day1) { t.Errorf("day 1 = %+v, want %v", days[0], tt.day1) }
+            if days[1].Values["weight"] != 79 { t.Errorf("day 2 = %+v", days[1]) }
+        })
+    }
+}
@@ -0,0 +1,94 @@
+package body
+
+import (
+    "math"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+)
+
+const appleExport = `<?xml version="1.0" encoding="UTF-8"?>
+<HealthData locale="ru_RU">
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" value="1000" startDate="2025-09-01 08:00:00 +0300" endDate="2025-09-01 08:30:00 +0300"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" value="500" startDate="2025-09-01 18:00:00 +0300" endDate="2025-09-01 18:10:00 +0300"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="Watch" unit="count" value="1200" startDate="2025-09-01 08:00:00 +0300" endDate="2025-09-01 08:30:00 +0300"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" value="10" startDate="yesterday" endDate="today"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" sourceName="Scale" unit="lb" value="176.37" startDate="2025-09-01 07:00:00 +0300" endDate="2025-09-01 07:00:00 +0300"/>
+ <Record type="HKCategoryTypeIdentifierSleepAnalysis" sourceName="Watch" value="HKCategoryValueSleepAnalysisInBed" startDate="2025-09-01 22:30:00 +0300" endDate="2025-09-02 06:30:00 +0300"/>
+ <Record type="HKCategoryTypeIdentifierSleepAnalysis" sourceName="Watch" value="HKCategoryValueSleepAnalysisAsleepCore" startDate="2025-09-01 23:00:00 +0300" endDate="2025-09-02 06:00:00 +0300"/>
+ <Record type="HKQuantityTypeIdentifierHeartRate" sourceName="Watch" unit="count/min" value="60" startDate="2025-09-01 08:00:00 +0300" endDate="2025-09-01 08:00:00 +0300"/>
+ <Workout workoutActivityType="HKWorkoutActivityTypeRunning" duration="30" durationUnit="min" totalDistance="5" totalDistanceUnit="km" totalEnergyBurned="300" totalEnergyBurnedUnit="kcal" startDate="2025-09-01 19:00:00 +0300" endDate="2025-09-01 19:30:00 +0300"/>
+</HealthData>`
+
+func TestParseAppleHealth(t *testing.T) {
+    data, err := parseAppleHealth(strings.NewReader(appleExport))
+    if err != nil { t.Fatalf("parseAppleHealth() error = %v", err) }
+
+    if got := data.steps["2025-09-01"]; got["iPhone"] != 1500 || got["Watch"] != 1200 || maxBySource(got) != 1500 {
+        t.Errorf("steps = %v, want iPhone 1500 and Watch 1200", got)
+    }
+    if got := data.sleep["2025-09-02"]["Watch"]; got != 420 { t.Errorf("sleep = %v, want 420 (in bed is not sleep)", got) }
+    if w := data.weights["2025-09-01"]; math.Abs(w.value-80) > 0.01 { t.Errorf("weight = %v, want 80 kg", w.value) }
+    if data.records != 8 || data.skipped != 1 { t.Errorf("records/skipped = %d/%d, want 8/1", data.records, data.skipped) }
+
+    if len(data.workouts) != 1 { t.Fatalf("workouts = %d, want 1", len(data.workouts)) }
+    w := data.workouts[0]
+    if w.kind != "running" || w.durationMin != 30 || w.calories != 300 || w.distanceKm == nil || *w.distanceKm != 5 {
+        t.Errorf("workout = %+v", w)
+    }
+
+    if _, err := parseAppleHealth(strings.NewReader(`<Other/>`)); err == nil { t.Error("parseAppleHealth() of another xml: error = nil") }
+}
+
+func TestParseGoogleDailyMetrics(t *testing.T) {
+    csv := "\ufeffDate,Step count,Calories (kcal)\n2025-09-01,8000,2000\n2025-09-02,,1900\nbad,100,0\n"
+    data := newHealthData()
+    if err := parseGoogleDailyMetrics(strings.NewReader(csv), data); err != nil { t.Fatalf("parseGoogleDailyMetrics() error = %v", err) }
+    if got := data.steps["2025-09-01"]["google_fit"]; got != 8000 { t.Errorf("steps = %v, want 8000", got) }
+    if data.records != 2 || data.skipped != 1 { t.Errorf("records/skipped = %d/%d, want 2/1", data.records, data.skipped) }
+
+    // per-day interval files have no Date column
+    data = newHealthData()
+    if err := parseGoogleDailyMetrics(strings.NewReader("Start time,Step count\n00:00:00.000+03:00,10\n"), data); err != nil || data.records != 0 {
+        t.Errorf("interval file: records = %d, err = %v", data.records, err)
+    }
+}
+
+func TestParseGoogleSession(t *testing.T) {
+    loc := time.FixedZone("MSK", 3*60*60)
+    tests := []struct {
+        name     string
+        json     string
+        sleep    float64
+        workouts int
+    }{
+        {"sleep goes to wake-up day", `{"fitnessActivity":"sleep","startTime":"2025-09-01T20:00:00Z","endTime":"2025-09-02T04:00:00Z"}`, 480, 0},
+        {"workout", `{"fitnessActivity":"running","startTime":"2025-09-02T16:00:00Z","endTime":"2025-09-02T16:40:00Z","duration":"1800.000s",
+            "aggregate":[{"metricName":"com.google.calories.expended","floatValue":250},{"metricName":"com.google.distance.delta","floatValue":5000}]}`, 0, 1},
+        {"still is not a workout", `{"fitnessActivity":"still","startTime":"2025-09-02T16:00:00Z","endTime":"2025-09-02T17:00:00Z"}`, 0, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            data := newHealthData()
+            if err := parseGoogleSession(strings.NewReader(tt.json), data, loc); err != nil { t.Fatalf("parseGoogleSession() error = %v", err) }
+            if got := data.sleep["2025-09-02"]["google_fit"]; got != tt.sleep { t.Errorf("sleep = %v, want %v", got, tt.sleep) }
+            if len(data.workouts) != tt.workouts { t.Fatalf("workouts = %d, want %d", len(data.workouts), tt.workouts) }
+            if tt.workouts == 0 { return }
+            w := data.workouts[0]
+            if w.kind != "running" || w.durationMin != 30 || w.calories != 250 || w.distanceKm == nil || *w.distanceKm != 5 || w.start.Hour() != 19 {
+                t.Errorf("workout = %+v", w)
+            }
+        })
+    }
+}
+
+func TestParseGoogleWeights(t *testing.T) {
+    at := time.Date(2025, 9, 1, 22, 0, 0, 0, time.UTC)
+    json := `{"Data Points":[{"startTimeNanos":` + strconv.FormatInt(at.UnixNano(), 10) + `,"fitValue":[{"value":{"fpVal":80.5}}]},{"startTimeNanos":0,"fitValue":[]}]}`
+    data := newHealthData()
+    if err := parseGoogleWeights(strings.NewReader(json), data, time.FixedZone("MSK", 3*60*60)); err != nil { t.Fatalf("parseGoogleWeights() error = %v", err) }
+    // 22:00 UTC is the next local day
+    if w, ok := data.weights["2025-09-02"]; !ok || w.value != 80.5 { t.Errorf("weights = %v, want 80.5 on 2025-09-02", data.weights) }
+    if data.records != 2 || data.skipped != 1 { t.Errorf("records/skipped = %d/%d, want 2/1", data.records, data.skipped) }
+}
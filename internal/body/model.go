@@ -18,14 +18,20 @@ type WeightCreate struct {
 }
 
 type Measurement struct {
-    Id        int64      `json:"id" db:"id"`
-    UserId    string     `json:"-" db:"user_id"`
-    Chest     *float64   `json:"chest,omitempty" db:"chest"`
-    Waist     *float64   `json:"waist,omitempty" db:"waist"`
-    Hips      *float64   `json:"hips,omitempty" db:"hips"`
-    LoggedAt  time.Time  `json:"loggedAt" db:"logged_at"`
-    CreatedAt time.Time  `json:"-" db:"created_at"`
-    UpdatedAt time.Time  `json:"-" db:"updated_at"`
+    Id          int64        `json:"id" db:"id"`
+    UserId      string       `json:"-" db:"user_id"`
+    Chest       *float64     `json:"chest,omitempty" db:"chest"`
+    Waist       *float64     `json:"waist,omitempty" db:"waist"`
+    Hips        *float64     `json:"hips,omitempty" db:"hips"`
+    Neck        *float64     `json:"neck,omitempty" db:"neck"`
+    Arm         *float64     `json:"arm,omitempty" db:"arm"`
+    Thigh       *float64     `json:"thigh,omitempty" db:"thigh"`
+    Calf        *float64     `json:"calf,omitempty" db:"calf"`
+    BodyFat     *float64     `json:"bodyFat,omitempty" db:"body_fat"`
+    LoggedAt    time.Time    `json:"loggedAt" db:"logged_at"`
+    CreatedAt   time.Time    `json:"-" db:"created_at"`
+    UpdatedAt   time.Time    `json:"-" db:"updated_at"`
+    Composition *Composition `json:"composition,omitempty" db:"-"`
 }
 
 type MeasurementCreate struct {
@@ -33,36 +39,46 @@ type MeasurementCreate struct {
     Chest    *float64   `db:"chest"`
     Waist    *float64   `db:"waist"`
     Hips     *float64   `db:"hips"`
+    Neck     *float64   `db:"neck"`
+    Arm      *float64   `db:"arm"`
+    Thigh    *float64   `db:"thigh"`
+    Calf     *float64   `db:"calf"`
+    BodyFat  *float64   `db:"body_fat"`
     LoggedAt time.Time  `db:"logged_at"`
 }
 
 type PlateauResult struct {
-    IsPlateau        bool     `json:"isPlateau"`
-    Goal             string   `json:"goal"`
-    WindowStart      string   `json:"windowStart"`
-    WindowEnd        string   `json:"windowEnd"`
-    WindowDays       int      `json:"windowDays"`
-    DaysWithWeight   int      `json:"daysWithWeight"`
-    SlopeWeeklyPct   float64  `json:"slopeWeeklyPct"`
-    DeltaKg          float64  `json:"deltaKg"`
-    CaloriesGoodDays int      `json:"caloriesGoodDays"`
-    ProteinGoodDays  int      `json:"proteinGoodDays"`
-    CaloriesTarget   float64  `json:"caloriesTarget"`
-    ProteinPerKg     float64  `json:"proteinPerKgTarget"`
-    StepsAvg         float64  `json:"stepsAvg"`
-    StepsTarget      int      `json:"stepsTarget"`
-    SleepAvgHours    float64  `json:"sleepAvgHours"`
-    Reason           string   `json:"reason"`
+    IsPlateau        bool             `json:"isPlateau"`
+    Goal             string           `json:"goal"`
+    WindowStart      string           `json:"windowStart"`
+    WindowEnd        string           `json:"windowEnd"`
+    WindowDays       int              `json:"windowDays"`
+    DaysWithWeight   int              `json:"daysWithWeight"`
+    SlopeWeeklyPct   float64          `json:"slopeWeeklyPct"`
+    DeltaKg          float64          `json:"deltaKg"`
+    CaloriesGoodDays int              `json:"caloriesGoodDays"`
+    ProteinGoodDays  int              `json:"proteinGoodDays"`
+    CaloriesTarget   float64          `json:"caloriesTarget"`
+    ProteinPerKg     float64          `json:"proteinPerKgTarget"`
+    StepsAvg         float64          `json:"stepsAvg"`
+    StepsTarget      int              `json:"stepsTarget"`
+    SleepAvgHours    float64          `json:"sleepAvgHours"`
+    Sleep            *SleepStats      `json:"sleep,omitempty"`
+    Reason           string           `json:"reason"`
+    Params           *PlateauParams   `json:"params,omitempty"`
+    EventId          *int64           `json:"eventId,omitempty"`
+    Recommendations  []Recommendation `json:"recommendations"`
 }
 
 type Activity struct {
-    Id        int64     `json:"id" db:"id"`
-    UserId    string    `json:"-" db:"user_id"`
-    Steps     *int      `json:"steps,omitempty" db:"steps"`
-    SleepMin  *int      `json:"sleepMin,omitempty" db:"sleep_min"`
-    LoggedAt  time.Time `json:"loggedAt" db:"logged_at"`
-    CreatedAt time.Time `json:"-" db:"created_at"`
-    UpdatedAt time.Time `json:"-" db:"updated_at"`
+    Id               int64     `json:"id" db:"id"`
+    UserId           string    `json:"-" db:"user_id"`
+    Steps            *int      `json:"steps,omitempty" db:"steps"`
+    SleepMin         *int      `json:"sleepMin,omitempty" db:"sleep_min"`                  // manual or imported
+    SleepSessionsMin *int      `json:"sleepSessionsMin,omitempty" db:"sleep_sessions_min"` // total of sleep sessions, wins when set
+    LoggedAt         time.Time `json:"loggedAt" db:"logged_at"`
+    CreatedAt        time.Time `json:"-" db:"created_at"`
+    UpdatedAt        time.Time `json:"-" db:"updated_at"`
 }
 
 type ActivityCreate struct {
@@ -73,18 +89,80 @@ type ActivityCreate struct {
 }
 
 type PlateauEvent struct {
-    Id               int64     `json:"id" db:"id"`
-    UserId           string    `json:"-" db:"user_id"`
-    WindowStart      time.Time `json:"windowStart" db:"window_start"`
-    WindowEnd        time.Time `json:"windowEnd" db:"window_end"`
-    Goal             *string   `json:"goal,omitempty" db:"goal"`
-    SlopeWeeklyPct   float64   `json:"slopeWeeklyPct" db:"slope_weekly_pct"`
-    DeltaKg          float64   `json:"deltaKg" db:"delta_kg"`
-    DaysWithWeight   int       `json:"daysWithWeight" db:"days_with_weight"`
-    CaloriesGoodDays int       `json:"caloriesGoodDays" db:"calories_good_days"`
-    ProteinGoodDays  int       `json:"proteinGoodDays" db:"protein_good_days"`
-    WindowDays       int       `json:"windowDays" db:"window_days"`
-    IsPlateau        bool      `json:"isPlateau" db:"is_plateau"`
-    Reason           string    `json:"reason" db:"reason"`
-    CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+    Id                     int64            `json:"id" db:"id"`
+    UserId                 string           `json:"-" db:"user_id"`
+    WindowStart            time.Time        `json:"windowStart" db:"window_start"`
+    WindowEnd              time.Time        `json:"windowEnd" db:"window_end"`
+    Goal                   *string          `json:"goal,omitempty" db:"goal"`
+    SlopeWeeklyPct         float64          `json:"slopeWeeklyPct" db:"slope_weekly_pct"`
+    DeltaKg                float64          `json:"deltaKg" db:"delta_kg"`
+    DaysWithWeight         int              `json:"daysWithWeight" db:"days_with_weight"`
+    CaloriesGoodDays       int              `json:"caloriesGoodDays" db:"calories_good_days"`
+    ProteinGoodDays        int              `json:"proteinGoodDays" db:"protein_good_days"`
+    WindowDays             int              `json:"windowDays" db:"window_days"`
+    IsPlateau              bool             `json:"isPlateau" db:"is_plateau"`
+    Reason                 string           `json:"reason" db:"reason"`
+    Params                 *PlateauParams   `json:"params,omitempty" db:"-"`
+    ParamsRaw              []byte           `json:"-" db:"params"`
+    Recommendations        []Recommendation `json:"recommendations" db:"-"`
+    RecommendationsRaw     []byte           `json:"-" db:"recommendations"`
+    AcceptedRecommendation *string          `json:"acceptedRecommendation,omitempty" db:"accepted_recommendation"`
+    AcceptedAt             *time.Time       `json:"acceptedAt,omitempty" db:"accepted_at"`
+    CreatedAt              time.Time        `json:"createdAt" db:"created_at"`
+    UpdatedAt              time.Time        `json:"updatedAt" db:"updated_at"`
+}
+
+type Workout struct {
+    Id          int64     `json:"id" db:"id"`
+    UserId      string    `json:"-" db:"user_id"`
+    Type        string    `json:"type" db:"type"`
+    DurationMin int       `json:"durationMin" db:"duration_min"`
+    Intensity   string    `json:"intensity" db:"intensity"`
+    DistanceKm  *float64  `json:"distanceKm,omitempty" db:"distance_km"`
+    Met         float64   `json:"met" db:"met"`
+    Weight      float64   `json:"weight" db:"weight"`
+    Calories    float64   `json:"calories" db:"calories"`
+    Source      string    `json:"source" db:"source"`
+    LoggedAt    time.Time `json:"loggedAt" db:"logged_at"`
+    CreatedAt   time.Time `json:"-" db:"created_at"`
+    UpdatedAt   time.Time `json:"-" db:"updated_at"`
+}
+
+type WorkoutCreate struct {
+    UserId      string    `db:"user_id"`
+    Type        string    `db:"type"`
+    DurationMin int       `db:"duration_min"`
+    Intensity   string    `db:"intensity"`
+    DistanceKm  *float64  `db:"distance_km"`
+    Met         float64   `db:"met"`
+    Weight      float64   `db:"weight"`
+    Calories    float64   `db:"calories"`
+    Source      string    `db:"source"`
+    LoggedAt    time.Time `db:"logged_at"`
+    ExternalId  *string   `db:"external_id"` // id записи в приложении здоровья при импорте
+}
+
+type SleepSession struct {
+    Id          int64     `json:"id" db:"id"`
+    UserId      string    `json:"-" db:"user_id"`
+    StartAt     time.Time `json:"startAt" db:"start_at"`     // местное время отхода ко сну
+    EndAt       time.Time `json:"endAt" db:"end_at"`         // местное время пробуждения
+    SleepDate   time.Time `json:"sleepDate" db:"sleep_date"` // день пробуждения
+    DurationMin int       `json:"durationMin" db:"duration_min"`
+    Quality     *int      `json:"quality,omitempty" db:"quality"` // 1–5
+    IsNap       bool      `json:"isNap" db:"is_nap"`
+    Note        *string   `json:"note,omitempty" db:"note"`
+    CreatedAt   time.Time `json:"-" db:"created_at"`
+    UpdatedAt   time.Time `json:"-" db:"updated_at"`
+}
+
+type SleepSessionCreate struct {
+    UserId      string    `db:"user_id"`
+    StartAt     time.Time `db:"start_at"`
+    EndAt       time.Time `db:"end_at"`
+    SleepDate   time.Time `db:"sleep_date"`
+    DurationMin int       `db:"duration_min"`
+    Quality     *int      `db:"quality"`
+    IsNap       bool      `db:"is_nap"`
+    Note        *string   `db:"note"`
 }
@@ -0,0 +1,125 @@
+package body
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+)
+
+const (
+    ParamsScopeGlobal = "global"
+    ParamsScopePlan   = "plan"
+    ParamsScopeUser   = "user"
+)
+
+// PlateauParams — thresholds of plateau detection.
+type PlateauParams struct {
+    WindowDays          int     `json:"windowDays"`
+    MinWeightDays       int     `json:"minWeightDays"`
+    Lambda              float64 `json:"lambda"`              // EWMA
+    SlopeThresholdPct   float64 `json:"slopeThresholdPct"`   // % веса в неделю
+    MinDeltaKg          float64 `json:"minDeltaKg"`
+    CalorieTolerancePct float64 `json:"calorieTolerancePct"` // день в пределах ±% от цели
+    MinCompliantDays    int     `json:"minCompliantDays"`
+    ProteinPerKg        float64 `json:"proteinPerKg"`
+    MinSleepHours       float64 `json:"minSleepHours"`
+    MaxBedtimeStdMin    float64 `json:"maxBedtimeStdMin"` // допустимый разброс отхода ко сну, мин
+    DefaultStepsTarget  int     `json:"defaultStepsTarget"`
+    StepsTargetShare    float64 `json:"stepsTargetShare"` // доля цели по шагам, достаточная для соблюдения
+}
+
+// DefaultPlateauParams — built-in defaults, the base of every resolution.
+func DefaultPlateauParams() PlateauParams {
+    return PlateauParams{
+        WindowDays:          21,
+        MinWeightDays:       7,
+        Lambda:              0.2,
+        SlopeThresholdPct:   0.05,
+        MinDeltaKg:          0.3,
+        CalorieTolerancePct: 10,
+        MinCompliantDays:    14,
+        ProteinPerKg:        1.6,
+        MinSleepHours:       6,
+        MaxBedtimeStdMin:    60,
+        DefaultStepsTarget:  8000,
+        StepsTargetShare:    0.8,
+    }
+}
+
+func (p PlateauParams) Validate() error {
+    switch {
+    case p.WindowDays < 7 || p.WindowDays > 90:
+        return errors.New("windowDays must be between 7 and 90")
+    case p.MinWeightDays < 3 || p.MinWeightDays > p.WindowDays:
+        return errors.New("minWeightDays must be between 3 and windowDays")
+    case p.Lambda <= 0 || p.Lambda > 1:
+        return errors.New("lambda must be in (0, 1]")
+    case p.SlopeThresholdPct < 0 || p.MinDeltaKg < 0:
+        return errors.New("thresholds must not be negative")
+    case p.CalorieTolerancePct <= 0 || p.CalorieTolerancePct > 50:
+        return errors.New("calorieTolerancePct must be in (0, 50]")
+    case p.MinCompliantDays < 0 || p.MinCompliantDays > p.WindowDays:
+        return errors.New("minCompliantDays must be between 0 and windowDays")
+    case p.ProteinPerKg < 0 || p.MinSleepHours < 0 || p.MaxBedtimeStdMin < 0 || p.DefaultStepsTarget < 0 || p.StepsTargetShare < 0:
+        return errors.New("targets must not be negative")
+    }
+    return nil
+}
+
+// PlateauParamLayer — stored override of one scope.
+type PlateauParamLayer struct {
+    Scope  string `db:"scope"`
+    Params []byte `db:"params"`
+}
+
+var paramScopeRank = map[string]int{ParamsScopeGlobal: 0, ParamsScopePlan: 1, ParamsScopeUser: 2}
+
+// applyParamLayers applies overrides in order on top of p. Overrides are partial JSON objects:
+// only present keys replace the value. A layer that does not parse or makes the params invalid
+// is skipped, so a broken override drops only itself; its error is returned for logging.
+func applyParamLayers(p PlateauParams, layers []PlateauParamLayer) (PlateauParams, []error) {
+    var errs []error
+    for _, l := range layers {
+        if len(l.Params) == 0 { continue }
+        next := p
+        err := json.Unmarshal(l.Params, &next)
+        if err == nil { err = next.Validate() }
+        if err != nil { errs = append(errs, fmt.Errorf("%s params: %w", l.Scope, err)); continue }
+        p = next
+    }
+    return p, errs
+}
+
+// ResolvePlateauParams applies overrides (global, plan, user) on top of the defaults.
+func (s *service) ResolvePlateauParams(ctx context.Context, userId string) (*PlateauParams, error) {
+    layers, err := s.repo.GetPlateauParamLayers(ctx, userId)
+    if err != nil { return nil, err }
+    // a broken override must not break evaluation
+    p, errs := applyParamLayers(DefaultPlateauParams(), layers)
+    for _, err := range errs { logger.Warn("skip plateau params override", "user", userId, "err", err) }
+    return &p, nil
+}
+
+// SetPlateauParams stores an override for the scope after checking that it resolves to valid params
+// on top of the layers below it, as they are stored now.
+func (s *service) SetPlateauParams(ctx context.Context, scope string, planId *int64, userId *string, override json.RawMessage) error {
+    var keys map[string]json.RawMessage
+    if err := json.Unmarshal(override, &keys); err != nil { return errors.New("params must be a JSON object") }
+    uid := ""
+    if scope == ParamsScopeUser && userId != nil { uid = *userId }
+    layers, err := s.repo.GetPlateauParamLayers(ctx, uid)
+    if err != nil { return err }
+    var below []PlateauParamLayer
+    for _, l := range layers {
+        if paramScopeRank[l.Scope] < paramScopeRank[scope] { below = append(below, l) }
+    }
+    check, _ := applyParamLayers(DefaultPlateauParams(), below)
+    if err := json.Unmarshal(override, &check); err != nil { return err }
+    if err := check.Validate(); err != nil { return err }
+    return s.repo.UpsertPlateauParams(ctx, scope, planId, userId, override)
+}
+
+func (s *service) ResetUserPlateauParams(ctx context.Context, userId string) error {
+    return s.repo.DeletePlateauParams(ctx, ParamsScopeUser, userId)
+}
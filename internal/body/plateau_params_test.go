@@ -0,0 +1,42 @@
+package body
+
+import "testing"
+
+func TestPlateauParamsValidate(t *testing.T) {
+    tests := []struct {
+        name    string
+        change  func(p *PlateauParams)
+        wantErr bool
+    }{
+        {"defaults", func(p *PlateauParams) {}, false},
+        {"short window", func(p *PlateauParams) { p.WindowDays = 5 }, true},
+        {"weight days above window", func(p *PlateauParams) { p.MinWeightDays = 30 }, true},
+        {"zero lambda", func(p *PlateauParams) { p.Lambda = 0 }, true},
+        {"negative threshold", func(p *PlateauParams) { p.MinDeltaKg = -1 }, true},
+        {"tolerance too wide", func(p *PlateauParams) { p.CalorieTolerancePct = 60 }, true},
+        {"compliant days above window", func(p *PlateauParams) { p.MinCompliantDays = 22 }, true},
+        {"negative target", func(p *PlateauParams) { p.ProteinPerKg = -1 }, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := DefaultPlateauParams()
+            tt.change(&p)
+            if err := p.Validate(); (err != nil) != tt.wantErr { t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr) }
+        })
+    }
+}
+
+func TestApplyParamLayers(t *testing.T) {
+    layers := []PlateauParamLayer{
+        {Scope: ParamsScopeGlobal, Params: []byte(`{"windowDays":28}`)},
+        {Scope: ParamsScopePlan, Params: []byte(`{`)},
+        {Scope: ParamsScopeUser, Params: []byte(`{"minWeightDays":40}`)},
+        {Scope: ParamsScopeUser, Params: []byte(`{"lambda":0.3}`)},
+        {Scope: ParamsScopeUser},
+    }
+    got, errs := applyParamLayers(DefaultPlateauParams(), layers)
+    want := DefaultPlateauParams()
+    want.WindowDays, want.Lambda = 28, 0.3
+    if got != want { t.Errorf("applyParamLayers() = %+v, want %+v", got, want) }
+    if len(errs) != 2 { t.Errorf("applyParamLayers() errors = %v, want 2 skipped layers", errs) }
+}
@@ -0,0 +1,193 @@
+package body
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/fit"
+)
+
+const (
+    RecReduceCalories   = "reduce_calories"
+    RecIncreaseCalories = "increase_calories"
+    RecDietBreak        = "diet_break"
+    RecRaiseProtein     = "raise_protein"
+    RecAddSteps         = "add_steps"
+    RecImproveSleep     = "improve_sleep"
+    RecRegularSleep     = "regular_sleep"
+    RecImproveAdherence = "improve_adherence"
+)
+
+const (
+    // repeated plateaus within this period suggest a diet break instead of a deeper cut
+    dietBreakLookbackDays = 42
+    dietBreakMinPlateaus  = 2
+)
+
+// Recommendation — structured suggestion for a plateau. Targets is set when
+// accepting the suggestion changes fit targets.
+type Recommendation struct {
+    Code    string             `json:"code"`
+    Title   string             `json:"title"`
+    Message string             `json:"message"`
+    Data    map[string]float64 `json:"data"`
+    Targets *fit.Macros        `json:"targets,omitempty"`
+}
+
+type recText struct{ title, message string }
+
+// recTexts — localized texts; message placeholders follow the order of recommend's arguments.
+var recTexts = map[string]map[string]recText{
+    "ru": {
+        RecReduceCalories:   {"Снизить калорийность", "Снизьте калорийность на %.0f ккал: с %.0f до %.0f ккал в день"},
+        RecIncreaseCalories: {"Повысить калорийность", "Добавьте %.0f ккал: с %.0f до %.0f ккал в день"},
+        RecDietBreak:        {"Сделать диетический перерыв", "Уже %.0f плато за последние недели. Поешьте на поддержании (%.0f ккал) 1–2 недели"},
+        RecRaiseProtein:     {"Добрать белок", "Белок в норме только %.0f из %.0f дней. Цель — %.0f г в день"},
+        RecAddSteps:         {"Больше шагов", "В среднем %.0f шагов в день. Добавьте около %.0f шагов"},
+        RecImproveSleep:     {"Наладить сон", "В среднем %.1f ч сна. Старайтесь спать не меньше %.1f ч"},
+        RecRegularSleep:     {"Ложиться в одно время", "Время отхода ко сну гуляет на ±%.0f мин. Старайтесь ложиться около %s"},
+        RecImproveAdherence: {"Точнее соблюдать калории", "Калории в пределах цели только %.0f из %.0f дней. Сначала добейтесь стабильности"},
+    },
+    "en": {
+        RecReduceCalories:   {"Reduce calories", "Cut %.0f kcal: from %.0f to %.0f kcal a day"},
+        RecIncreaseCalories: {"Increase calories", "Add %.0f kcal: from %.0f to %.0f kcal a day"},
+        RecDietBreak:        {"Take a diet break", "%.0f plateaus in recent weeks. Eat at maintenance (%.0f kcal) for 1–2 weeks"},
+        RecRaiseProtein:     {"Eat more protein", "Protein target met on %.0f of %.0f days only. Aim for %.0f g a day"},
+        RecAddSteps:         {"Walk more", "You average %.0f steps a day. Add about %.0f steps"},
+        RecImproveSleep:     {"Improve sleep", "You average %.1f h of sleep. Aim for at least %.1f h"},
+        RecRegularSleep:     {"Keep a regular bedtime", "Your bedtime varies by ±%.0f min. Try to go to bed around %s"},
+        RecImproveAdherence: {"Hit your calories", "Calories were on target on %.0f of %.0f days only. Get consistent first"},
+    },
+}
+
+func newRecommendation(locale, code string, data map[string]float64, targets *fit.Macros, args ...any) Recommendation {
+    texts, ok := recTexts[locale]
+    if !ok { texts = recTexts["ru"] }
+    t := texts[code]
+    return Recommendation{Code: code, Title: t.title, Message: fmt.Sprintf(t.message, args...), Data: data, Targets: targets}
+}
+
+// recommend turns plateau metrics into suggestions. Calorie changes are suggested
+// only when adherence is good enough for the plateau to be real.
+func (s *service) recommend(ctx context.Context, userId string, res *PlateauResult, params *PlateauParams, weight float64) ([]Recommendation, error) {
+    var locale string
+    _ = s.db.GetContext(ctx, &locale, `SELECT COALESCE(locale, 'ru') FROM users WHERE id=$1`, userId)
+    f, err := s.fitService.GetFitProfileByUser(userId)
+    if err != nil { return nil, err }
+
+    recs := []Recommendation{}
+    days := float64(res.WindowDays)
+
+    if res.IsPlateau && f != nil && f.Calories > 0 {
+        switch res.Goal {
+        case "lose", "fat_loss", "weight_loss":
+            // the row of this window is re-evaluated daily, so earlier rows are compared by window
+            var windows []plateauWindow
+            end, _ := time.Parse("2006-01-02", res.WindowEnd)
+            _ = s.db.SelectContext(ctx, &windows, `SELECT window_start, window_end FROM body_plateau_events WHERE user_id=$1 AND is_plateau AND window_end >= $2 AND window_end < $3 ORDER BY window_end DESC`, userId, end.AddDate(0, 0, -dietBreakLookbackDays), end)
+            start, _ := time.Parse("2006-01-02", res.WindowStart)
+            plateaus := distinctPlateaus(windows, start)
+            if calc, err := fit.Calculate(fit.ProfileInput(*f)); err == nil && plateaus >= dietBreakMinPlateaus && calc.TDEE > f.Calories {
+                m := shiftCalories(*f, calc.TDEE)
+                recs = append(recs, newRecommendation(locale, RecDietBreak, map[string]float64{"plateaus": float64(plateaus), "calories": calc.TDEE}, &m, float64(plateaus), calc.TDEE))
+                break
+            }
+            cut := math.Max(100, roundTo(0.1*f.Calories, 50))
+            next := math.Max(f.Calories-cut, fit.MinCalories(f.Gender))
+            if next < f.Calories {
+                m := shiftCalories(*f, next)
+                recs = append(recs, newRecommendation(locale, RecReduceCalories, map[string]float64{"delta": f.Calories - next, "current": f.Calories, "calories": next}, &m, f.Calories-next, f.Calories, next))
+            }
+        case "gain", "muscle_gain", "bulk":
+            add := math.Max(100, roundTo(0.05*f.Calories, 50))
+            m := shiftCalories(*f, f.Calories+add)
+            recs = append(recs, newRecommendation(locale, RecIncreaseCalories, map[string]float64{"delta": add, "current": f.Calories, "calories": f.Calories + add}, &m, add, f.Calories, f.Calories+add))
+        }
+    }
+
+    if res.CaloriesGoodDays < params.MinCompliantDays {
+        recs = append(recs, newRecommendation(locale, RecImproveAdherence, map[string]float64{"goodDays": float64(res.CaloriesGoodDays), "windowDays": days}, nil, float64(res.CaloriesGoodDays), days))
+    }
+
+    if res.ProteinGoodDays < params.MinCompliantDays {
+        protein := math.Round(params.ProteinPerKg * weight)
+        var m *fit.Macros
+        // raise the protein target itself if it is below the recommended amount
+        if f != nil && f.Protein < protein {
+            m = &fit.Macros{Calories: f.Calories, Protein: protein, Fat: f.Fat, Carbs: math.Max(0, f.Carbs-(protein-f.Protein))}
+        }
+        recs = append(recs, newRecommendation(locale, RecRaiseProtein, map[string]float64{"goodDays": float64(res.ProteinGoodDays), "windowDays": days, "protein": protein}, m, float64(res.ProteinGoodDays), days, protein))
+    }
+
+    if need := params.StepsTargetShare * float64(res.StepsTarget); res.StepsAvg < need {
+        add := math.Max(500, roundTo(float64(res.StepsTarget)-res.StepsAvg, 500))
+        recs = append(recs, newRecommendation(locale, RecAddSteps, map[string]float64{"stepsAvg": math.Round(res.StepsAvg), "stepsTarget": float64(res.StepsTarget), "add": add}, nil, res.StepsAvg, add))
+    }
+
+    // zero means no sleep data at all — nothing to judge
+    if res.SleepAvgHours > 0 && res.SleepAvgHours < params.MinSleepHours {
+        recs = append(recs, newRecommendation(locale, RecImproveSleep, map[string]float64{"sleepAvgHours": round2(res.SleepAvgHours), "targetHours": params.MinSleepHours}, nil, res.SleepAvgHours, params.MinSleepHours))
+    }
+    if sl := res.Sleep; sl != nil && sl.BedtimeStdMin != nil && params.MaxBedtimeStdMin > 0 && *sl.BedtimeStdMin > params.MaxBedtimeStdMin {
+        recs = append(recs, newRecommendation(locale, RecRegularSleep, map[string]float64{"bedtimeStdMin": *sl.BedtimeStdMin, "maxBedtimeStdMin": params.MaxBedtimeStdMin}, nil, *sl.BedtimeStdMin, sl.AvgBedtime))
+    }
+    return recs, nil
+}
+
+type plateauWindow struct {
+    Start time.Time `db:"window_start"`
+    End   time.Time `db:"window_end"`
+}
+
+// distinctPlateaus counts the current plateau plus earlier ones that do not overlap it or each other.
+// windows are ordered by end, newest first; an overlapping window is the same plateau evaluated on another day.
+func distinctPlateaus(windows []plateauWindow, currentStart time.Time) int {
+    n := 1
+    for _, w := range windows {
+        if !w.End.Before(currentStart) { continue }
+        n++
+        currentStart = w.Start
+    }
+    return n
+}
+
+// shiftCalories changes calories keeping protein and fat, the difference goes to carbs.
+func shiftCalories(f fit.FitProfile, calories float64) fit.Macros {
+    carbs := math.Max(0, math.Round(f.Carbs+(calories-f.Calories)/4))
+    return fit.Macros{Calories: math.Round(calories), Protein: f.Protein, Fat: f.Fat, Carbs: carbs}
+}
+
+func roundTo(v, step float64) float64 { return math.Round(v/step) * step }
+
+// AcceptRecommendation applies the targets of a plateau recommendation to the fit profile.
+func (s *service) AcceptRecommendation(ctx context.Context, userId string, eventId int64, code string) (*fit.FitProfile, error) {
+    e, err := s.repo.GetPlateauEvent(ctx, eventId, userId)
+    if err != nil { return nil, err }
+    if e.AcceptedRecommendation != nil { return nil, errors.New("a recommendation was already accepted for this plateau") }
+    var rec *Recommendation
+    for i := range e.Recommendations {
+        if e.Recommendations[i].Code == code { rec = &e.Recommendations[i] }
+    }
+    if rec == nil { return nil, errors.New("recommendation not found") }
+    if rec.Targets == nil { return nil, errors.New("recommendation does not change targets") }
+
+    // targets of the recommendation are derived from the targets at evaluation time
+    versions, err := s.fitService.GetTargetsHistory(ctx, userId)
+    if err != nil { return nil, err }
+    for _, v := range versions {
+        if v.UpdatedAt.After(e.UpdatedAt) { return nil, errors.New("targets changed after this plateau was evaluated, evaluate it again") }
+    }
+
+    claimed, err := s.repo.AcceptPlateauRecommendation(ctx, eventId, userId, code)
+    if err != nil { return nil, err }
+    if !claimed { return nil, errors.New("a recommendation was already accepted for this plateau") }
+    f, err := s.fitService.ApplyTargets(ctx, userId, *rec.Targets, fit.SourcePlateau)
+    if err != nil {
+        _ = s.repo.ReleasePlateauRecommendation(ctx, eventId, userId, code)
+        return nil, err
+    }
+    return f, nil
+}
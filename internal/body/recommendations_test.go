@@ -0,0 +1,44 @@
+package body
+
+import (
+    "testing"
+    "time"
+)
+
+func day(s string) time.Time {
+    d, _ := time.Parse("2006-01-02", s)
+    return d
+}
+
+func TestDistinctPlateaus(t *testing.T) {
+    tests := []struct {
+        name    string
+        windows []plateauWindow
+        want    int
+    }{
+        {"only current", nil, 1},
+        {
+            name: "overlapping evaluations are one plateau",
+            windows: []plateauWindow{
+                {Start: day("2025-09-05"), End: day("2025-09-25")},
+                {Start: day("2025-09-01"), End: day("2025-09-21")},
+                {Start: day("2025-08-31"), End: day("2025-09-20")},
+            },
+            want: 2,
+        },
+        {
+            name: "separate plateaus",
+            windows: []plateauWindow{
+                {Start: day("2025-09-01"), End: day("2025-09-21")},
+                {Start: day("2025-08-25"), End: day("2025-09-14")},
+                {Start: day("2025-08-01"), End: day("2025-08-21")},
+            },
+            want: 3,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := distinctPlateaus(tt.windows, day("2025-09-22")); got != tt.want { t.Errorf("distinctPlateaus() = %d, want %d", got, tt.want) }
+        })
+    }
+}
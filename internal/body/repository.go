@@ -2,7 +2,11 @@ package body
 
 import (
     "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
     "fmt"
+    "strings"
     "time"
 
     "github.com/jmoiron/sqlx"
@@ -23,6 +27,7 @@ type Repository interface {
     UpdateMeasurement(ctx context.Context, m Measurement) (*Measurement, error)
     DeleteMeasurement(ctx context.Context, id int64, userId string) error
     GetMeasurements(ctx context.Context, userId string, from, to *time.Time) ([]Measurement, error)
+    MergeMeasurement(ctx context.Context, m MeasurementCreate, overwrite bool) error
     GetLatestMeasurement(ctx context.Context, userId string) (*Measurement, error)
 
     // Analytics helpers
@@ -37,14 +42,63 @@ type Repository interface {
     DeleteActivity(ctx context.Context, id int64, userId string) error
     GetActivity(ctx context.Context, userId string, from, to *time.Time) ([]Activity, error)
 
+    // Sleep sessions
+    CreateSleep(ctx context.Context, sc SleepSessionCreate) (*SleepSession, error)
+    UpdateSleep(ctx context.Context, ss SleepSession) (*SleepSession, error)
+    DeleteSleep(ctx context.Context, id int64, userId string) error
+    GetSleepById(ctx context.Context, id int64, userId string) (*SleepSession, error)
+    GetSleep(ctx context.Context, userId string, from, to *time.Time) ([]SleepSession, error)
+    SetSessionSleep(ctx context.Context, userId string, day time.Time, minutes *int) error
+
+    // Workouts
+    CreateWorkout(ctx context.Context, w WorkoutCreate) (*Workout, error)
+    UpdateWorkout(ctx context.Context, w Workout) (*Workout, error)
+    DeleteWorkout(ctx context.Context, id int64, userId string) error
+    GetWorkouts(ctx context.Context, userId string, from, to *time.Time) ([]Workout, error)
+    ImportWorkout(ctx context.Context, w WorkoutCreate) (bool, error)
+
     // Plateau events
     GetPlateauHistory(ctx context.Context, userId string, from, to *time.Time) ([]PlateauEvent, error)
+    GetPlateauEvent(ctx context.Context, id int64, userId string) (*PlateauEvent, error)
+    AcceptPlateauRecommendation(ctx context.Context, id int64, userId, code string) (bool, error)
+    ReleasePlateauRecommendation(ctx context.Context, id int64, userId, code string) error
+
+    // Guardrail alerts (notification dedup)
+    HasRecentGuardrailAlert(ctx context.Context, userId, code string, since time.Time) (bool, error)
+    CreateGuardrailAlert(ctx context.Context, userId, code string) error
+
+    // Plateau params (global -> plan -> user)
+    GetPlateauParamLayers(ctx context.Context, userId string) ([]PlateauParamLayer, error)
+    UpsertPlateauParams(ctx context.Context, scope string, planId *int64, userId *string, params []byte) error
+    DeletePlateauParams(ctx context.Context, scope string, userId string) error
+
+    // WithTx runs fn with a repository bound to one transaction
+    WithTx(ctx context.Context, fn func(Repository) error) error
+}
+
+// dbtx is the part of *sqlx.DB and *sqlx.Tx the repository uses.
+type dbtx interface {
+    sqlx.ExtContext
+    GetContext(ctx context.Context, dest any, query string, args ...any) error
+    SelectContext(ctx context.Context, dest any, query string, args ...any) error
+    NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
 }
 
-type repository struct { db *sqlx.DB }
+type repository struct { db dbtx }
 
 func NewRepository() Repository { return &repository{db: database.Database} }
 
+func (r *repository) WithTx(ctx context.Context, fn func(Repository) error) error {
+    db, ok := r.db.(*sqlx.DB)
+    // already inside a transaction
+    if !ok { return fn(r) }
+    tx, err := db.BeginTxx(ctx, nil)
+    if err != nil { return err }
+    defer func() { _ = tx.Rollback() }()
+    if err := fn(&repository{db: tx}); err != nil { return err }
+    return tx.Commit()
+}
+
 // ===== Weights =====
 func (r *repository) CreateWeight(ctx context.Context, w WeightCreate) (*Weight, error) {
     const q = `
@@ -52,7 +106,7 @@ func (r *repository) CreateWeight(ctx context.Context, w WeightCreate) (*Weight,
         VALUES (:user_id, :value, :logged_at)
         ON CONFLICT (user_id, logged_at) DO UPDATE SET value=EXCLUDED.value, updated_at=now()
         RETURNING id, user_id, value, logged_at, created_at, updated_at;`
-    rows, err := r.db.NamedQueryContext(ctx, q, w)
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, w)
     if err != nil { return nil, err }
     defer rows.Close()
     var out Weight
@@ -66,7 +120,7 @@ func (r *repository) UpdateWeight(ctx context.Context, w Weight) (*Weight, error
         SET value=:value, logged_at=:logged_at, updated_at=now()
         WHERE id=:id AND user_id=:user_id
         RETURNING id, user_id, value, logged_at, created_at, updated_at;`
-    rows, err := r.db.NamedQueryContext(ctx, q, w)
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, w)
     if err != nil { return nil, err }
     defer rows.Close()
     var out Weight
@@ -100,11 +154,12 @@ func (r *repository) GetLatestWeight(ctx context.Context, userId string) (*Weigh
 // ===== Measurements =====
 func (r *repository) CreateMeasurement(ctx context.Context, m MeasurementCreate) (*Measurement, error) {
     const q = `
-        INSERT INTO body_measurements (user_id, chest, waist, hips, logged_at)
-        VALUES (:user_id, :chest, :waist, :hips, :logged_at)
-        ON CONFLICT (user_id, logged_at) DO UPDATE SET chest=EXCLUDED.chest, waist=EXCLUDED.waist, hips=EXCLUDED.hips, updated_at=now()
-        RETURNING id, user_id, chest, waist, hips, logged_at, created_at, updated_at;`
-    rows, err := r.db.NamedQueryContext(ctx, q, m)
+        INSERT INTO body_measurements (user_id, chest, waist, hips, neck, arm, thigh, calf, body_fat, logged_at)
+        VALUES (:user_id, :chest, :waist, :hips, :neck, :arm, :thigh, :calf, :body_fat, :logged_at)
+        ON CONFLICT (user_id, logged_at) DO UPDATE SET chest=EXCLUDED.chest, waist=EXCLUDED.waist, hips=EXCLUDED.hips,
+            neck=EXCLUDED.neck, arm=EXCLUDED.arm, thigh=EXCLUDED.thigh, calf=EXCLUDED.calf, body_fat=EXCLUDED.body_fat, updated_at=now()
+        RETURNING id, user_id, chest, waist, hips, neck, arm, thigh, calf, body_fat, logged_at, created_at, updated_at;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, m)
     if err != nil { return nil, err }
     defer rows.Close()
     var out Measurement
@@ -112,13 +167,30 @@ func (r *repository) CreateMeasurement(ctx context.Context, m MeasurementCreate)
     return &out, nil
 }
 
+// MergeMeasurement upserts only the given (non-nil) values of the day: with overwrite
+// they replace stored ones, otherwise they only fill empty columns.
+func (r *repository) MergeMeasurement(ctx context.Context, m MeasurementCreate, overwrite bool) error {
+    set := "%[1]s=COALESCE(EXCLUDED.%[1]s, body_measurements.%[1]s)"
+    if !overwrite { set = "%[1]s=COALESCE(body_measurements.%[1]s, EXCLUDED.%[1]s)" }
+    cols := []string{"chest", "waist", "hips", "neck", "arm", "thigh", "calf", "body_fat"}
+    sets := make([]string, len(cols))
+    for i, c := range cols { sets[i] = fmt.Sprintf(set, c) }
+    q := `
+        INSERT INTO body_measurements (user_id, chest, waist, hips, neck, arm, thigh, calf, body_fat, logged_at)
+        VALUES (:user_id, :chest, :waist, :hips, :neck, :arm, :thigh, :calf, :body_fat, :logged_at)
+        ON CONFLICT (user_id, logged_at) DO UPDATE SET ` + strings.Join(sets, ", ") + `, updated_at=now();`
+    _, err := r.db.NamedExecContext(ctx, q, m)
+    return err
+}
+
 func (r *repository) UpdateMeasurement(ctx context.Context, m Measurement) (*Measurement, error) {
     const q = `
         UPDATE body_measurements
-        SET chest=:chest, waist=:waist, hips=:hips, logged_at=:logged_at, updated_at=now()
+        SET chest=:chest, waist=:waist, hips=:hips, neck=:neck, arm=:arm, thigh=:thigh, calf=:calf, body_fat=:body_fat,
+            logged_at=:logged_at, updated_at=now()
         WHERE id=:id AND user_id=:user_id
-        RETURNING id, user_id, chest, waist, hips, logged_at, created_at, updated_at;`
-    rows, err := r.db.NamedQueryContext(ctx, q, m)
+        RETURNING id, user_id, chest, waist, hips, neck, arm, thigh, calf, body_fat, logged_at, created_at, updated_at;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, m)
     if err != nil { return nil, err }
     defer rows.Close()
     var out Measurement
@@ -132,7 +204,7 @@ func (r *repository) DeleteMeasurement(ctx context.Context, id int64, userId str
 }
 
 func (r *repository) GetMeasurements(ctx context.Context, userId string, from, to *time.Time) ([]Measurement, error) {
-    q := `SELECT id, user_id, chest, waist, hips, logged_at, created_at, updated_at FROM body_measurements WHERE user_id = $1`
+    q := `SELECT id, user_id, chest, waist, hips, neck, arm, thigh, calf, body_fat, logged_at, created_at, updated_at FROM body_measurements WHERE user_id = $1`
     args := []any{userId}
     if from != nil { q += fmt.Sprintf(" AND logged_at >= $%d", len(args)+1); args = append(args, *from) }
     if to != nil { q += fmt.Sprintf(" AND logged_at <= $%d", len(args)+1); args = append(args, *to) }
@@ -144,17 +216,19 @@ func (r *repository) GetMeasurements(ctx context.Context, userId string, from, t
 
 func (r *repository) GetLatestMeasurement(ctx context.Context, userId string) (*Measurement, error) {
     var m Measurement
-    err := r.db.GetContext(ctx, &m, `SELECT id, user_id, chest, waist, hips, logged_at, created_at, updated_at FROM body_measurements WHERE user_id=$1 ORDER BY logged_at DESC LIMIT 1`, userId)
+    err := r.db.GetContext(ctx, &m, `SELECT id, user_id, chest, waist, hips, neck, arm, thigh, calf, body_fat, logged_at, created_at, updated_at FROM body_measurements WHERE user_id=$1 ORDER BY logged_at DESC LIMIT 1`, userId)
     if err != nil { return nil, err }
     return &m, nil
 }
 
 // ===== Analytics helpers from products =====
+// days are split in the user's timezone, like analytics
 func (r *repository) GetDailyCalories(ctx context.Context, userId string, from, to time.Time) (map[string]float64, error) {
+    day := database.LocalDate("created_at", database.UserTimezone("$1"))
     rows, err := r.db.QueryxContext(ctx, `
-        SELECT created_at::date AS d, COALESCE(SUM(calories),0)::float AS v
+        SELECT `+day+` AS d, COALESCE(SUM(calories),0)::float AS v
         FROM products
-        WHERE user_id=$1 AND created_at >= $2 AND created_at < ($3 + INTERVAL '1 day')
+        WHERE user_id=$1 AND `+database.LocalDays("created_at", "$2::date", "$3::date", database.UserTimezone("$1"))+`
         GROUP BY d
         ORDER BY d`, userId, from, to)
     if err != nil { return nil, err }
@@ -170,10 +244,11 @@ func (r *repository) GetDailyCalories(ctx context.Context, userId string, from,
 }
 
 func (r *repository) GetDailyProtein(ctx context.Context, userId string, from, to time.Time) (map[string]float64, error) {
+    day := database.LocalDate("created_at", database.UserTimezone("$1"))
     rows, err := r.db.QueryxContext(ctx, `
-        SELECT created_at::date AS d, COALESCE(SUM(protein),0)::float AS v
+        SELECT `+day+` AS d, COALESCE(SUM(protein),0)::float AS v
         FROM products
-        WHERE user_id=$1 AND created_at >= $2 AND created_at < ($3 + INTERVAL '1 day')
+        WHERE user_id=$1 AND `+database.LocalDays("created_at", "$2::date", "$3::date", database.UserTimezone("$1"))+`
         GROUP BY d
         ORDER BY d`, userId, from, to)
     if err != nil { return nil, err }
@@ -208,7 +283,7 @@ func (r *repository) GetDailySteps(ctx context.Context, userId string, from, to
 
 func (r *repository) GetDailySleepMin(ctx context.Context, userId string, from, to time.Time) (map[string]int, error) {
     rows, err := r.db.QueryxContext(ctx, `
-        SELECT logged_at AS d, COALESCE(sleep_min,0) AS v
+        SELECT logged_at AS d, COALESCE(sleep_sessions_min, sleep_min, 0) AS v
         FROM body_activity
         WHERE user_id=$1 AND logged_at >= $2 AND logged_at <= $3
         ORDER BY d`, userId, from, to)
@@ -230,8 +305,8 @@ func (r *repository) CreateActivity(ctx context.Context, a ActivityCreate) (*Act
         INSERT INTO body_activity (user_id, steps, sleep_min, logged_at)
         VALUES (:user_id, :steps, :sleep_min, :logged_at)
         ON CONFLICT (user_id, logged_at) DO UPDATE SET steps=COALESCE(EXCLUDED.steps, body_activity.steps), sleep_min=COALESCE(EXCLUDED.sleep_min, body_activity.sleep_min), updated_at=now()
-        RETURNING id, user_id, steps, sleep_min, logged_at, created_at, updated_at;`
-    rows, err := r.db.NamedQueryContext(ctx, q, a)
+        RETURNING id, user_id, steps, sleep_min, sleep_sessions_min, logged_at, created_at, updated_at;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, a)
     if err != nil { return nil, err }
     defer rows.Close()
     var out Activity
@@ -244,8 +319,8 @@ func (r *repository) UpdateActivity(ctx context.Context, a Activity) (*Activity,
         UPDATE body_activity
         SET steps=:steps, sleep_min=:sleep_min, logged_at=:logged_at, updated_at=now()
         WHERE id=:id AND user_id=:user_id
-        RETURNING id, user_id, steps, sleep_min, logged_at, created_at, updated_at;`
-    rows, err := r.db.NamedQueryContext(ctx, q, a)
+        RETURNING id, user_id, steps, sleep_min, sleep_sessions_min, logged_at, created_at, updated_at;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, a)
     if err != nil { return nil, err }
     defer rows.Close()
     var out Activity
@@ -259,7 +334,7 @@ func (r *repository) DeleteActivity(ctx context.Context, id int64, userId string
 }
 
 func (r *repository) GetActivity(ctx context.Context, userId string, from, to *time.Time) ([]Activity, error) {
-    q := `SELECT id, user_id, steps, sleep_min, logged_at, created_at, updated_at FROM body_activity WHERE user_id = $1`
+    q := `SELECT id, user_id, steps, sleep_min, sleep_sessions_min, logged_at, created_at, updated_at FROM body_activity WHERE user_id = $1`
     args := []any{userId}
     if from != nil { q += fmt.Sprintf(" AND logged_at >= $%d", len(args)+1); args = append(args, *from) }
     if to != nil { q += fmt.Sprintf(" AND logged_at <= $%d", len(args)+1); args = append(args, *to) }
@@ -269,14 +344,221 @@ func (r *repository) GetActivity(ctx context.Context, userId string, from, to *t
     return res, nil
 }
 
+// ===== Sleep sessions =====
+const sleepColumns = `id, user_id, start_at, end_at, sleep_date, duration_min, quality, is_nap, note, created_at, updated_at`
+
+func (r *repository) CreateSleep(ctx context.Context, sc SleepSessionCreate) (*SleepSession, error) {
+    const q = `
+        INSERT INTO body_sleep (user_id, start_at, end_at, sleep_date, duration_min, quality, is_nap, note)
+        VALUES (:user_id, :start_at, :end_at, :sleep_date, :duration_min, :quality, :is_nap, :note)
+        RETURNING ` + sleepColumns + `;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, sc)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out SleepSession
+    if rows.Next() { if err := rows.StructScan(&out); err != nil { return nil, err } }
+    return &out, nil
+}
+
+func (r *repository) UpdateSleep(ctx context.Context, ss SleepSession) (*SleepSession, error) {
+    const q = `
+        UPDATE body_sleep
+        SET start_at=:start_at, end_at=:end_at, sleep_date=:sleep_date, duration_min=:duration_min,
+            quality=:quality, is_nap=:is_nap, note=:note, updated_at=now()
+        WHERE id=:id AND user_id=:user_id
+        RETURNING ` + sleepColumns + `;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, ss)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out SleepSession
+    if rows.Next() { if err := rows.StructScan(&out); err != nil { return nil, err } }
+    return &out, nil
+}
+
+func (r *repository) DeleteSleep(ctx context.Context, id int64, userId string) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM body_sleep WHERE id=$1 AND user_id=$2`, id, userId)
+    return err
+}
+
+func (r *repository) GetSleepById(ctx context.Context, id int64, userId string) (*SleepSession, error) {
+    var out SleepSession
+    err := r.db.GetContext(ctx, &out, `SELECT `+sleepColumns+` FROM body_sleep WHERE id=$1 AND user_id=$2`, id, userId)
+    if err != nil { return nil, err }
+    return &out, nil
+}
+
+func (r *repository) GetSleep(ctx context.Context, userId string, from, to *time.Time) ([]SleepSession, error) {
+    q := `SELECT ` + sleepColumns + ` FROM body_sleep WHERE user_id = $1`
+    args := []any{userId}
+    if from != nil { q += fmt.Sprintf(" AND sleep_date >= $%d", len(args)+1); args = append(args, *from) }
+    if to != nil { q += fmt.Sprintf(" AND sleep_date <= $%d", len(args)+1); args = append(args, *to) }
+    q += ` ORDER BY start_at`
+    var res []SleepSession
+    if err := r.db.SelectContext(ctx, &res, q, args...); err != nil { return nil, err }
+    return res, nil
+}
+
+// SetSessionSleep stores the day's sleep sessions total in body_activity.sleep_sessions_min;
+// nil clears it without creating a row. Manual or imported sleep_min is never touched.
+func (r *repository) SetSessionSleep(ctx context.Context, userId string, day time.Time, minutes *int) error {
+    if minutes == nil {
+        _, err := r.db.ExecContext(ctx, `UPDATE body_activity SET sleep_sessions_min=NULL, updated_at=now() WHERE user_id=$1 AND logged_at=$2 AND sleep_sessions_min IS NOT NULL`, userId, day)
+        return err
+    }
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO body_activity (user_id, sleep_sessions_min, logged_at) VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, logged_at) DO UPDATE SET sleep_sessions_min=EXCLUDED.sleep_sessions_min, updated_at=now()`, userId, *minutes, day)
+    return err
+}
+
+// ===== Workouts =====
+const workoutColumns = `id, user_id, type, duration_min, intensity, distance_km, met, weight, calories, source, logged_at, created_at, updated_at`
+
+func (r *repository) CreateWorkout(ctx context.Context, w WorkoutCreate) (*Workout, error) {
+    const q = `
+        INSERT INTO body_workouts (user_id, type, duration_min, intensity, distance_km, met, weight, calories, source, logged_at)
+        VALUES (:user_id, :type, :duration_min, :intensity, :distance_km, :met, :weight, :calories, :source, :logged_at)
+        RETURNING ` + workoutColumns + `;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, w)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out Workout
+    if rows.Next() { if err := rows.StructScan(&out); err != nil { return nil, err } }
+    return &out, nil
+}
+
+func (r *repository) UpdateWorkout(ctx context.Context, w Workout) (*Workout, error) {
+    const q = `
+        UPDATE body_workouts
+        SET type=:type, duration_min=:duration_min, intensity=:intensity, distance_km=:distance_km,
+            met=:met, weight=:weight, calories=:calories, source=:source, logged_at=:logged_at, updated_at=now()
+        WHERE id=:id AND user_id=:user_id
+        RETURNING ` + workoutColumns + `;`
+    rows, err := sqlx.NamedQueryContext(ctx, r.db, q, w)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out Workout
+    if rows.Next() { if err := rows.StructScan(&out); err != nil { return nil, err } }
+    return &out, nil
+}
+
+// ImportWorkout inserts an imported workout once per external id; false — already imported.
+func (r *repository) ImportWorkout(ctx context.Context, w WorkoutCreate) (bool, error) {
+    const q = `
+        INSERT INTO body_workouts (user_id, type, duration_min, intensity, distance_km, met, weight, calories, source, logged_at, external_id)
+        VALUES (:user_id, :type, :duration_min, :intensity, :distance_km, :met, :weight, :calories, :source, :logged_at, :external_id)
+        ON CONFLICT (user_id, external_id) WHERE external_id IS NOT NULL DO NOTHING;`
+    res, err := r.db.NamedExecContext(ctx, q, w)
+    if err != nil { return false, err }
+    n, err := res.RowsAffected()
+    return n > 0, err
+}
+
+func (r *repository) DeleteWorkout(ctx context.Context, id int64, userId string) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM body_workouts WHERE id=$1 AND user_id=$2`, id, userId)
+    return err
+}
+
+func (r *repository) GetWorkouts(ctx context.Context, userId string, from, to *time.Time) ([]Workout, error) {
+    q := `SELECT ` + workoutColumns + ` FROM body_workouts WHERE user_id = $1`
+    args := []any{userId}
+    if from != nil { q += fmt.Sprintf(" AND logged_at >= $%d", len(args)+1); args = append(args, *from) }
+    if to != nil { q += fmt.Sprintf(" AND logged_at <= $%d", len(args)+1); args = append(args, *to) }
+    q += ` ORDER BY logged_at, id`
+    var res []Workout
+    if err := r.db.SelectContext(ctx, &res, q, args...); err != nil { return nil, err }
+    return res, nil
+}
+
+// ===== Guardrail alerts =====
+func (r *repository) HasRecentGuardrailAlert(ctx context.Context, userId, code string, since time.Time) (bool, error) {
+    var exists bool
+    err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM body_guardrail_alerts WHERE user_id=$1 AND code=$2 AND sent_at >= $3)`, userId, code, since)
+    return exists, err
+}
+
+func (r *repository) CreateGuardrailAlert(ctx context.Context, userId, code string) error {
+    _, err := r.db.ExecContext(ctx, `INSERT INTO body_guardrail_alerts (user_id, code) VALUES ($1, $2)`, userId, code)
+    return err
+}
+
+func (r *repository) GetPlateauEvent(ctx context.Context, id int64, userId string) (*PlateauEvent, error) {
+    var e PlateauEvent
+    err := r.db.GetContext(ctx, &e, `SELECT id, user_id, window_start, window_end, goal, slope_weekly_pct, delta_kg, days_with_weight, calories_good_days, protein_good_days, window_days, is_plateau, reason, params, recommendations, accepted_recommendation, accepted_at, created_at, updated_at FROM body_plateau_events WHERE id=$1 AND user_id=$2`, id, userId)
+    if err != nil { return nil, err }
+    decodePlateauEvent(&e)
+    return &e, nil
+}
+
+// AcceptPlateauRecommendation claims the event for the recommendation; false when another one was accepted first.
+func (r *repository) AcceptPlateauRecommendation(ctx context.Context, id int64, userId, code string) (bool, error) {
+    var claimed int64
+    err := r.db.GetContext(ctx, &claimed, `UPDATE body_plateau_events SET accepted_recommendation=$3, accepted_at=now() WHERE id=$1 AND user_id=$2 AND accepted_recommendation IS NULL RETURNING id`, id, userId, code)
+    if errors.Is(err, sql.ErrNoRows) { return false, nil }
+    if err != nil { return false, err }
+    return true, nil
+}
+
+// ReleasePlateauRecommendation undoes a claim whose targets could not be applied.
+func (r *repository) ReleasePlateauRecommendation(ctx context.Context, id int64, userId, code string) error {
+    _, err := r.db.ExecContext(ctx, `UPDATE body_plateau_events SET accepted_recommendation=NULL, accepted_at=NULL WHERE id=$1 AND user_id=$2 AND accepted_recommendation=$3`, id, userId, code)
+    return err
+}
+
+func decodePlateauEvent(e *PlateauEvent) {
+    if len(e.ParamsRaw) > 0 {
+        var p PlateauParams
+        if err := json.Unmarshal(e.ParamsRaw, &p); err == nil { e.Params = &p }
+    }
+    if len(e.RecommendationsRaw) > 0 { _ = json.Unmarshal(e.RecommendationsRaw, &e.Recommendations) }
+}
+
+// ===== Plateau params =====
+// GetPlateauParamLayers returns overrides from the least to the most specific level.
+// An empty userId returns the global layer only.
+func (r *repository) GetPlateauParamLayers(ctx context.Context, userId string) ([]PlateauParamLayer, error) {
+    var res []PlateauParamLayer
+    err := r.db.SelectContext(ctx, &res, `
+        SELECT p.scope, p.params FROM body_plateau_params p
+        WHERE p.scope = 'global'
+           OR (p.scope = 'plan' AND p.plan_id = (
+                SELECT s.plan_id FROM subscriptions s
+                WHERE s.user_id = NULLIF($1, '')::uuid AND s.status IN ('active', 'trialing')
+                ORDER BY s.period_end DESC LIMIT 1))
+           OR (p.scope = 'user' AND p.user_id = NULLIF($1, '')::uuid)
+        ORDER BY CASE p.scope WHEN 'global' THEN 0 WHEN 'plan' THEN 1 ELSE 2 END`, userId)
+    return res, err
+}
+
+func (r *repository) UpsertPlateauParams(ctx context.Context, scope string, planId *int64, userId *string, params []byte) error {
+    var conflict string
+    switch scope {
+    case "global": conflict = `(scope) WHERE scope = 'global'`
+    case "plan": conflict = `(plan_id) WHERE scope = 'plan'`
+    default: conflict = `(user_id) WHERE scope = 'user'`
+    }
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO body_plateau_params (scope, plan_id, user_id, params)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT `+conflict+` DO UPDATE SET params = EXCLUDED.params, updated_at = now()`,
+        scope, planId, userId, params)
+    return err
+}
+
+func (r *repository) DeletePlateauParams(ctx context.Context, scope string, userId string) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM body_plateau_params WHERE scope=$1 AND user_id=$2`, scope, userId)
+    return err
+}
+
 func (r *repository) GetPlateauHistory(ctx context.Context, userId string, from, to *time.Time) ([]PlateauEvent, error) {
-    q := `SELECT id, user_id, window_start, window_end, goal, slope_weekly_pct, delta_kg, days_with_weight, calories_good_days, protein_good_days, window_days, is_plateau, reason, created_at FROM body_plateau_events WHERE user_id=$1`
+    q := `SELECT id, user_id, window_start, window_end, goal, slope_weekly_pct, delta_kg, days_with_weight, calories_good_days, protein_good_days, window_days, is_plateau, reason, params, recommendations, accepted_recommendation, accepted_at, created_at, updated_at FROM body_plateau_events WHERE user_id=$1`
     args := []any{userId}
     if from != nil { q += fmt.Sprintf(" AND window_start >= $%d", len(args)+1); args = append(args, *from) }
     if to != nil { q += fmt.Sprintf(" AND window_end <= $%d", len(args)+1); args = append(args, *to) }
     q += ` ORDER BY created_at DESC`
     var list []PlateauEvent
     if err := r.db.SelectContext(ctx, &list, q, args...); err != nil { return nil, err }
+    for i := range list { decodePlateauEvent(&list[i]) }
     return list, nil
 }
 
@@ -2,12 +2,17 @@ package body
 
 import (
     "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
     "math"
     "time"
 
     "github.com/jmoiron/sqlx"
 
     "github.com/jourloy/nutri-backend/internal/database"
+    "github.com/jourloy/nutri-backend/internal/fit"
 )
 
 type Service interface {
@@ -17,12 +22,14 @@ type Service interface {
     DeleteWeight(ctx context.Context, id int64, userId string) error
     GetWeights(ctx context.Context, userId string, from, to *time.Time) ([]Weight, error)
     GetLatestWeight(ctx context.Context, userId string) (*Weight, error)
+    GetWeightTrend(ctx context.Context, userId string, from, to *time.Time) (*Trend, error)
     // measurements
     CreateMeasurement(ctx context.Context, m MeasurementCreate) (*Measurement, error)
     UpdateMeasurement(ctx context.Context, m Measurement) (*Measurement, error)
     DeleteMeasurement(ctx context.Context, id int64, userId string) error
     GetMeasurements(ctx context.Context, userId string, from, to *time.Time) ([]Measurement, error)
     GetLatestMeasurement(ctx context.Context, userId string) (*Measurement, error)
+    GetComposition(ctx context.Context, userId string, from, to *time.Time) ([]CompositionPoint, error)
     // analytics
     EvaluatePlateau(ctx context.Context, userId string) (*PlateauResult, error)
     // activity
@@ -30,33 +37,91 @@ type Service interface {
     UpdateActivity(ctx context.Context, a Activity) (*Activity, error)
     DeleteActivity(ctx context.Context, id int64, userId string) error
     GetActivity(ctx context.Context, userId string, from, to *time.Time) ([]Activity, error)
+    // sleep sessions
+    CreateSleep(ctx context.Context, sc SleepSessionCreate) (*SleepSession, error)
+    UpdateSleep(ctx context.Context, ss SleepSession) (*SleepSession, error)
+    DeleteSleep(ctx context.Context, id int64, userId string) error
+    GetSleep(ctx context.Context, userId string, from, to *time.Time) ([]SleepSession, error)
+    GetSleepStats(ctx context.Context, userId string, from, to time.Time) (*SleepStats, error)
+    // workouts
+    CreateWorkout(ctx context.Context, w WorkoutCreate) (*Workout, error)
+    UpdateWorkout(ctx context.Context, w Workout) (*Workout, error)
+    DeleteWorkout(ctx context.Context, id int64, userId string) error
+    GetWorkouts(ctx context.Context, userId string, from, to *time.Time) ([]Workout, error)
+    // health app import
+    ImportHealth(ctx context.Context, userId, source, filename string, file io.ReaderAt, size int64, loc *time.Location) (*ImportStats, error)
+    ImportScale(ctx context.Context, userId string, r io.Reader, opts ScaleImportOptions) (*ScaleImportResult, error)
     // plateau history
     GetPlateauHistory(ctx context.Context, userId string, from, to *time.Time) ([]PlateauEvent, error)
+    // plateau params
+    ResolvePlateauParams(ctx context.Context, userId string) (*PlateauParams, error)
+    SetPlateauParams(ctx context.Context, scope string, planId *int64, userId *string, override json.RawMessage) error
+    ResetUserPlateauParams(ctx context.Context, userId string) error
+    // plateau recommendations
+    AcceptRecommendation(ctx context.Context, userId string, eventId int64, code string) (*fit.FitProfile, error)
+    // adaptive expenditure
+    EstimateExpenditure(ctx context.Context, userId string) (*ExpenditureEstimate, error)
+    ApplyExpenditure(ctx context.Context, userId string) (*fit.FitProfile, error)
+    // goal forecast
+    ForecastGoal(ctx context.Context, userId string) (*GoalForecast, error)
+    // safety guardrails
+    CheckGuardrails(ctx context.Context, userId string) (*GuardrailReport, error)
 }
 
 type service struct {
-    repo Repository
-    db   *sqlx.DB
+    repo       Repository
+    db         *sqlx.DB
+    fitService fit.Service
 }
 
-func NewService() Service { return &service{repo: NewRepository(), db: database.Database} }
+func NewService() Service { return &service{repo: NewRepository(), db: database.Database, fitService: fit.NewService()} }
 
 // passthrough
-func (s *service) CreateWeight(ctx context.Context, w WeightCreate) (*Weight, error) { return s.repo.CreateWeight(ctx, w) }
-func (s *service) UpdateWeight(ctx context.Context, w Weight) (*Weight, error) { return s.repo.UpdateWeight(ctx, w) }
-func (s *service) DeleteWeight(ctx context.Context, id int64, userId string) error { return s.repo.DeleteWeight(ctx, id, userId) }
+func (s *service) CreateWeight(ctx context.Context, w WeightCreate) (*Weight, error) {
+    res, err := s.repo.CreateWeight(ctx, w)
+    if err == nil && res != nil { s.syncProfileWeight(ctx, w.UserId) }
+    return res, err
+}
+func (s *service) UpdateWeight(ctx context.Context, w Weight) (*Weight, error) {
+    res, err := s.repo.UpdateWeight(ctx, w)
+    if err == nil && res != nil { s.syncProfileWeight(ctx, w.UserId) }
+    return res, err
+}
+func (s *service) DeleteWeight(ctx context.Context, id int64, userId string) error {
+    if err := s.repo.DeleteWeight(ctx, id, userId); err != nil { return err }
+    s.syncProfileWeight(ctx, userId)
+    return nil
+}
 func (s *service) GetWeights(ctx context.Context, userId string, from, to *time.Time) ([]Weight, error) {
     return s.repo.GetWeights(ctx, userId, from, to)
 }
 func (s *service) GetLatestWeight(ctx context.Context, userId string) (*Weight, error) { return s.repo.GetLatestWeight(ctx, userId) }
-func (s *service) CreateMeasurement(ctx context.Context, m MeasurementCreate) (*Measurement, error) { return s.repo.CreateMeasurement(ctx, m) }
-func (s *service) UpdateMeasurement(ctx context.Context, m Measurement) (*Measurement, error) { return s.repo.UpdateMeasurement(ctx, m) }
+func (s *service) CreateMeasurement(ctx context.Context, m MeasurementCreate) (*Measurement, error) {
+    if err := validateBodyFat(m.BodyFat); err != nil { return nil, err }
+    return s.repo.CreateMeasurement(ctx, m)
+}
+func (s *service) UpdateMeasurement(ctx context.Context, m Measurement) (*Measurement, error) {
+    if err := validateBodyFat(m.BodyFat); err != nil { return nil, err }
+    return s.repo.UpdateMeasurement(ctx, m)
+}
+
+// validateBodyFat applies the same bounds as the scale import.
+func validateBodyFat(v *float64) error {
+    if v != nil && !scaleValueValid("bodyFat", *v) { return errors.New("body fat must be between 2 and 75 %") }
+    return nil
+}
 func (s *service) DeleteMeasurement(ctx context.Context, id int64, userId string) error { return s.repo.DeleteMeasurement(ctx, id, userId) }
 func (s *service) GetMeasurements(ctx context.Context, userId string, from, to *time.Time) ([]Measurement, error) {
-    return s.repo.GetMeasurements(ctx, userId, from, to)
+    list, err := s.repo.GetMeasurements(ctx, userId, from, to)
+    if err != nil { return nil, err }
+    return s.withComposition(ctx, userId, list)
 }
 func (s *service) GetLatestMeasurement(ctx context.Context, userId string) (*Measurement, error) {
-    return s.repo.GetLatestMeasurement(ctx, userId)
+    m, err := s.repo.GetLatestMeasurement(ctx, userId)
+    if err != nil { return nil, err }
+    list, err := s.withComposition(ctx, userId, []Measurement{*m})
+    if err != nil { return nil, err }
+    return &list[0], nil
 }
 
 // activity passthrough
@@ -72,9 +137,12 @@ func (s *service) GetPlateauHistory(ctx context.Context, userId string, from, to
 
 // ===== Plateau evaluation =====
 func (s *service) EvaluatePlateau(ctx context.Context, userId string) (*PlateauResult, error) {
+    params, err := s.ResolvePlateauParams(ctx, userId)
+    if err != nil { return nil, err }
     // window
-    windowDays := 21
-    end := time.Now().Truncate(24 * time.Hour)
+    windowDays := params.WindowDays
+    end, err := database.UserToday(ctx, userId)
+    if err != nil { return nil, err }
     start := end.AddDate(0, 0, -windowDays+1)
 
     // fetch weights (avg per day)
@@ -92,57 +160,50 @@ func (s *service) EvaluatePlateau(ctx context.Context, userId string) (*PlateauR
             series = append(series, dp{d: day, v: v})
         }
     }
-    if len(series) < 7 {
+    if len(series) < params.MinWeightDays {
         // not enough data
-        res := &PlateauResult{IsPlateau: false, Goal: "unknown", WindowStart: start.Format("2006-01-02"), WindowEnd: end.Format("2006-01-02"), WindowDays: windowDays, DaysWithWeight: len(series), Reason: "Недостаточно данных (минимум 7 дней)"}
+        res := &PlateauResult{IsPlateau: false, Goal: "unknown", WindowStart: start.Format("2006-01-02"), WindowEnd: end.Format("2006-01-02"), WindowDays: windowDays, DaysWithWeight: len(series), Reason: fmt.Sprintf("Недостаточно данных (минимум %d дней)", params.MinWeightDays), Params: params}
         return res, nil
     }
 
-    // EWMA smoothing (lambda=0.2)
-    lambda := 0.2
-    sm := make([]float64, len(series))
-    sm[0] = series[0].v
-    for i := 1; i < len(series); i++ { sm[i] = lambda*series[i].v + (1-lambda)*sm[i-1] }
+    // EWMA smoothing
+    lambda := params.Lambda
+    raw := make([]float64, len(series))
+    for i, p := range series { raw[i] = p.v }
+    sm := ewma(raw, lambda)
 
     // OLS slope over indexes 0..n-1
-    n := float64(len(sm))
-    var sumX, sumY, sumXY, sumXX float64
-    for i, y := range sm {
-        x := float64(i)
-        sumX += x
-        sumY += y
-        sumXY += x * y
-        sumXX += x * x
-    }
-    denom := n*sumXX - sumX*sumX
-    if denom == 0 { denom = 1 }
-    slopePerDay := (n*sumXY - sumX*sumY) / denom // kg/day
-    meanW := sumY / n
+    slopePerDay := olsSlope(indexes(len(sm)), sm) // kg/day
+    meanW := mean(sm)
     slopeWeeklyPct := (slopePerDay / meanW) * 7 * 100.0
     deltaKg := sm[len(sm)-1] - sm[0]
 
-    // Fetch fit profile for goal and targets
+    // Fetch fit profile for goal; calorie targets are resolved per day from history
     var goal string
-    var targetCalories, profileWeight float64
     _ = s.db.GetContext(ctx, &goal, `SELECT goal FROM fit_profiles WHERE user_id=$1 ORDER BY created_at DESC LIMIT 1`, userId)
-    _ = s.db.GetContext(ctx, &targetCalories, `SELECT calories::float FROM fit_profiles WHERE user_id=$1 ORDER BY created_at DESC LIMIT 1`, userId)
-    _ = s.db.GetContext(ctx, &profileWeight, `SELECT weight::float FROM fit_profiles WHERE user_id=$1 ORDER BY created_at DESC LIMIT 1`, userId)
     if goal == "" { goal = "unknown" }
+    targets, err := s.fitService.ResolveTargets(ctx, userId, start, end)
+    if err != nil { return nil, err }
+    targetCalories := targets[end.Format("2006-01-02")].Calories
 
-    // Compliance: calories +/- 10%, protein >= 1.6g/kg
+    // Compliance: calories within tolerance, protein >= params.ProteinPerKg
     dailyCals, _ := s.repo.GetDailyCalories(ctx, userId, start, end)
     dailyProt, _ := s.repo.GetDailyProtein(ctx, userId, start, end)
     dailySteps, _ := s.repo.GetDailySteps(ctx, userId, start, end)
     dailySleep, _ := s.repo.GetDailySleepMin(ctx, userId, start, end)
     calsGood := 0
     protGood := 0
-    lower := 0.9 * targetCalories
-    upper := 1.1 * targetCalories
-    proteinTarget := 1.6 * profileWeight
-    // iterate days in window
+    tol := params.CalorieTolerancePct / 100
+    // iterate days in window, comparing each day against the targets valid on that day;
+    // protein is per kg of the smoothed weight of that day (the last weigh-in on or before it)
+    next := 0
     for d := 0; d < windowDays; d++ {
-        day := start.AddDate(0,0,d).Format("2006-01-02")
-        if v, ok := dailyCals[day]; ok && targetCalories > 0 && v >= lower && v <= upper { calsGood++ }
+        date := start.AddDate(0,0,d)
+        day := date.Format("2006-01-02")
+        for next+1 < len(series) && !series[next+1].d.After(date) { next++ }
+        proteinTarget := params.ProteinPerKg * sm[next]
+        dayCalories := targets[day].Calories
+        if v, ok := dailyCals[day]; ok && dayCalories > 0 && v >= (1-tol)*dayCalories && v <= (1+tol)*dayCalories { calsGood++ }
         if v, ok := dailyProt[day]; ok && proteinTarget > 0 && v >= proteinTarget { protGood++ }
     }
     // steps & sleep averages across the window
@@ -155,19 +216,26 @@ func (s *service) EvaluatePlateau(ctx context.Context, userId string) (*PlateauR
     }
     stepsAvg := float64(stepsSum) / float64(windowDays)
     sleepAvgHours := float64(sleepMinSum) / float64(windowDays*60)
+    // sleep sessions, when logged, give per-night averages and bedtime regularity
+    var sleep *SleepStats
+    if sessions, err := s.repo.GetSleep(ctx, userId, &start, &end); err == nil && len(sessions) > 0 {
+        sleep = sleepStats(sessions)
+        sleep.From, sleep.To, sleep.Days = start.Format("2006-01-02"), end.Format("2006-01-02"), nil
+        sleepAvgHours = sleep.AvgTotalMin / 60
+    }
     // steps target from fit profile
     var stepsTarget int
     _ = s.db.GetContext(ctx, &stepsTarget, `SELECT steps_target FROM fit_profiles WHERE user_id=$1 ORDER BY created_at DESC LIMIT 1`, userId)
-    if stepsTarget <= 0 { stepsTarget = 8000 }
+    if stepsTarget <= 0 { stepsTarget = params.DefaultStepsTarget }
 
-    sufficient := (calsGood >= 14) && (protGood >= 14) && (stepsAvg >= 0.8*float64(stepsTarget)) && (sleepAvgHours >= 6.0)
+    sufficient := (calsGood >= params.MinCompliantDays) && (protGood >= params.MinCompliantDays) && (stepsAvg >= params.StepsTargetShare*float64(stepsTarget)) && (sleepAvgHours >= params.MinSleepHours)
 
     // Plateau rules
     isPlateau := false
     reason := ""
     // base thresholds
-    thrPct := 0.05 // per week
-    smallDelta := 0.3 // kg
+    thrPct := params.SlopeThresholdPct // per week
+    smallDelta := params.MinDeltaKg // kg
 
     switch goal {
     case "lose", "fat_loss", "weight_loss":
@@ -185,13 +253,6 @@ func (s *service) EvaluatePlateau(ctx context.Context, userId string) (*PlateauR
         if reason == "" { reason = "Недостаточное соблюдение режима" } else { reason += "; недостаточное соблюдение" }
     }
 
-    // persist event
-    _, _ = s.db.ExecContext(ctx, `
-        INSERT INTO body_plateau_events (user_id, window_start, window_end, goal, slope_weekly_pct, delta_kg, days_with_weight, calories_good_days, protein_good_days, window_days, is_plateau, reason)
-        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`,
-        userId, start, end, goal, slopeWeeklyPct, deltaKg, len(series), calsGood, protGood, windowDays, isPlateau, reason,
-    )
-
     res := &PlateauResult{
         IsPlateau: isPlateau,
         Goal: goal,
@@ -204,11 +265,41 @@ func (s *service) EvaluatePlateau(ctx context.Context, userId string) (*PlateauR
         CaloriesGoodDays: calsGood,
         ProteinGoodDays: protGood,
         CaloriesTarget: targetCalories,
-        ProteinPerKg: 1.6,
+        ProteinPerKg: params.ProteinPerKg,
         StepsAvg: stepsAvg,
         StepsTarget: stepsTarget,
         SleepAvgHours: sleepAvgHours,
+        Sleep: sleep,
         Reason: reason,
+        Params: params,
+    }
+    // suggestions only make sense when the trend stalled (with or without good adherence)
+    if reason != "" {
+        recs, err := s.recommend(ctx, userId, res, params, sm[len(sm)-1])
+        if err != nil { return nil, err }
+        res.Recommendations = recs
+    }
+
+    // persist event with the parameters used, so results can be reproduced;
+    // re-evaluating the same window updates its row instead of adding one
+    paramsRaw, _ := json.Marshal(params)
+    recsRaw, _ := json.Marshal(res.Recommendations)
+    var eventId int64
+    if err := s.db.GetContext(ctx, &eventId, `
+        INSERT INTO body_plateau_events (user_id, window_start, window_end, goal, slope_weekly_pct, delta_kg, days_with_weight, calories_good_days, protein_good_days, window_days, is_plateau, reason, params, recommendations)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+        ON CONFLICT (user_id, window_end) DO UPDATE SET
+            window_start=EXCLUDED.window_start, goal=EXCLUDED.goal, slope_weekly_pct=EXCLUDED.slope_weekly_pct, delta_kg=EXCLUDED.delta_kg,
+            days_with_weight=EXCLUDED.days_with_weight, calories_good_days=EXCLUDED.calories_good_days, protein_good_days=EXCLUDED.protein_good_days,
+            window_days=EXCLUDED.window_days, is_plateau=EXCLUDED.is_plateau, reason=EXCLUDED.reason, params=EXCLUDED.params,
+            -- an accepted recommendation stays tied to the list it was picked from
+            recommendations=CASE WHEN body_plateau_events.accepted_recommendation IS NULL THEN EXCLUDED.recommendations ELSE body_plateau_events.recommendations END,
+            updated_at=now()
+        RETURNING id`,
+        userId, start, end, goal, slopeWeeklyPct, deltaKg, len(series), calsGood, protGood, windowDays, isPlateau, reason, paramsRaw, recsRaw,
+    ); err != nil {
+        return nil, fmt.Errorf("save plateau event: %w", err)
     }
+    res.EventId = &eventId
     return res, nil
 }
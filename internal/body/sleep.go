@@ -0,0 +1,190 @@
+package body
+
+import (
+    "context"
+    "errors"
+    "math"
+    "sort"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/lib"
+)
+
+const (
+    maxSleepSession = 20 * time.Hour
+    // bedtimes are measured from noon, so 23:30 and 00:30 are an hour apart, not 23
+    sleepClockShift = 12 * 60
+)
+
+// SleepNight — сон, отнесённый ко дню пробуждения.
+type SleepNight struct {
+    Date        string   `json:"date"`
+    Bedtime     string   `json:"bedtime,omitempty"`  // HH:MM основного сна
+    WakeTime    string   `json:"wakeTime,omitempty"` // HH:MM
+    DurationMin int      `json:"durationMin"`        // основной сон
+    NapMin      int      `json:"napMin"`
+    TotalMin    int      `json:"totalMin"`
+    Quality     *float64 `json:"quality,omitempty"`
+}
+
+// SleepStats — средние и регулярность сна за период.
+type SleepStats struct {
+    From           string       `json:"from"`
+    To             string       `json:"to"`
+    Nights         int          `json:"nights"` // дни с основным сном
+    AvgDurationMin float64      `json:"avgDurationMin"`
+    AvgNapMin      float64      `json:"avgNapMin"`
+    AvgTotalMin    float64      `json:"avgTotalMin"`
+    AvgQuality     *float64     `json:"avgQuality,omitempty"`
+    AvgBedtime     string       `json:"avgBedtime,omitempty"`
+    AvgWakeTime    string       `json:"avgWakeTime,omitempty"`
+    BedtimeStdMin  *float64     `json:"bedtimeStdMin,omitempty"` // разброс времени отхода ко сну
+    WakeStdMin     *float64     `json:"wakeStdMin,omitempty"`
+    Days           []SleepNight `json:"days,omitempty"`
+}
+
+// prepareSleep validates a session and derives its day and duration.
+func prepareSleep(sc *SleepSessionCreate) error {
+    if !sc.EndAt.After(sc.StartAt) { return errors.New("endAt must be after startAt") }
+    d := sc.EndAt.Sub(sc.StartAt)
+    if d > maxSleepSession { return errors.New("sleep session must not exceed 20 hours") }
+    if sc.Quality != nil && (*sc.Quality < 1 || *sc.Quality > 5) { return errors.New("quality must be between 1 and 5") }
+    sc.SleepDate = time.Date(sc.EndAt.Year(), sc.EndAt.Month(), sc.EndAt.Day(), 0, 0, 0, 0, time.UTC)
+    sc.DurationMin = int(math.Round(d.Minutes()))
+    return nil
+}
+
+func (s *service) CreateSleep(ctx context.Context, sc SleepSessionCreate) (*SleepSession, error) {
+    if err := prepareSleep(&sc); err != nil { return nil, err }
+    res, err := s.repo.CreateSleep(ctx, sc)
+    if err != nil { return nil, err }
+    s.syncDailySleep(ctx, sc.UserId, sc.SleepDate)
+    return res, nil
+}
+
+func (s *service) UpdateSleep(ctx context.Context, ss SleepSession) (*SleepSession, error) {
+    old, err := s.repo.GetSleepById(ctx, ss.Id, ss.UserId)
+    if err != nil { return nil, err }
+    sc := SleepSessionCreate{UserId: ss.UserId, StartAt: ss.StartAt, EndAt: ss.EndAt, Quality: ss.Quality, IsNap: ss.IsNap, Note: ss.Note}
+    if err := prepareSleep(&sc); err != nil { return nil, err }
+    ss.SleepDate, ss.DurationMin = sc.SleepDate, sc.DurationMin
+    res, err := s.repo.UpdateSleep(ctx, ss)
+    if err != nil { return nil, err }
+    s.syncDailySleep(ctx, ss.UserId, ss.SleepDate)
+    if !old.SleepDate.Equal(ss.SleepDate) { s.syncDailySleep(ctx, ss.UserId, old.SleepDate) }
+    return res, nil
+}
+
+func (s *service) DeleteSleep(ctx context.Context, id int64, userId string) error {
+    old, err := s.repo.GetSleepById(ctx, id, userId)
+    if err != nil { return err }
+    if err := s.repo.DeleteSleep(ctx, id, userId); err != nil { return err }
+    s.syncDailySleep(ctx, userId, old.SleepDate)
+    return nil
+}
+
+func (s *service) GetSleep(ctx context.Context, userId string, from, to *time.Time) ([]SleepSession, error) {
+    return s.repo.GetSleep(ctx, userId, from, to)
+}
+
+// syncDailySleep keeps the day's sessions total in body_activity next to manual or imported
+// sleep_min, so code reading the daily number stays correct. A day without sessions falls back to sleep_min.
+func (s *service) syncDailySleep(ctx context.Context, userId string, day time.Time) {
+    list, err := s.repo.GetSleep(ctx, userId, &day, &day)
+    if err != nil { logger.Warn("sync daily sleep", "user", userId, "err", err); return }
+    var total *int
+    if len(list) > 0 {
+        v := 0
+        for _, ss := range list { v += ss.DurationMin }
+        total = &v
+    }
+    if err := s.repo.SetSessionSleep(ctx, userId, day, total); err != nil { logger.Warn("sync daily sleep", "user", userId, "err", err) }
+}
+
+func (s *service) GetSleepStats(ctx context.Context, userId string, from, to time.Time) (*SleepStats, error) {
+    list, err := s.repo.GetSleep(ctx, userId, &from, &to)
+    if err != nil { return nil, err }
+    st := sleepStats(list)
+    st.From, st.To = from.Format("2006-01-02"), to.Format("2006-01-02")
+    return st, nil
+}
+
+// sleepStats groups sessions by wake-up day. Several night sessions of one day
+// (woke up at night) make one night from the first bedtime to the last wake-up.
+func sleepStats(list []SleepSession) *SleepStats {
+    type night struct {
+        bed, wake      *time.Time
+        main, nap      int
+        qualSum, qualN int
+    }
+    byDay := map[string]*night{}
+    for i := range list {
+        ss := &list[i]
+        key := ss.SleepDate.Format("2006-01-02")
+        n := byDay[key]
+        if n == nil { n = &night{}; byDay[key] = n }
+        if ss.Quality != nil { n.qualSum += *ss.Quality; n.qualN++ }
+        if ss.IsNap { n.nap += ss.DurationMin; continue }
+        n.main += ss.DurationMin
+        if n.bed == nil || ss.StartAt.Before(*n.bed) { n.bed = &ss.StartAt }
+        if n.wake == nil || ss.EndAt.After(*n.wake) { n.wake = &ss.EndAt }
+    }
+
+    keys := make([]string, 0, len(byDay))
+    for k := range byDay { keys = append(keys, k) }
+    sort.Strings(keys)
+
+    st := &SleepStats{Days: make([]SleepNight, 0, len(keys))}
+    var beds, wakes, mains, naps, totals, quals []float64
+    for _, k := range keys {
+        n := byDay[k]
+        sn := SleepNight{Date: k, DurationMin: n.main, NapMin: n.nap, TotalMin: n.main + n.nap}
+        if n.qualN > 0 {
+            q := round2(float64(n.qualSum) / float64(n.qualN))
+            sn.Quality = &q
+            quals = append(quals, q)
+        }
+        if n.bed != nil {
+            sn.Bedtime, sn.WakeTime = n.bed.Format("15:04"), n.wake.Format("15:04")
+            beds = append(beds, shiftedClock(*n.bed))
+            wakes = append(wakes, float64(n.wake.Hour()*60+n.wake.Minute()))
+            mains = append(mains, float64(n.main))
+        }
+        naps = append(naps, float64(n.nap))
+        totals = append(totals, float64(sn.TotalMin))
+        st.Days = append(st.Days, sn)
+    }
+
+    st.Nights = len(mains)
+    if len(mains) > 0 {
+        st.AvgDurationMin = round2(mean(mains))
+        st.AvgBedtime = lib.ClockString(mean(beds) + sleepClockShift)
+        st.AvgWakeTime = lib.ClockString(mean(wakes))
+    }
+    if len(totals) > 0 {
+        st.AvgNapMin = round2(mean(naps))
+        st.AvgTotalMin = round2(mean(totals))
+    }
+    if len(quals) > 0 { q := round2(mean(quals)); st.AvgQuality = &q }
+    // variance needs a few nights to mean anything
+    if len(beds) >= 3 {
+        b, w := round2(stdDev(beds)), round2(stdDev(wakes))
+        st.BedtimeStdMin, st.WakeStdMin = &b, &w
+    }
+    return st
+}
+
+// shiftedClock returns minutes since noon of the time of day.
+func shiftedClock(t time.Time) float64 {
+    m := t.Hour()*60 + t.Minute() - sleepClockShift
+    if m < 0 { m += lib.MinutesPerDay }
+    return float64(m)
+}
+
+func stdDev(vs []float64) float64 {
+    if len(vs) < 2 { return 0 }
+    mu := mean(vs)
+    var ss float64
+    for _, v := range vs { ss += (v - mu) * (v - mu) }
+    return math.Sqrt(ss / float64(len(vs)-1))
+}
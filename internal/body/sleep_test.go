@@ -0,0 +1,37 @@
+package body
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestSleepStats(t *testing.T) {
+    at := func(day, hour, min int) time.Time { return time.Date(2025, 9, day, hour, min, 0, 0, time.UTC) }
+    q := func(v int) *int { return &v }
+    list := []SleepSession{
+        {StartAt: at(1, 23, 30), EndAt: at(2, 7, 0), SleepDate: at(2, 0, 0), DurationMin: 450, Quality: q(4)},
+        {StartAt: at(3, 0, 30), EndAt: at(3, 7, 30), SleepDate: at(3, 0, 0), DurationMin: 420, Quality: q(2)},
+        {StartAt: at(3, 14, 0), EndAt: at(3, 14, 30), SleepDate: at(3, 0, 0), DurationMin: 30, IsNap: true},
+        // woke up at night: one night from the first bedtime to the last wake-up
+        {StartAt: at(3, 23, 0), EndAt: at(4, 2, 0), SleepDate: at(4, 0, 0), DurationMin: 180},
+        {StartAt: at(4, 3, 0), EndAt: at(4, 7, 0), SleepDate: at(4, 0, 0), DurationMin: 240},
+    }
+    st := sleepStats(list)
+
+    if st.Nights != 3 || st.AvgDurationMin != 430 || st.AvgNapMin != 10 || st.AvgTotalMin != 440 {
+        t.Errorf("averages = nights %d, duration %v, nap %v, total %v; want 3, 430, 10, 440", st.Nights, st.AvgDurationMin, st.AvgNapMin, st.AvgTotalMin)
+    }
+    // bedtimes around midnight average to 23:40, not midday
+    if st.AvgBedtime != "23:40" || st.AvgWakeTime != "07:10" { t.Errorf("bedtime/wake = %s/%s, want 23:40/07:10", st.AvgBedtime, st.AvgWakeTime) }
+    if st.AvgQuality == nil || *st.AvgQuality != 3 { t.Errorf("quality = %v, want 3", st.AvgQuality) }
+    if st.BedtimeStdMin == nil || *st.BedtimeStdMin != 45.83 || st.WakeStdMin == nil || *st.WakeStdMin != 17.32 {
+        t.Errorf("std = %v/%v, want 45.83/17.32", st.BedtimeStdMin, st.WakeStdMin)
+    }
+    quality := 2.0
+    want := SleepNight{Date: "2025-09-03", Bedtime: "00:30", WakeTime: "07:30", DurationMin: 420, NapMin: 30, TotalMin: 450, Quality: &quality}
+    if len(st.Days) != 3 || !reflect.DeepEqual(st.Days[1], want) { t.Errorf("days = %+v, want second %+v", st.Days, want) }
+    if d := st.Days[2]; d.Bedtime != "23:00" || d.WakeTime != "07:00" || d.DurationMin != 420 { t.Errorf("interrupted night = %+v", d) }
+
+    if st := sleepStats(nil); st.Nights != 0 || st.AvgBedtime != "" || st.BedtimeStdMin != nil { t.Errorf("empty stats = %+v", st) }
+}
@@ -0,0 +1,78 @@
+package body
+
+import (
+    "context"
+
+    "github.com/jourloy/nutri-backend/internal/database"
+)
+
+// Shared smoothing/regression helpers for weight-based analytics.
+
+// kcalPerKg — energy equivalent of 1 kg of body weight change.
+const kcalPerKg = 7700.0
+
+// ewma smooths values with an exponentially weighted moving average.
+func ewma(values []float64, lambda float64) []float64 {
+    if len(values) == 0 { return nil }
+    sm := make([]float64, len(values))
+    sm[0] = values[0]
+    for i := 1; i < len(values); i++ { sm[i] = lambda*values[i] + (1-lambda)*sm[i-1] }
+    return sm
+}
+
+// olsSlope returns the least-squares slope of ys over xs.
+func olsSlope(xs, ys []float64) float64 {
+    n := float64(len(ys))
+    var sumX, sumY, sumXY, sumXX float64
+    for i, y := range ys {
+        x := xs[i]
+        sumX += x
+        sumY += y
+        sumXY += x * y
+        sumXX += x * x
+    }
+    denom := n*sumXX - sumX*sumX
+    if denom == 0 { denom = 1 }
+    return (n*sumXY - sumX*sumY) / denom
+}
+
+// indexes returns 0..n-1 as float x values.
+func indexes(n int) []float64 {
+    xs := make([]float64, n)
+    for i := range xs { xs[i] = float64(i) }
+    return xs
+}
+
+func mean(values []float64) float64 {
+    if len(values) == 0 { return 0 }
+    var sum float64
+    for _, v := range values { sum += v }
+    return sum / float64(len(values))
+}
+
+// syncWindowDays — how far back weights are smoothed for the profile weight.
+const syncWindowDays = 14
+
+// smoothedWeight returns the EWMA-smoothed latest weight over the recent window (nil without data).
+func (s *service) smoothedWeight(ctx context.Context, userId string) (*float64, error) {
+    end, err := database.UserToday(ctx, userId)
+    if err != nil { return nil, err }
+    start := end.AddDate(0, 0, -syncWindowDays+1)
+    ws, err := s.repo.GetWeights(ctx, userId, &start, &end)
+    if err != nil || len(ws) == 0 { return nil, err }
+    raw := make([]float64, len(ws))
+    for i, w := range ws { raw[i] = w.Value }
+    sm := ewma(raw, 0.2)
+    return &sm[len(sm)-1], nil
+}
+
+// syncProfileWeight keeps the fit profile weight in line with the weight log.
+// Failures are logged only: the weight entry itself is already saved.
+func (s *service) syncProfileWeight(ctx context.Context, userId string) {
+    v, err := s.smoothedWeight(ctx, userId)
+    if err != nil { logger.Warn("smooth weight", "user", userId, "err", err); return }
+    if v == nil { return }
+    if _, err := s.fitService.SyncWeight(ctx, userId, *v); err != nil {
+        logger.Warn("sync profile weight", "user", userId, "err", err)
+    }
+}
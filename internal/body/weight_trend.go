@@ -0,0 +1,166 @@
+package body
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jourloy/nutri-backend/internal/database"
+)
+
+const (
+    // daily series is denser than raw weigh-ins, so it is smoothed gentler
+    trendLambda       = 0.1
+    trendDefaultRange = 90
+)
+
+// TrendMaxRange — самый длинный диапазон дневного тренда в днях.
+const TrendMaxRange = 366
+
+var ErrTrendRange = errors.New("range is too long")
+
+// DatedValue is a single measurement on a day.
+type DatedValue struct {
+    Date  time.Time
+    Value float64
+}
+
+type TrendPoint struct {
+    Date         string   `json:"date"`
+    Raw          *float64 `json:"raw,omitempty"` // nil — пропуск, значение интерполировано
+    Value        float64  `json:"value"`
+    Interpolated bool     `json:"interpolated"`
+    Smoothed     float64  `json:"smoothed"`
+    MA7          *float64 `json:"ma7,omitempty"`
+    MA14         *float64 `json:"ma14,omitempty"`
+}
+
+type TrendWeek struct {
+    WeekStart string   `json:"weekStart"` // понедельник
+    Avg       float64  `json:"avg"`
+    Days      int      `json:"days"` // дней с реальными значениями
+    Change    *float64 `json:"change,omitempty"`
+}
+
+// Trend — daily series with smoothing and rate of change.
+type Trend struct {
+    From           string       `json:"from"`
+    To             string       `json:"to"`
+    Points         []TrendPoint `json:"points"`
+    Weeks          []TrendWeek  `json:"weeks"`
+    Latest         *float64     `json:"latest,omitempty"` // последнее сглаженное значение
+    RatePerWeek    *float64     `json:"ratePerWeek,omitempty"`
+    RatePctPerWeek *float64     `json:"ratePctPerWeek,omitempty"`
+}
+
+// DailyTrend builds a daily series over [from, to]: gaps between values are
+// linearly interpolated, the series is EWMA-smoothed and the weekly rate is the
+// OLS slope of the smoothed values. Values must be sorted by date.
+func DailyTrend(values []DatedValue, from, to time.Time) *Trend {
+    res := &Trend{From: from.Format("2006-01-02"), To: to.Format("2006-01-02"), Points: []TrendPoint{}, Weeks: []TrendWeek{}}
+    byDay := map[string]float64{}
+    for _, v := range values {
+        if v.Date.Before(from) || v.Date.After(to) { continue }
+        byDay[v.Date.Format("2006-01-02")] = v.Value
+    }
+    if len(byDay) == 0 { return res }
+
+    // the series starts at the first and ends at the last real value — no extrapolation
+    first, last := to, from
+    for _, v := range values {
+        if v.Date.Before(from) || v.Date.After(to) { continue }
+        if v.Date.Before(first) { first = v.Date }
+        if v.Date.After(last) { last = v.Date }
+    }
+    first, last = first.Truncate(24*time.Hour), last.Truncate(24*time.Hour)
+
+    var prevDay time.Time
+    var prevVal float64
+    for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+        key := d.Format("2006-01-02")
+        if v, ok := byDay[key]; ok {
+            // fill the gap since the previous real value
+            if gap := int(d.Sub(prevDay).Hours() / 24); len(res.Points) > 0 && gap > 1 {
+                for i := 1; i < gap; i++ {
+                    idx := len(res.Points) - gap + i
+                    res.Points[idx].Value = prevVal + (v-prevVal)*float64(i)/float64(gap)
+                }
+            }
+            res.Points = append(res.Points, TrendPoint{Date: key, Raw: &v, Value: v})
+            prevDay, prevVal = d, v
+        } else {
+            res.Points = append(res.Points, TrendPoint{Date: key, Interpolated: true})
+        }
+    }
+
+    series := make([]float64, len(res.Points))
+    for i, p := range res.Points { series[i] = p.Value }
+    sm := ewma(series, trendLambda)
+    ma7, ma14 := movingAverage(series, 7), movingAverage(series, 14)
+    for i := range res.Points {
+        res.Points[i].Smoothed = round2(sm[i])
+        res.Points[i].Value = round2(res.Points[i].Value)
+        res.Points[i].MA7, res.Points[i].MA14 = ma7[i], ma14[i]
+    }
+    latest := round2(sm[len(sm)-1])
+    res.Latest = &latest
+
+    if len(sm) >= 2 {
+        rate := olsSlope(indexes(len(sm)), sm) * 7
+        pct := round2(rate / mean(sm) * 100)
+        rate = round2(rate)
+        res.RatePerWeek, res.RatePctPerWeek = &rate, &pct
+    }
+    res.Weeks = weeklyAverages(res.Points)
+    return res
+}
+
+// movingAverage returns trailing averages over window; nil until the window is full.
+func movingAverage(values []float64, window int) []*float64 {
+    res := make([]*float64, len(values))
+    var sum float64
+    for i, v := range values {
+        sum += v
+        if i >= window { sum -= values[i-window] }
+        if i >= window-1 { avg := round2(sum / float64(window)); res[i] = &avg }
+    }
+    return res
+}
+
+// weeklyAverages groups real (non-interpolated) values by ISO week.
+func weeklyAverages(points []TrendPoint) []TrendWeek {
+    res := []TrendWeek{}
+    var sum float64
+    for _, p := range points {
+        if p.Raw == nil { continue }
+        d, _ := time.Parse("2006-01-02", p.Date)
+        ws := d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7)).Format("2006-01-02")
+        if len(res) == 0 || res[len(res)-1].WeekStart != ws {
+            if len(res) > 0 { res[len(res)-1].Avg = round2(sum / float64(res[len(res)-1].Days)) }
+            res = append(res, TrendWeek{WeekStart: ws})
+            sum = 0
+        }
+        sum += *p.Raw
+        res[len(res)-1].Days++
+    }
+    if len(res) > 0 { res[len(res)-1].Avg = round2(sum / float64(res[len(res)-1].Days)) }
+    for i := 1; i < len(res); i++ {
+        change := round2(res[i].Avg - res[i-1].Avg)
+        res[i].Change = &change
+    }
+    return res
+}
+
+func (s *service) GetWeightTrend(ctx context.Context, userId string, from, to *time.Time) (*Trend, error) {
+    end, err := database.UserToday(ctx, userId)
+    if err != nil { return nil, err }
+    if to != nil { end = *to }
+    start := end.AddDate(0, 0, -trendDefaultRange+1)
+    if from != nil { start = *from }
+    if end.Sub(start) >= TrendMaxRange*24*time.Hour { return nil, ErrTrendRange }
+    ws, err := s.repo.GetWeights(ctx, userId, &start, &end)
+    if err != nil { return nil, err }
+    values := make([]DatedValue, len(ws))
+    for i, w := range ws { values[i] = DatedValue{Date: w.LoggedAt, Value: w.Value} }
+    return DailyTrend(values, start, end), nil
+}
@@ -2,43 +2,99 @@ package body
 
 import (
     "context"
+    "sync"
+    "sync/atomic"
     "time"
 
     "github.com/charmbracelet/log"
     "github.com/jmoiron/sqlx"
 
     "github.com/jourloy/nutri-backend/internal/database"
+    "github.com/jourloy/nutri-backend/internal/lib"
+    "github.com/jourloy/nutri-backend/internal/telegram"
 )
 
 var (
     bgLogger = log.WithPrefix("[bodyw]")
 )
 
+const (
+    // only users who logged weight recently are evaluated
+    workerActiveDays  = 21
+    workerUserTimeout = 30 * time.Second
+)
+
 func StartWorker() {
     go func() {
-        // initial run after startup delay
-        time.Sleep(5 * time.Second)
-        runOnce()
-        ticker := time.NewTicker(24 * time.Hour)
-        defer ticker.Stop()
-        for range ticker.C { runOnce() }
+        for {
+            next := nextRunAt(time.Now().In(lib.Config.BodyWorkerLocation), lib.Config.BodyWorkerHour)
+            bgLogger.Info("next run", "at", next.Format(time.RFC3339))
+            time.Sleep(time.Until(next))
+            runOnce()
+        }
     }()
 }
 
+// nextRunAt returns the next moment of the given hour in now's location (BODY_WORKER_TZ, UTC by default).
+func nextRunAt(now time.Time, hour int) time.Time {
+    next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+    if !next.After(now) { next = next.AddDate(0, 0, 1) }
+    return next
+}
+
 func runOnce() {
-    svc := NewService()
+    started := time.Now().In(lib.Config.BodyWorkerLocation)
+    svc := NewService().(*service)
     db := database.Database
-    ids, err := getAllUserIds(db)
-    if err != nil { bgLogger.Error("load users", "err", err); return }
-    for _, uid := range ids {
-        if _, err := svc.EvaluatePlateau(context.Background(), uid); err != nil {
-            bgLogger.Warn("eval plateau", "user", uid, "err", err)
-        }
+    ctx := context.Background()
+    // adaptive targets are re-applied once a week
+    if started.Weekday() == time.Monday { applyAdaptiveTargets(ctx, svc) }
+
+    ids, err := getActiveUserIds(db, started.AddDate(0, 0, -workerActiveDays))
+    if err != nil {
+        bgLogger.Error("load users", "err", err)
+        saveRun(db, started, 0, 0, 0, err)
+        return
+    }
+
+    concurrency := lib.Config.BodyWorkerConcurrency
+    if concurrency <= 0 { concurrency = 1 }
+    tg := telegram.NewService()
+    jobs := make(chan string)
+    var ok, failed atomic.Int64
+    var wg sync.WaitGroup
+    for i := 0; i < concurrency; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for uid := range jobs {
+                uctx, cancel := context.WithTimeout(ctx, workerUserTimeout)
+                if _, err := svc.EvaluatePlateau(uctx, uid); err != nil {
+                    bgLogger.Warn("eval plateau", "user", uid, "err", err)
+                    failed.Add(1)
+                } else {
+                    ok.Add(1)
+                }
+                notifyGuardrails(uctx, svc, tg, uid)
+                cancel()
+            }
+        }()
     }
+    for _, uid := range ids { jobs <- uid }
+    close(jobs)
+    wg.Wait()
+
+    saveRun(db, started, len(ids), int(ok.Load()), int(failed.Load()), nil)
+    bgLogger.Info("run finished", "users", len(ids), "ok", ok.Load(), "failed", failed.Load(), "took", time.Since(started))
 }
 
-func getAllUserIds(db *sqlx.DB) ([]string, error) {
-    rows, err := db.Queryx(`SELECT id FROM users WHERE deleted_at IS NULL`)
+// getActiveUserIds returns users with at least one weight logged since the given day.
+func getActiveUserIds(db *sqlx.DB, since time.Time) ([]string, error) {
+    rows, err := db.Queryx(`
+        SELECT DISTINCT w.user_id
+        FROM body_weights w
+        JOIN users u ON u.id = w.user_id
+        WHERE u.deleted_at IS NULL AND w.logged_at >= $1`, since)
     if err != nil { return nil, err }
     defer rows.Close()
     var res []string
@@ -46,3 +102,13 @@ func getAllUserIds(db *sqlx.DB) ([]string, error) {
     return res, rows.Err()
 }
 
+func saveRun(db *sqlx.DB, started time.Time, total, ok, failed int, runErr error) {
+    var errText *string
+    if runErr != nil { s := runErr.Error(); errText = &s }
+    finished := time.Now()
+    _, err := db.Exec(`
+        INSERT INTO body_worker_runs (started_at, finished_at, users_total, users_ok, users_failed, duration_ms, error)
+        VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+        started, finished, total, ok, failed, finished.Sub(started).Milliseconds(), errText)
+    if err != nil { bgLogger.Warn("save run", "err", err) }
+}
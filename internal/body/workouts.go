@@ -0,0 +1,148 @@
+package body
+
+import (
+    "context"
+    "errors"
+    "math"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    IntensityLow      = "low"
+    IntensityModerate = "moderate"
+    IntensityHigh     = "high"
+
+    WorkoutSourceEstimate = "estimate"
+    WorkoutSourceManual   = "manual"
+)
+
+// workoutMET — MET по типу тренировки и интенсивности (Compendium of Physical Activities).
+var workoutMET = map[string]map[string]float64{
+    "walking":     {IntensityLow: 2.8, IntensityModerate: 3.5, IntensityHigh: 5.0},
+    "hiking":      {IntensityLow: 5.3, IntensityModerate: 6.0, IntensityHigh: 7.8},
+    "running":     {IntensityLow: 7.0, IntensityModerate: 9.8, IntensityHigh: 11.8},
+    "cycling":     {IntensityLow: 4.0, IntensityModerate: 6.8, IntensityHigh: 10.0},
+    "swimming":    {IntensityLow: 5.8, IntensityModerate: 8.3, IntensityHigh: 9.8},
+    "rowing":      {IntensityLow: 4.8, IntensityModerate: 7.0, IntensityHigh: 8.5},
+    "elliptical":  {IntensityLow: 4.6, IntensityModerate: 5.0, IntensityHigh: 6.8},
+    "strength":    {IntensityLow: 3.5, IntensityModerate: 5.0, IntensityHigh: 6.0},
+    "hiit":        {IntensityLow: 6.0, IntensityModerate: 8.0, IntensityHigh: 10.0},
+    "yoga":        {IntensityLow: 2.5, IntensityModerate: 3.0, IntensityHigh: 4.0},
+    "team_sports": {IntensityLow: 6.0, IntensityModerate: 7.0, IntensityHigh: 8.0},
+    "other":       {IntensityLow: 3.0, IntensityModerate: 4.5, IntensityHigh: 6.0},
+}
+
+// WorkoutMET returns the MET of a workout. For walking, running and cycling
+// with a distance the pace is used instead of the declared intensity.
+func WorkoutMET(kind, intensity string, durationMin int, distanceKm *float64) (float64, error) {
+    row, ok := workoutMET[kind]
+    if !ok { return 0, errors.New("unknown workout type") }
+    met, ok := row[intensity]
+    if !ok { return 0, errors.New("intensity must be low, moderate or high") }
+    if distanceKm == nil || *distanceKm <= 0 || durationMin <= 0 { return met, nil }
+
+    speed := *distanceKm / (float64(durationMin) / 60) // km/h
+    switch kind {
+    case "walking":
+        switch {
+        case speed < 4: return 2.8, nil
+        case speed < 5: return 3.5, nil
+        case speed < 6: return 4.3, nil
+        default: return 5.0, nil
+        }
+    case "running":
+        // на беговых скоростях MET примерно равен скорости в км/ч
+        return math.Max(6, math.Min(19, speed)), nil
+    case "cycling":
+        switch {
+        case speed < 16: return 4.0, nil
+        case speed < 19: return 6.8, nil
+        case speed < 22: return 8.0, nil
+        case speed < 25: return 10.0, nil
+        default: return 12.0, nil
+        }
+    }
+    return met, nil
+}
+
+// WorkoutCalories — калории сверх покоя: (MET-1) × вес × часы. Покой уже учтён в TDEE,
+// поэтому при добавлении к бюджету он не должен считаться дважды.
+func WorkoutCalories(met, weight float64, durationMin int) float64 {
+    return math.Round(math.Max(0, met-1) * weight * float64(durationMin) / 60)
+}
+
+// prepareWorkout validates input and fills MET, weight and calories.
+// Calories sent by the client (e.g. from a watch) are kept as manual.
+func (s *service) prepareWorkout(w *WorkoutCreate, weights *weightLookup) error {
+    w.Type = strings.ToLower(strings.TrimSpace(w.Type))
+    w.Intensity = strings.ToLower(strings.TrimSpace(w.Intensity))
+    if w.Intensity == "" { w.Intensity = IntensityModerate }
+    if w.DurationMin <= 0 || w.DurationMin > 24*60 { return errors.New("duration must be between 1 and 1440 minutes") }
+    if w.DistanceKm != nil && (*w.DistanceKm < 0 || *w.DistanceKm > 1000) { return errors.New("distance must be between 0 and 1000 km") }
+    if w.Calories < 0 { return errors.New("calories must not be negative") }
+
+    met, err := WorkoutMET(w.Type, w.Intensity, w.DurationMin, w.DistanceKm)
+    if err != nil { return err }
+    weight, err := weights.on(w.LoggedAt)
+    if err != nil { return err }
+
+    w.Met = met
+    w.Weight = weight
+    if w.Calories > 0 {
+        w.Source = WorkoutSourceManual
+    } else {
+        w.Source = WorkoutSourceEstimate
+        w.Calories = WorkoutCalories(met, weight, w.DurationMin)
+    }
+    return nil
+}
+
+// weightLookup answers workout weights from the user's weights loaded once.
+type weightLookup struct {
+    weights []Weight // ascending by LoggedAt
+    profile float64
+}
+
+func (s *service) loadWeights(ctx context.Context, repo Repository, userId string) (*weightLookup, error) {
+    ws, err := repo.GetWeights(ctx, userId, nil, nil)
+    if err != nil { return nil, err }
+    l := &weightLookup{weights: ws}
+    if f, err := s.fitService.GetFitProfileByUser(userId); err == nil && f != nil { l.profile = f.Weight }
+    return l, nil
+}
+
+// on returns the latest weight logged on or before the day; a workout before the first
+// weigh-in takes the earliest weight, the closest in time, and without weights — the fit profile.
+func (l *weightLookup) on(day time.Time) (float64, error) {
+    i := sort.Search(len(l.weights), func(i int) bool { return l.weights[i].LoggedAt.After(day) })
+    if i > 0 { return l.weights[i-1].Value, nil }
+    if len(l.weights) > 0 { return l.weights[0].Value, nil }
+    if l.profile > 0 { return l.profile, nil }
+    return 0, errors.New("log your weight to estimate workout calories")
+}
+
+func (s *service) CreateWorkout(ctx context.Context, w WorkoutCreate) (*Workout, error) {
+    weights, err := s.loadWeights(ctx, s.repo, w.UserId)
+    if err != nil { return nil, err }
+    if err := s.prepareWorkout(&w, weights); err != nil { return nil, err }
+    return s.repo.CreateWorkout(ctx, w)
+}
+
+func (s *service) UpdateWorkout(ctx context.Context, w Workout) (*Workout, error) {
+    in := WorkoutCreate{UserId: w.UserId, Type: w.Type, DurationMin: w.DurationMin, Intensity: w.Intensity, DistanceKm: w.DistanceKm, Calories: w.Calories, LoggedAt: w.LoggedAt}
+    weights, err := s.loadWeights(ctx, s.repo, w.UserId)
+    if err != nil { return nil, err }
+    if err := s.prepareWorkout(&in, weights); err != nil { return nil, err }
+    w.Type, w.Intensity, w.Met, w.Weight, w.Calories, w.Source = in.Type, in.Intensity, in.Met, in.Weight, in.Calories, in.Source
+    return s.repo.UpdateWorkout(ctx, w)
+}
+
+func (s *service) DeleteWorkout(ctx context.Context, id int64, userId string) error {
+    return s.repo.DeleteWorkout(ctx, id, userId)
+}
+
+func (s *service) GetWorkouts(ctx context.Context, userId string, from, to *time.Time) ([]Workout, error) {
+    return s.repo.GetWorkouts(ctx, userId, from, to)
+}
@@ -0,0 +1,73 @@
+package body
+
+import "testing"
+
+func TestWorkoutMET(t *testing.T) {
+    tests := []struct {
+        name        string
+        kind        string
+        intensity   string
+        durationMin int
+        distanceKm  *float64
+        want        float64
+        wantErr     bool
+    }{
+        {"by intensity", "running", IntensityModerate, 30, nil, 9.8, false},
+        {"running pace", "running", IntensityLow, 60, fptr(10), 10, false},
+        {"slow running is clamped", "running", IntensityHigh, 60, fptr(3), 6, false},
+        {"walking pace", "walking", IntensityLow, 60, fptr(5.5), 4.3, false},
+        {"cycling pace", "cycling", IntensityLow, 60, fptr(20), 8, false},
+        {"distance ignored for yoga", "yoga", IntensityModerate, 60, fptr(5), 3, false},
+        {"unknown type", "chess", IntensityLow, 60, nil, 0, true},
+        {"unknown intensity", "running", "extreme", 60, nil, 0, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := WorkoutMET(tt.kind, tt.intensity, tt.durationMin, tt.distanceKm)
+            if (err != nil) != tt.wantErr { t.Fatalf("WorkoutMET() error = %v, wantErr %v", err, tt.wantErr) }
+            if got != tt.want { t.Errorf("WorkoutMET() = %v, want %v", got, tt.want) }
+        })
+    }
+}
+
+func TestWorkoutCalories(t *testing.T) {
+    tests := []struct {
+        name        string
+        met, weight float64
+        durationMin int
+        want        float64
+    }{
+        {"net of rest", 9.8, 70, 30, 308},
+        {"hour of walking", 3.5, 80, 60, 200},
+        {"below rest", 0.5, 80, 60, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := WorkoutCalories(tt.met, tt.weight, tt.durationMin); got != tt.want { t.Errorf("WorkoutCalories() = %v, want %v", got, tt.want) }
+        })
+    }
+}
+
+func TestWeightLookupOn(t *testing.T) {
+    weights := []Weight{{Value: 82, LoggedAt: day("2025-09-01")}, {Value: 80, LoggedAt: day("2025-09-10")}}
+    tests := []struct {
+        name    string
+        l       weightLookup
+        day     string
+        want    float64
+        wantErr bool
+    }{
+        {"on a weigh-in day", weightLookup{weights: weights}, "2025-09-10", 80, false},
+        {"between weigh-ins", weightLookup{weights: weights}, "2025-09-05", 82, false},
+        {"before the first weigh-in takes the earliest", weightLookup{weights: weights, profile: 90}, "2025-08-20", 82, false},
+        {"no weights takes the profile", weightLookup{profile: 90}, "2025-09-05", 90, false},
+        {"nothing known", weightLookup{}, "2025-09-05", 0, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tt.l.on(day(tt.day))
+            if (err != nil) != tt.wantErr { t.Fatalf("on() error = %v, wantErr %v", err, tt.wantErr) }
+            if got != tt.want { t.Errorf("on() = %v, want %v", got, tt.want) }
+        })
+    }
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Дневные границы считаются в часовом поясе пользователя (users.timezone) — это
+// единственный источник пояса для дневника, целей и аналитики. Метки created_at
+// хранятся в UTC.
+
+// UserTimezone возвращает SQL-выражение с часовым поясом пользователя,
+// id которого передан параметром userParam (например, "$1").
+func UserTimezone(userParam string) string {
+	return "(SELECT timezone FROM users WHERE id = " + userParam + ")"
+}
+
+// LocalDate возвращает SQL-выражение календарного дня метки column в часовом поясе tz.
+// tz — SQL-выражение: параметр запроса с именем пояса или UserTimezone.
+// Для группировки; в WHERE используйте LocalDays, чтобы работал индекс по column.
+func LocalDate(column, tz string) string {
+	return "(" + column + " AT TIME ZONE 'UTC' AT TIME ZONE " + tz + ")::date"
+}
+
+// LocalToday возвращает SQL-выражение сегодняшнего дня в часовом поясе tz.
+func LocalToday(tz string) string {
+	return "(now() AT TIME ZONE " + tz + ")::date"
+}
+
+// localDayStart возвращает SQL-выражение начала дня day (SQL-выражение типа date)
+// в поясе tz как метку UTC, сравнимую с created_at.
+func localDayStart(day, tz string) string {
+	return "((" + day + ")::timestamp AT TIME ZONE " + tz + " AT TIME ZONE 'UTC')"
+}
+
+// LocalDays возвращает условие «метка column попадает в дни с from по to включительно
+// в поясе tz». Условие — диапазон по самой метке, поэтому индекс (user_id, column) используется.
+func LocalDays(column, from, to, tz string) string {
+	return column + " >= " + localDayStart(from, tz) + " AND " + column + " < " + localDayStart("("+to+")::date + 1", tz)
+}
+
+// UserLocation загружает часовой пояс пользователя; UTC, если пользователь не найден.
+func UserLocation(ctx context.Context, userId string) (*time.Location, error) {
+	var name string
+	err := Database.GetContext(ctx, &name, `SELECT timezone FROM users WHERE id = $1`, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.UTC, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(name)
+}
+
+// LocalDay возвращает календарный день момента t в поясе loc как полночь UTC —
+// в таком виде дни хранятся в DATE-колонках и ключах карт по дням.
+func LocalDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// UserToday возвращает сегодняшний день в часовом поясе пользователя.
+func UserToday(ctx context.Context, userId string) (time.Time, error) {
+	loc, err := UserLocation(ctx, userId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return LocalDay(time.Now(), loc), nil
+}
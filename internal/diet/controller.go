@@ -0,0 +1,148 @@
+package diet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jourloy/nutri-backend/internal/auth"
+)
+
+var (
+	logger = log.NewWithOptions(os.Stderr, log.Options{
+		Prefix: "[diet]",
+		Level:  log.DebugLevel,
+	})
+)
+
+type Controller struct {
+	service Service
+}
+
+func NewController() *Controller {
+	return &Controller{service: NewService()}
+}
+
+func (c *Controller) RegisterRoutes(router chi.Router) {
+	router.Route("/diet", func(r chi.Router) {
+		r.Get("/presets", c.GetAll)
+		r.Post("/preset", c.Create)
+		r.Put("/preset", c.Update)
+		r.Delete("/preset/{id}", c.Delete)
+	})
+
+	logger.Info("╔═════ Diet")
+	logger.Info("║    GET /presets")
+	logger.Info("║   POST /preset")
+	logger.Info("║    PUT /preset")
+	logger.Info("║ DELETE /preset/{id}")
+	logger.Info("╚═════")
+}
+
+func (c *Controller) GetAll(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// выключенные пресеты видят только админы
+	onlyEnabled := !(u.IsAdmin && r.URL.Query().Get("all") == "true")
+
+	resp, err := c.service.GetAll(context.Background(), onlyEnabled)
+	if err != nil {
+		logger.Error("Error get presets", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) Create(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var p Preset
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.Create(context.Background(), p)
+	if err != nil {
+		logger.Error("Error creating preset", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) Update(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var p Preset
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.Update(context.Background(), p)
+	if err != nil {
+		logger.Error("Error updating preset", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.Delete(context.Background(), id); err != nil {
+		logger.Error("Error deleting preset", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,34 @@
+package diet
+
+import "time"
+
+const (
+	ModePercent = "percent" // доли калорий
+	ModePerKg   = "per_kg"  // граммы на кг веса, углеводы — остаток
+)
+
+// Limits — optional nutrient limits of a preset. Only nutrients tracked by products
+// can be limited.
+type Limits struct {
+	MaxCarbs *float64 `json:"maxCarbs,omitempty"` // г в день
+}
+
+// Preset describes how calories are distributed between macros.
+type Preset struct {
+	Id           int64     `json:"id" db:"id"`
+	Key          string    `json:"key" db:"key"`
+	Name         string    `json:"name" db:"name"`
+	Description  string    `json:"description" db:"description"`
+	Mode         string    `json:"mode" db:"mode"`
+	ProteinPct   *float64  `json:"proteinPct,omitempty" db:"protein_pct"`
+	FatPct       *float64  `json:"fatPct,omitempty" db:"fat_pct"`
+	CarbsPct     *float64  `json:"carbsPct,omitempty" db:"carbs_pct"`
+	ProteinPerKg *float64  `json:"proteinPerKg,omitempty" db:"protein_per_kg"`
+	FatPerKg     *float64  `json:"fatPerKg,omitempty" db:"fat_per_kg"`
+	Limits       Limits    `json:"limits" db:"-"`
+	LimitsRaw    []byte    `json:"-" db:"limits"`
+	Enabled      bool      `json:"enabled" db:"enabled"`
+	Position     int64     `json:"position" db:"position"`
+	CreatedAt    time.Time `json:"-" db:"created_at"`
+	UpdatedAt    time.Time `json:"-" db:"updated_at"`
+}
@@ -0,0 +1,139 @@
+package diet
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jourloy/nutri-backend/internal/database"
+)
+
+type Repository interface {
+	Create(ctx context.Context, p Preset) (*Preset, error)
+	Update(ctx context.Context, p Preset) (*Preset, error)
+	Delete(ctx context.Context, id int64) error
+	GetById(ctx context.Context, id int64) (*Preset, error)
+	GetAll(ctx context.Context, onlyEnabled bool) ([]Preset, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository() Repository {
+	return &repository{db: database.Database}
+}
+
+const presetColumns = `
+	id, key, name, description, mode,
+	protein_pct, fat_pct, carbs_pct, protein_per_kg, fat_per_kg,
+	limits, enabled, position, created_at, updated_at
+`
+
+func (r *repository) Create(ctx context.Context, p Preset) (*Preset, error) {
+	p.LimitsRaw, _ = json.Marshal(p.Limits)
+	const q = `
+	INSERT INTO diet_presets (
+		key, name, description, mode,
+		protein_pct, fat_pct, carbs_pct, protein_per_kg, fat_per_kg,
+		limits, enabled, position
+	) VALUES (
+		:key, :name, :description, :mode,
+		:protein_pct, :fat_pct, :carbs_pct, :protein_per_kg, :fat_per_kg,
+		:limits, :enabled, :position
+	)
+	RETURNING ` + presetColumns + `;`
+
+	return r.namedOne(ctx, q, p)
+}
+
+func (r *repository) Update(ctx context.Context, p Preset) (*Preset, error) {
+	p.LimitsRaw, _ = json.Marshal(p.Limits)
+	const q = `
+	UPDATE diet_presets
+	SET
+		key = :key,
+		name = :name,
+		description = :description,
+		mode = :mode,
+		protein_pct = :protein_pct,
+		fat_pct = :fat_pct,
+		carbs_pct = :carbs_pct,
+		protein_per_kg = :protein_per_kg,
+		fat_per_kg = :fat_per_kg,
+		limits = :limits,
+		enabled = :enabled,
+		position = :position,
+		updated_at = now()
+	WHERE id = :id
+	RETURNING ` + presetColumns + `;`
+
+	return r.namedOne(ctx, q, p)
+}
+
+func (r *repository) namedOne(ctx context.Context, q string, p Preset) (*Preset, error) {
+	rows, err := r.db.NamedQueryContext(ctx, q, p)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var res Preset
+		if err := rows.StructScan(&res); err != nil {
+			return nil, err
+		}
+		decodeLimits(&res)
+		return &res, nil
+	}
+	return nil, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	const q = `DELETE FROM diet_presets WHERE id = $1 RETURNING id;`
+
+	var deletedId int64
+	if err := r.db.GetContext(ctx, &deletedId, q, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) GetById(ctx context.Context, id int64) (*Preset, error) {
+	const q = `SELECT ` + presetColumns + ` FROM diet_presets WHERE id = $1;`
+
+	var p Preset
+	if err := r.db.GetContext(ctx, &p, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	decodeLimits(&p)
+	return &p, nil
+}
+
+func (r *repository) GetAll(ctx context.Context, onlyEnabled bool) ([]Preset, error) {
+	q := `SELECT ` + presetColumns + ` FROM diet_presets`
+	if onlyEnabled {
+		q += ` WHERE enabled = TRUE`
+	}
+	q += ` ORDER BY position, id;`
+
+	var res []Preset
+	if err := r.db.SelectContext(ctx, &res, q); err != nil {
+		return nil, err
+	}
+	for i := range res {
+		decodeLimits(&res[i])
+	}
+	return res, nil
+}
+
+func decodeLimits(p *Preset) {
+	if len(p.LimitsRaw) > 0 {
+		_ = json.Unmarshal(p.LimitsRaw, &p.Limits)
+	}
+}
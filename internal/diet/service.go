@@ -0,0 +1,45 @@
+package diet
+
+import "context"
+
+type Service interface {
+	Create(ctx context.Context, p Preset) (*Preset, error)
+	Update(ctx context.Context, p Preset) (*Preset, error)
+	Delete(ctx context.Context, id int64) error
+	GetById(ctx context.Context, id int64) (*Preset, error)
+	GetAll(ctx context.Context, onlyEnabled bool) ([]Preset, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService() Service {
+	return &service{repo: NewRepository()}
+}
+
+func (s *service) Create(ctx context.Context, p Preset) (*Preset, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, p)
+}
+
+func (s *service) Update(ctx context.Context, p Preset) (*Preset, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, p)
+}
+
+func (s *service) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) GetById(ctx context.Context, id int64) (*Preset, error) {
+	return s.repo.GetById(ctx, id)
+}
+
+func (s *service) GetAll(ctx context.Context, onlyEnabled bool) ([]Preset, error) {
+	return s.repo.GetAll(ctx, onlyEnabled)
+}
@@ -0,0 +1,66 @@
+package diet
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	kcalPerGramProtein = 4.0
+	kcalPerGramFat     = 9.0
+	kcalPerGramCarbs   = 4.0
+)
+
+// Validate проверяет правила пресета.
+func (p Preset) Validate() error {
+	if p.Key == "" || p.Name == "" {
+		return errors.New("key and name are required")
+	}
+	switch p.Mode {
+	case ModePercent:
+		if p.ProteinPct == nil || p.FatPct == nil || p.CarbsPct == nil {
+			return errors.New("percent preset requires proteinPct, fatPct and carbsPct")
+		}
+		if sum := *p.ProteinPct + *p.FatPct + *p.CarbsPct; math.Abs(sum-100) > 0.5 {
+			return errors.New("macro percentages must add up to 100")
+		}
+	case ModePerKg:
+		if p.ProteinPerKg == nil || p.FatPerKg == nil {
+			return errors.New("per_kg preset requires proteinPerKg and fatPerKg")
+		}
+		if *p.ProteinPerKg <= 0 || *p.FatPerKg <= 0 {
+			return errors.New("grams per kg must be positive")
+		}
+	default:
+		return errors.New("mode must be percent or per_kg")
+	}
+	return nil
+}
+
+// Split распределяет калории по БЖУ по правилам пресета.
+// Лимит углеводов срезает углеводы, освободившиеся калории уходят в жиры.
+func (p Preset) Split(calories, weight float64) (protein, fat, carbs float64) {
+	switch p.Mode {
+	case ModePerKg:
+		protein = deref(p.ProteinPerKg) * weight
+		fat = deref(p.FatPerKg) * weight
+		carbs = math.Max(0, (calories-protein*kcalPerGramProtein-fat*kcalPerGramFat)/kcalPerGramCarbs)
+	default:
+		protein = calories * deref(p.ProteinPct) / 100 / kcalPerGramProtein
+		fat = calories * deref(p.FatPct) / 100 / kcalPerGramFat
+		carbs = calories * deref(p.CarbsPct) / 100 / kcalPerGramCarbs
+	}
+
+	if p.Limits.MaxCarbs != nil && carbs > *p.Limits.MaxCarbs {
+		fat += (carbs - *p.Limits.MaxCarbs) * kcalPerGramCarbs / kcalPerGramFat
+		carbs = *p.Limits.MaxCarbs
+	}
+	return math.Round(protein), math.Round(fat), math.Round(carbs)
+}
+
+func deref(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
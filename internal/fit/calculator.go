@@ -0,0 +1,132 @@
+package fit
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+const (
+	FormulaMifflin = "mifflin_st_jeor"
+	FormulaKatch   = "katch_mcardle"
+
+	kcalPerGramProtein = 4.0
+	kcalPerGramFat     = 9.0
+	kcalPerGramCarbs   = 4.0
+)
+
+// Calculation — результат расчёта BMR, TDEE и целей по КБЖУ.
+type Calculation struct {
+	Formula        string    `json:"formula"`
+	BMR            float64   `json:"bmr"`
+	TDEE           float64   `json:"tdee"`
+	ActivityLevel  float64   `json:"activityLevel"`
+	GoalAdjustment float64   `json:"goalAdjustment"` // доля от TDEE: -0.2 — дефицит 20%
+	Calories       float64   `json:"calories"`
+	Protein        float64   `json:"protein"`
+	Fat            float64   `json:"fat"`
+	Carbs          float64   `json:"carbs"`
+	Warnings       []Warning `json:"warnings,omitempty"`
+}
+
+// IsMale нормализует значение пола из профиля.
+func IsMale(gender string) bool {
+	g := strings.ToLower(strings.TrimSpace(gender))
+	return g == "male" || g == "m" || g == "man" || strings.HasPrefix(g, "муж")
+}
+
+// GoalAdjustment возвращает поправку к TDEE для цели профиля.
+func GoalAdjustment(goal string) float64 {
+	switch goal {
+	case "lose", "fat_loss", "weight_loss":
+		return -0.2
+	case "gain", "muscle_gain", "bulk":
+		return 0.1
+	default:
+		return 0
+	}
+}
+
+// proteinPerKg — белок на кг веса в зависимости от цели.
+func proteinPerKg(goal string) float64 {
+	switch goal {
+	case "lose", "fat_loss", "weight_loss":
+		return 2.0
+	case "gain", "muscle_gain", "bulk":
+		return 1.8
+	default:
+		return 1.6
+	}
+}
+
+// BMR считает базовый обмен: Кэтч-МакАрдл при известном проценте жира,
+// иначе Миффлин-Сан Жеор.
+func BMR(age int64, gender string, height, weight float64, bodyFat *float64) (float64, string) {
+	if bodyFat != nil && *bodyFat > 0 && *bodyFat < 70 {
+		lbm := weight * (1 - *bodyFat/100)
+		return 370 + 21.6*lbm, FormulaKatch
+	}
+	bmr := 10*weight + 6.25*height - 5*float64(age)
+	if IsMale(gender) {
+		bmr += 5
+	} else {
+		bmr -= 161
+	}
+	return bmr, FormulaMifflin
+}
+
+// SplitMacros распределяет калории на БЖУ: белок по г/кг, жиры 25% калорий
+// (но не меньше 0.6 г/кг), углеводы — остаток.
+func SplitMacros(calories, weight float64, goal string) (protein, fat, carbs float64) {
+	protein = proteinPerKg(goal) * weight
+	fat = math.Max(0.25*calories/kcalPerGramFat, 0.6*weight)
+	carbs = math.Max(0, (calories-protein*kcalPerGramProtein-fat*kcalPerGramFat)/kcalPerGramCarbs)
+	return math.Round(protein), math.Round(fat), math.Round(carbs)
+}
+
+// Calculate рассчитывает цели по данным профиля.
+func Calculate(fc FitProfileCreate) (*Calculation, error) {
+	if fc.Age <= 0 || fc.Height <= 0 || fc.Weight <= 0 {
+		return nil, errors.New("age, height and weight are required")
+	}
+
+	activity := fc.ActivityLevel
+	if activity <= 0 {
+		activity = 1.2
+	}
+
+	bmr, formula := BMR(fc.Age, fc.Gender, float64(fc.Height), fc.Weight, fc.BodyFat)
+	tdee := bmr * activity
+	adj := GoalAdjustment(fc.Goal)
+	calories := math.Round(tdee * (1 + adj))
+	protein, fat, carbs := SplitMacros(calories, fc.Weight, fc.Goal)
+
+	return &Calculation{
+		Formula:        formula,
+		BMR:            math.Round(bmr),
+		TDEE:           math.Round(tdee),
+		ActivityLevel:  activity,
+		GoalAdjustment: adj,
+		Calories:       calories,
+		Protein:        protein,
+		Fat:            fat,
+		Carbs:          carbs,
+	}, nil
+}
+
+// applyCalculation заполняет цели профиля, если включён автоматический расчёт.
+// Без флага цели остаются такими, какими их прислал клиент.
+func applyCalculation(fc *FitProfileCreate) error {
+	if !fc.AutoTargets {
+		return nil
+	}
+	calc, err := Calculate(*fc)
+	if err != nil {
+		return err
+	}
+	fc.Calories = calc.Calories
+	fc.Protein = calc.Protein
+	fc.Fat = calc.Fat
+	fc.Carbs = calc.Carbs
+	return nil
+}
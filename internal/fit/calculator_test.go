@@ -0,0 +1,77 @@
+package fit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      FitProfileCreate
+		want    Calculation
+		wantErr bool
+	}{
+		{
+			name: "male loss, mifflin",
+			in:   FitProfileCreate{Age: 30, Gender: "male", Height: 180, Weight: 80, ActivityLevel: 1.55, Goal: "lose"},
+			want: Calculation{Formula: FormulaMifflin, BMR: 1780, TDEE: 2759, ActivityLevel: 1.55, GoalAdjustment: -0.2, Calories: 2207, Protein: 160, Fat: 61, Carbs: 254},
+		},
+		{
+			name: "female maintain, default activity",
+			in:   FitProfileCreate{Age: 25, Gender: "female", Height: 165, Weight: 60, Goal: "maintain"},
+			want: Calculation{Formula: FormulaMifflin, BMR: 1345, TDEE: 1614, ActivityLevel: 1.2, GoalAdjustment: 0, Calories: 1614, Protein: 96, Fat: 45, Carbs: 207},
+		},
+		{
+			name: "gain with body fat, katch-mcardle",
+			in:   FitProfileCreate{Age: 30, Gender: "male", Height: 180, Weight: 80, ActivityLevel: 1.2, Goal: "gain", BodyFat: ptr(20)},
+			want: Calculation{Formula: FormulaKatch, BMR: 1752, TDEE: 2103, ActivityLevel: 1.2, GoalAdjustment: 0.1, Calories: 2313, Protein: 144, Fat: 64, Carbs: 290},
+		},
+		{
+			name:    "missing age",
+			in:      FitProfileCreate{Gender: "male", Height: 180, Weight: 80},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Calculate(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Calculate() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			got.Warnings = nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMacros(t *testing.T) {
+	tests := []struct {
+		name                string
+		calories, weight    float64
+		goal                string
+		protein, fat, carbs float64
+	}{
+		{"loss", 2000, 80, "lose", 160, 56, 215},
+		{"fat floor per kg", 1200, 100, "maintain", 160, 60, 5},
+		{"carbs never negative", 1000, 100, "lose", 200, 60, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, f, c := SplitMacros(tt.calories, tt.weight, tt.goal)
+			if p != tt.protein || f != tt.fat || c != tt.carbs {
+				t.Errorf("SplitMacros() = %v/%v/%v, want %v/%v/%v", p, f, c, tt.protein, tt.fat, tt.carbs)
+			}
+		})
+	}
+}
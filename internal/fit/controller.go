@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/go-chi/chi/v5"
 
 	"github.com/jourloy/nutri-backend/internal/auth"
+	"github.com/jourloy/nutri-backend/internal/database"
 )
 
 var (
@@ -34,12 +36,32 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
 		r.Post("/", c.Create)
 		r.Put("/", c.Update)
 		r.Get("/", c.Get)
+		r.Post("/calculate", c.Calculate)
+		r.Get("/history", c.GetHistory)
+		r.Get("/targets", c.GetTargets)
+		r.Get("/weekdays", c.GetWeekdays)
+		r.Put("/weekdays", c.ReplaceWeekdays)
+		r.Get("/training", c.GetTrainingDays)
+		r.Put("/training", c.SetTrainingDay)
+		r.Delete("/training/{date}", c.DeleteTrainingDay)
+		r.Post("/preset/apply", c.ApplyPreset)
+		r.Delete("/preset", c.ClearPreset)
 	})
 
 	logger.Info("╔═════ Fit")
 	logger.Info("║   POST /")
 	logger.Info("║    PUT /")
 	logger.Info("║    GET /")
+	logger.Info("║   POST /calculate")
+	logger.Info("║    GET /history")
+	logger.Info("║    GET /targets?from=&to=")
+	logger.Info("║    GET /weekdays")
+	logger.Info("║    PUT /weekdays")
+	logger.Info("║    GET /training?from=&to=")
+	logger.Info("║    PUT /training")
+	logger.Info("║ DELETE /training/{date}")
+	logger.Info("║   POST /preset/apply")
+	logger.Info("║ DELETE /preset")
 	logger.Info("╚═════")
 }
 
@@ -112,3 +134,269 @@ func (c *Controller) Get(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// Calculate returns the calculated targets for the given profile data
+// without saving anything.
+func (c *Controller) Calculate(w http.ResponseWriter, r *http.Request) {
+	_, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var fc FitProfileCreate
+	if err := json.NewDecoder(r.Body).Decode(&fc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.Calculate(fc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ApplyPreset remembers a diet preset and recomputes macros from the profile's calories.
+func (c *Controller) ApplyPreset(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		PresetId int64 `json:"presetId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.ApplyPreset(context.Background(), u.Id, body.PresetId)
+	if err != nil {
+		logger.Error("Error applying preset", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) ClearPreset(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := c.service.ClearPreset(context.Background(), u.Id)
+	if err != nil {
+		logger.Error("Error clearing preset", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GetHistory returns all versions of the user's targets, oldest first.
+func (c *Controller) GetHistory(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := c.service.GetTargetsHistory(r.Context(), u.Id)
+	if err != nil {
+		logger.Error("Error get targets history", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// parseRange reads ?from=&to= (YYYY-MM-DD); by default — the ISO week of today.
+func parseRange(r *http.Request, today time.Time) (time.Time, time.Time) {
+	from := weekStart(today)
+	to := from.AddDate(0, 0, 6)
+	if s := r.URL.Query().Get("from"); s != "" {
+		if t, err := time.Parse(dayLayout, s); err == nil {
+			from = t
+		}
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		if t, err := time.Parse(dayLayout, s); err == nil {
+			to = t
+		}
+	}
+	return from, to
+}
+
+// GetTargets returns resolved targets for every day of the range.
+func (c *Controller) GetTargets(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	today, err := database.UserToday(r.Context(), u.Id)
+	if err != nil {
+		logger.Error("Error get user timezone", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	from, to := parseRange(r, today)
+	if to.Sub(from) > 366*24*time.Hour {
+		http.Error(w, "range is too long", http.StatusBadRequest)
+		return
+	}
+
+	resolved, err := c.service.ResolveTargets(r.Context(), u.Id, from, to)
+	if err != nil {
+		logger.Error("Error resolve targets", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := make([]Targets, 0, len(resolved))
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if t, ok := resolved[day.Format(dayLayout)]; ok {
+			resp = append(resp, t)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) GetWeekdays(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := c.service.GetWeekdayTargets(r.Context(), u.Id)
+	if err != nil {
+		logger.Error("Error get weekday targets", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ReplaceWeekdays replaces all weekday overrides with the given list.
+func (c *Controller) ReplaceWeekdays(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var list []WeekdayTarget
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.ReplaceWeekdayTargets(r.Context(), u.Id, list)
+	if err != nil {
+		logger.Error("Error replace weekday targets", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) GetTrainingDays(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	today, err := database.UserToday(r.Context(), u.Id)
+	if err != nil {
+		logger.Error("Error get user timezone", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	from, to := parseRange(r, today)
+	resp, err := c.service.GetTrainingDays(r.Context(), u.Id, from, to)
+	if err != nil {
+		logger.Error("Error get training days", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) SetTrainingDay(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Date       string `json:"date"`
+		IsTraining bool   `json:"isTraining"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	day, err := time.Parse(dayLayout, body.Date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.SetTrainingDay(r.Context(), TrainingDay{UserId: u.Id, Day: day, IsTraining: body.IsTraining})
+	if err != nil {
+		logger.Error("Error set training day", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) DeleteTrainingDay(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	day, err := time.Parse(dayLayout, chi.URLParam(r, "date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.DeleteTrainingDay(r.Context(), u.Id, day); err != nil {
+		logger.Error("Error delete training day", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
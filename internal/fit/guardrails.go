@@ -0,0 +1,106 @@
+package fit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	MinCaloriesFemale = 1200.0
+	MinCaloriesMale   = 1500.0
+	// MinProteinPerKg — нижняя граница белка, г на кг веса
+	MinProteinPerKg = 0.8
+	// MaxLossPctPerWeek — безопасный темп снижения веса, % от веса в неделю
+	MaxLossPctPerWeek = 1.0
+)
+
+const (
+	WarningCaloriesFloor = "calories_floor"
+	WarningProteinFloor  = "protein_floor"
+	WarningUnsafeGoal    = "unsafe_goal_rate"
+	WarningFastLoss      = "fast_loss"
+	WarningLowIntake     = "low_intake"
+)
+
+// Warning — предупреждение защитных ограничений.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// MinCalories возвращает нижнюю границу калорий для пола.
+func MinCalories(gender string) float64 {
+	if IsMale(gender) {
+		return MinCaloriesMale
+	}
+	return MinCaloriesFemale
+}
+
+// checkCaloriesFloor отклоняет переопределение калорий ниже безопасного минимума.
+func checkCaloriesFloor(calories *float64, gender, what string) error {
+	if floor := MinCalories(gender); calories != nil && *calories > 0 && *calories < floor {
+		return fmt.Errorf("%s calories must not be below %.0f kcal", what, floor)
+	}
+	return nil
+}
+
+// ClampMacros поднимает калории и белок до безопасного минимума.
+// Добавленные калории уходят в углеводы.
+func ClampMacros(m *Macros, gender string, weight float64) []Warning {
+	var warnings []Warning
+
+	if floor := MinCalories(gender); m.Calories > 0 && m.Calories < floor {
+		warnings = append(warnings, Warning{
+			Code:    WarningCaloriesFloor,
+			Message: fmt.Sprintf("Калорийность %.0f ниже безопасного минимума, цель поднята до %.0f ккал", m.Calories, floor),
+		})
+		m.Carbs += (floor - m.Calories) / kcalPerGramCarbs
+		m.Calories = floor
+	}
+
+	if floor := math.Round(MinProteinPerKg * weight); weight > 0 && m.Protein < floor {
+		warnings = append(warnings, Warning{
+			Code:    WarningProteinFloor,
+			Message: fmt.Sprintf("Белок %.0f г ниже минимума %.1f г/кг, цель поднята до %.0f г", m.Protein, MinProteinPerKg, floor),
+		})
+		m.Protein = floor
+	}
+
+	m.Carbs = math.Round(m.Carbs)
+	return warnings
+}
+
+// GoalRateWarnings проверяет, не требует ли цель по весу слишком быстрого снижения.
+func GoalRateWarnings(weight float64, targetWeight *float64, targetDate *time.Time) []Warning {
+	if weight <= 0 || targetWeight == nil || targetDate == nil || *targetWeight >= weight {
+		return nil
+	}
+	days := targetDate.Sub(time.Now().Truncate(24*time.Hour)).Hours() / 24
+	if days <= 0 {
+		return nil
+	}
+	weekly := (weight - *targetWeight) / days * 7
+	pct := weekly / weight * 100
+	if pct <= MaxLossPctPerWeek {
+		return nil
+	}
+	return []Warning{{
+		Code:    WarningUnsafeGoal,
+		Message: fmt.Sprintf("Цель требует снижения %.2f кг (%.1f%%) в неделю — больше безопасных %.0f%%", weekly, pct, MaxLossPctPerWeek),
+	}}
+}
+
+// applyGuardrails ограничивает цели профиля и возвращает предупреждения.
+func applyGuardrails(fc *FitProfileCreate) []Warning {
+	m := Macros{Calories: fc.Calories, Protein: fc.Protein, Fat: fc.Fat, Carbs: fc.Carbs}
+	warnings := ClampMacros(&m, fc.Gender, fc.Weight)
+	fc.Calories, fc.Protein, fc.Fat, fc.Carbs = m.Calories, m.Protein, m.Fat, m.Carbs
+
+	if fc.TargetDate != nil {
+		if d, err := time.Parse("2006-01-02", *fc.TargetDate); err == nil {
+			warnings = append(warnings, GoalRateWarnings(fc.Weight, fc.TargetWeight, &d)...)
+		}
+	}
+	return warnings
+}
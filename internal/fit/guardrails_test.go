@@ -0,0 +1,79 @@
+package fit
+
+import "testing"
+
+func TestClampMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       Macros
+		gender   string
+		weight   float64
+		want     Macros
+		warnings []string
+	}{
+		{
+			name:   "within limits",
+			in:     Macros{Calories: 2000, Protein: 120, Fat: 60, Carbs: 230},
+			gender: "female", weight: 70,
+			want: Macros{Calories: 2000, Protein: 120, Fat: 60, Carbs: 230},
+		},
+		{
+			name:   "female calories floor goes to carbs",
+			in:     Macros{Calories: 1000, Protein: 100, Fat: 40, Carbs: 60},
+			gender: "female", weight: 60,
+			want:     Macros{Calories: 1200, Protein: 100, Fat: 40, Carbs: 110},
+			warnings: []string{WarningCaloriesFloor},
+		},
+		{
+			name:   "male floor and protein floor",
+			in:     Macros{Calories: 1400, Protein: 50, Fat: 50, Carbs: 180},
+			gender: "male", weight: 90,
+			want:     Macros{Calories: 1500, Protein: 72, Fat: 50, Carbs: 205},
+			warnings: []string{WarningCaloriesFloor, WarningProteinFloor},
+		},
+		{
+			name:   "zero calories and unknown weight are left alone",
+			in:     Macros{Protein: 10},
+			gender: "male", weight: 0,
+			want: Macros{Protein: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.in
+			warnings := ClampMacros(&m, tt.gender, tt.weight)
+			if m != tt.want {
+				t.Errorf("ClampMacros() macros = %+v, want %+v", m, tt.want)
+			}
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("ClampMacros() warnings = %+v, want codes %v", warnings, tt.warnings)
+			}
+			for i, w := range warnings {
+				if w.Code != tt.warnings[i] {
+					t.Errorf("warning %d = %s, want %s", i, w.Code, tt.warnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCaloriesFloor(t *testing.T) {
+	tests := []struct {
+		name     string
+		calories *float64
+		gender   string
+		wantErr  bool
+	}{
+		{"not set", nil, "female", false},
+		{"above floor", ptr(1300), "female", false},
+		{"below female floor", ptr(1100), "female", true},
+		{"below male floor", ptr(1400), "male", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCaloriesFloor(tt.calories, tt.gender, "weekday"); (err != nil) != tt.wantErr {
+				t.Errorf("checkCaloriesFloor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,35 +3,135 @@ package fit
 import "time"
 
 type FitProfile struct {
-	Id            string     `json:"id" db:"id"`
-	Age           int64      `json:"age" db:"age"`
-	Gender        string     `json:"gender" db:"gender"`
-	Height        int64      `json:"height" db:"height"`
-	Weight        int64      `json:"weight" db:"weight"`
-	ActivityLevel float64    `json:"activityLevel" db:"activity_level"`
-	Goal          string     `json:"goal" db:"goal"`
-	Calories      float64    `json:"calories" db:"calories"`
-	Protein       float64    `json:"protein" db:"protein"`
-	Fat           float64    `json:"fat" db:"fat"`
-	Carbs         float64    `json:"carbs" db:"carbs"`
-	WaterLimit    *int64     `json:"waterLimit" db:"water_limit"`
-	UserId        string     `json:"-" db:"user_id"`
-	CreatedAt     time.Time  `json:"-" db:"created_at"`
-	UpdatedAt     time.Time  `json:"-" db:"updated_at"`
-	DeletedAt     *time.Time `json:"-" db:"deleted_at"`
+	Id               string     `json:"id" db:"id"`
+	Age              int64      `json:"age" db:"age"`
+	Gender           string     `json:"gender" db:"gender"`
+	Height           int64      `json:"height" db:"height"`
+	Weight           float64    `json:"weight" db:"weight"`
+	ActivityLevel    float64    `json:"activityLevel" db:"activity_level"`
+	Goal             string     `json:"goal" db:"goal"`
+	Calories         float64    `json:"calories" db:"calories"`
+	Protein          float64    `json:"protein" db:"protein"`
+	Fat              float64    `json:"fat" db:"fat"`
+	Carbs            float64    `json:"carbs" db:"carbs"`
+	WaterLimit       *int64     `json:"waterLimit" db:"water_limit"`
+	BodyFat          *float64   `json:"bodyFat" db:"body_fat"`
+	AutoTargets      bool       `json:"autoTargets" db:"auto_targets"`
+	TrainingCalories *float64   `json:"trainingCalories" db:"training_calories"`
+	TrainingProtein  *float64   `json:"trainingProtein" db:"training_protein"`
+	TrainingFat      *float64   `json:"trainingFat" db:"training_fat"`
+	TrainingCarbs    *float64   `json:"trainingCarbs" db:"training_carbs"`
+	WeeklyBudget     bool       `json:"weeklyBudget" db:"weekly_budget"`
+	AdaptiveTargets  bool       `json:"adaptiveTargets" db:"adaptive_targets"`
+	TargetWeight     *float64   `json:"targetWeight" db:"target_weight"`
+	TargetDate       *time.Time `json:"targetDate" db:"target_date"`
+	WeightRecalc     bool       `json:"weightRecalc" db:"weight_recalc"`
+	PresetId         *int64     `json:"presetId" db:"preset_id"`
+	ExerciseAddBack  bool       `json:"exerciseAddBack" db:"exercise_add_back"`
+	Warnings         []Warning  `json:"warnings,omitempty" db:"-"`
+	UserId           string     `json:"-" db:"user_id"`
+	CreatedAt        time.Time  `json:"-" db:"created_at"`
+	UpdatedAt        time.Time  `json:"-" db:"updated_at"`
+	DeletedAt        *time.Time `json:"-" db:"deleted_at"`
 }
 
 type FitProfileCreate struct {
-	Age           int64   `json:"age" db:"age"`
-	Gender        string  `json:"gender" db:"gender"`
-	Height        int64   `json:"height" db:"height"`
-	Weight        int64   `json:"weight" db:"weight"`
-	ActivityLevel float64 `json:"activityLevel" db:"activity_level"`
-	Goal          string  `json:"goal" db:"goal"`
-	Calories      float64 `json:"calories" db:"calories"`
-	Protein       float64 `json:"protein" db:"protein"`
-	Fat           float64 `json:"fat" db:"fat"`
-	Carbs         float64 `json:"carbs" db:"carbs"`
-	WaterLimit    int64   `json:"waterLimit" db:"water_limit"`
-	UserId        string  `json:"-" db:"user_id"`
+	Age              int64    `json:"age" db:"age"`
+	Gender           string   `json:"gender" db:"gender"`
+	Height           int64    `json:"height" db:"height"`
+	Weight           float64  `json:"weight" db:"weight"`
+	ActivityLevel    float64  `json:"activityLevel" db:"activity_level"`
+	Goal             string   `json:"goal" db:"goal"`
+	Calories         float64  `json:"calories" db:"calories"`
+	Protein          float64  `json:"protein" db:"protein"`
+	Fat              float64  `json:"fat" db:"fat"`
+	Carbs            float64  `json:"carbs" db:"carbs"`
+	WaterLimit       int64    `json:"waterLimit" db:"water_limit"`
+	BodyFat          *float64 `json:"bodyFat" db:"body_fat"`
+	AutoTargets      bool     `json:"autoTargets" db:"auto_targets"`  // Рассчитать цели на сервере
+	EffectiveFrom    *string  `json:"effectiveFrom,omitempty" db:"-"` // YYYY-MM-DD, по умолчанию сегодня
+	TrainingCalories *float64 `json:"trainingCalories" db:"training_calories"`
+	TrainingProtein  *float64 `json:"trainingProtein" db:"training_protein"`
+	TrainingFat      *float64 `json:"trainingFat" db:"training_fat"`
+	TrainingCarbs    *float64 `json:"trainingCarbs" db:"training_carbs"`
+	WeeklyBudget     bool     `json:"weeklyBudget" db:"weekly_budget"`       // Переносить недобор/перебор калорий в пределах недели
+	AdaptiveTargets  bool     `json:"adaptiveTargets" db:"adaptive_targets"` // Еженедельно применять оценку фактического расхода
+	TargetWeight     *float64 `json:"targetWeight" db:"target_weight"`
+	TargetDate       *string  `json:"targetDate" db:"target_date"`            // YYYY-MM-DD
+	WeightRecalc     bool     `json:"weightRecalc" db:"weight_recalc"`        // Пересчитывать цели, когда вес заметно изменился
+	PresetId         *int64   `json:"presetId" db:"preset_id"`                // Пресет распределения БЖУ
+	ExerciseAddBack  bool     `json:"exerciseAddBack" db:"exercise_add_back"` // Добавлять калории тренировок к дневному бюджету
+	UserId           string   `json:"-" db:"user_id"`
+}
+
+// TargetsVersion is a row of fit_profile_history.
+type TargetsVersion struct {
+	Id               int64     `json:"id" db:"id"`
+	FitId            string    `json:"-" db:"fit_id"`
+	UserId           string    `json:"-" db:"user_id"`
+	Goal             string    `json:"goal" db:"goal"`
+	Weight           float64   `json:"weight" db:"weight"`
+	Calories         float64   `json:"calories" db:"calories"`
+	Protein          float64   `json:"protein" db:"protein"`
+	Fat              float64   `json:"fat" db:"fat"`
+	Carbs            float64   `json:"carbs" db:"carbs"`
+	WaterLimit       *int64    `json:"waterLimit" db:"water_limit"`
+	TrainingCalories *float64  `json:"trainingCalories" db:"training_calories"`
+	TrainingProtein  *float64  `json:"trainingProtein" db:"training_protein"`
+	TrainingFat      *float64  `json:"trainingFat" db:"training_fat"`
+	TrainingCarbs    *float64  `json:"trainingCarbs" db:"training_carbs"`
+	WeeklyBudget     bool      `json:"weeklyBudget" db:"weekly_budget"`
+	Source           string    `json:"source" db:"source"`
+	EffectiveFrom    time.Time `json:"effectiveFrom" db:"effective_from"`
+	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time `json:"-" db:"updated_at"`
+}
+
+// Targets are the targets resolved for a single day.
+type Targets struct {
+	Date          string  `json:"date"`
+	Calories      float64 `json:"calories"`
+	Protein       float64 `json:"protein"`
+	Fat           float64 `json:"fat"`
+	Carbs         float64 `json:"carbs"`
+	WaterLimit    *int64  `json:"waterLimit,omitempty"`
+	EffectiveFrom string  `json:"effectiveFrom"`
+	Source        string  `json:"source"`
+	IsTraining    bool    `json:"isTraining"`
+	BaseCalories  float64 `json:"baseCalories"` // калории до поправки недельного бюджета
+	WeeklyBudget  bool    `json:"weeklyBudget"`
+}
+
+// WeekdayTarget overrides targets for a weekday (0 — Sunday, as time.Weekday)
+// from ValidFrom until the next set.
+type WeekdayTarget struct {
+	Id         int64     `json:"id" db:"id"`
+	UserId     string    `json:"-" db:"user_id"`
+	Weekday    int       `json:"weekday" db:"weekday"`
+	Calories   *float64  `json:"calories" db:"calories"`
+	Protein    *float64  `json:"protein" db:"protein"`
+	Fat        *float64  `json:"fat" db:"fat"`
+	Carbs      *float64  `json:"carbs" db:"carbs"`
+	IsTraining bool      `json:"isTraining" db:"is_training"`
+	ValidFrom  time.Time `json:"validFrom" db:"valid_from"`
+	CreatedAt  time.Time `json:"-" db:"created_at"`
+	UpdatedAt  time.Time `json:"-" db:"updated_at"`
+}
+
+// TrainingDay is an explicit training/rest toggle for a date.
+type TrainingDay struct {
+	Id         int64     `json:"id" db:"id"`
+	UserId     string    `json:"-" db:"user_id"`
+	Day        time.Time `json:"day" db:"day"`
+	IsTraining bool      `json:"isTraining" db:"is_training"`
+	CreatedAt  time.Time `json:"-" db:"created_at"`
+	UpdatedAt  time.Time `json:"-" db:"updated_at"`
+}
+
+// Macros is a set of calorie and macro targets.
+type Macros struct {
+	Calories float64 `json:"calories" db:"calories"`
+	Protein  float64 `json:"protein" db:"protein"`
+	Fat      float64 `json:"fat" db:"fat"`
+	Carbs    float64 `json:"carbs" db:"carbs"`
 }
@@ -3,6 +3,7 @@ package fit
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -14,6 +15,25 @@ type Repository interface {
 	GetFitProfileByUser(ctx context.Context, uid string) (*FitProfile, error)
 	GetFitProfileById(ctx context.Context, id string) (*FitProfile, error)
 	UpdateFitProfile(ctx context.Context, fu FitProfileCreate, uid string, fid string) (*FitProfile, error)
+	UpdateTargets(ctx context.Context, fid string, m Macros) (*FitProfile, error)
+	UpdateWeight(ctx context.Context, fid string, weight float64) (*FitProfile, error)
+	UpdatePreset(ctx context.Context, fid string, presetId *int64) (*FitProfile, error)
+	GetAdaptiveUserIds(ctx context.Context) ([]string, error)
+
+	// Targets history
+	UpsertHistory(ctx context.Context, f FitProfile, source string, effectiveFrom time.Time) (*TargetsVersion, error)
+	GetHistory(ctx context.Context, uid string) ([]TargetsVersion, error)
+
+	// Weekday overrides & training days
+	GetWeekdayTargets(ctx context.Context, uid string) ([]WeekdayTarget, error)
+	GetWeekdayTargetsHistory(ctx context.Context, uid string) ([]WeekdayTarget, error)
+	ReplaceWeekdayTargets(ctx context.Context, uid string, list []WeekdayTarget, validFrom time.Time) ([]WeekdayTarget, error)
+	GetTrainingDays(ctx context.Context, uid string, from, to time.Time) ([]TrainingDay, error)
+	UpsertTrainingDay(ctx context.Context, td TrainingDay) (*TrainingDay, error)
+	DeleteTrainingDay(ctx context.Context, uid string, day time.Time) error
+
+	// Consumption (weekly budget)
+	GetDailyCalories(ctx context.Context, uid string, from, to time.Time) (map[string]float64, error)
 }
 
 type repository struct {
@@ -28,6 +48,9 @@ func NewRepository() Repository {
 const fitColumns = `
 	id, age, gender, height, weight, activity_level, goal,
 	calories, protein, fat, carbs, water_limit,
+	body_fat, auto_targets,
+	training_calories, training_protein, training_fat, training_carbs, weekly_budget,
+	adaptive_targets, target_weight, target_date, weight_recalc, preset_id, exercise_add_back,
 	user_id, created_at, updated_at, deleted_at
 `
 
@@ -35,26 +58,45 @@ func (r *repository) CreateFitProfile(ctx context.Context, fc FitProfileCreate)
 	const q = `
 	INSERT INTO fit_profiles (
 		age, gender, height, weight, activity_level, goal,
-		calories, protein, fat, carbs, water_limit, user_id
+		calories, protein, fat, carbs, water_limit,
+		body_fat, auto_targets,
+		training_calories, training_protein, training_fat, training_carbs, weekly_budget,
+		adaptive_targets, target_weight, target_date, weight_recalc, preset_id, exercise_add_back, user_id
 	) VALUES (
 		:age, :gender, :height, :weight, :activity_level, :goal,
-		:calories, :protein, :fat, :carbs, :water_limit, :user_id
+		:calories, :protein, :fat, :carbs, :water_limit,
+		:body_fat, :auto_targets,
+		:training_calories, :training_protein, :training_fat, :training_carbs, :weekly_budget,
+		:adaptive_targets, :target_weight, :target_date, :weight_recalc, :preset_id, :exercise_add_back, :user_id
 	)
 	RETURNING ` + fitColumns + `;`
 
 	args := map[string]any{
-		"age":            fc.Age,
-		"gender":         fc.Gender,
-		"height":         fc.Height,
-		"weight":         fc.Weight,
-		"activity_level": fc.ActivityLevel,
-		"goal":           fc.Goal,
-		"calories":       fc.Calories,
-		"protein":        fc.Protein,
-		"fat":            fc.Fat,
-		"carbs":          fc.Carbs,
-		"water_limit":    fc.WaterLimit,
-		"user_id":        fc.UserId,
+		"age":               fc.Age,
+		"gender":            fc.Gender,
+		"height":            fc.Height,
+		"weight":            fc.Weight,
+		"activity_level":    fc.ActivityLevel,
+		"goal":              fc.Goal,
+		"calories":          fc.Calories,
+		"protein":           fc.Protein,
+		"fat":               fc.Fat,
+		"carbs":             fc.Carbs,
+		"water_limit":       fc.WaterLimit,
+		"body_fat":          fc.BodyFat,
+		"auto_targets":      fc.AutoTargets,
+		"training_calories": fc.TrainingCalories,
+		"training_protein":  fc.TrainingProtein,
+		"training_fat":      fc.TrainingFat,
+		"training_carbs":    fc.TrainingCarbs,
+		"weekly_budget":     fc.WeeklyBudget,
+		"adaptive_targets":  fc.AdaptiveTargets,
+		"target_weight":     fc.TargetWeight,
+		"target_date":       fc.TargetDate,
+		"weight_recalc":     fc.WeightRecalc,
+		"preset_id":         fc.PresetId,
+		"exercise_add_back": fc.ExerciseAddBack,
+		"user_id":           fc.UserId,
 	}
 
 	rows, err := r.db.NamedQueryContext(ctx, q, args)
@@ -88,24 +130,50 @@ func (r *repository) UpdateFitProfile(ctx context.Context, fu FitProfileCreate,
 		fat = :fat,
 		carbs = :carbs,
 		water_limit = :water_limit,
+		body_fat = :body_fat,
+		auto_targets = :auto_targets,
+		training_calories = :training_calories,
+		training_protein = :training_protein,
+		training_fat = :training_fat,
+		training_carbs = :training_carbs,
+		weekly_budget = :weekly_budget,
+		adaptive_targets = :adaptive_targets,
+		target_weight = :target_weight,
+		target_date = :target_date,
+		weight_recalc = :weight_recalc,
+		preset_id = :preset_id,
+		exercise_add_back = :exercise_add_back,
 		updated_at = now()
 	WHERE id = :id AND user_id = :user_id
 	RETURNING ` + fitColumns + `;`
 
 	args := map[string]any{
-		"id":             fid,
-		"user_id":        uid,
-		"age":            fu.Age,
-		"gender":         fu.Gender,
-		"height":         fu.Height,
-		"weight":         fu.Weight,
-		"activity_level": fu.ActivityLevel,
-		"goal":           fu.Goal,
-		"calories":       fu.Calories,
-		"protein":        fu.Protein,
-		"fat":            fu.Fat,
-		"carbs":          fu.Carbs,
-		"water_limit":    fu.WaterLimit,
+		"id":                fid,
+		"user_id":           uid,
+		"age":               fu.Age,
+		"gender":            fu.Gender,
+		"height":            fu.Height,
+		"weight":            fu.Weight,
+		"activity_level":    fu.ActivityLevel,
+		"goal":              fu.Goal,
+		"calories":          fu.Calories,
+		"protein":           fu.Protein,
+		"fat":               fu.Fat,
+		"carbs":             fu.Carbs,
+		"water_limit":       fu.WaterLimit,
+		"body_fat":          fu.BodyFat,
+		"auto_targets":      fu.AutoTargets,
+		"training_calories": fu.TrainingCalories,
+		"training_protein":  fu.TrainingProtein,
+		"training_fat":      fu.TrainingFat,
+		"training_carbs":    fu.TrainingCarbs,
+		"weekly_budget":     fu.WeeklyBudget,
+		"adaptive_targets":  fu.AdaptiveTargets,
+		"target_weight":     fu.TargetWeight,
+		"target_date":       fu.TargetDate,
+		"weight_recalc":     fu.WeightRecalc,
+		"preset_id":         fu.PresetId,
+		"exercise_add_back": fu.ExerciseAddBack,
 	}
 
 	rows, err := r.db.NamedQueryContext(ctx, q, args)
@@ -125,6 +193,70 @@ func (r *repository) UpdateFitProfile(ctx context.Context, fu FitProfileCreate,
 	return nil, nil
 }
 
+// UpdateTargets меняет только цели по КБЖУ, не трогая остальные поля профиля.
+func (r *repository) UpdateTargets(ctx context.Context, fid string, m Macros) (*FitProfile, error) {
+	const q = `
+	UPDATE fit_profiles
+	SET calories = $2, protein = $3, fat = $4, carbs = $5, updated_at = now()
+	WHERE id = $1
+	RETURNING ` + fitColumns + `;`
+
+	var f FitProfile
+	if err := r.db.GetContext(ctx, &f, q, fid, m.Calories, m.Protein, m.Fat, m.Carbs); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+// UpdateWeight меняет только рабочий вес профиля.
+func (r *repository) UpdateWeight(ctx context.Context, fid string, weight float64) (*FitProfile, error) {
+	const q = `
+	UPDATE fit_profiles
+	SET weight = $2, updated_at = now()
+	WHERE id = $1
+	RETURNING ` + fitColumns + `;`
+
+	var f FitProfile
+	if err := r.db.GetContext(ctx, &f, q, fid, weight); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+// UpdatePreset запоминает выбранный пресет БЖУ (nil — без пресета).
+func (r *repository) UpdatePreset(ctx context.Context, fid string, presetId *int64) (*FitProfile, error) {
+	const q = `
+	UPDATE fit_profiles
+	SET preset_id = $2, updated_at = now()
+	WHERE id = $1
+	RETURNING ` + fitColumns + `;`
+
+	var f FitProfile
+	if err := r.db.GetContext(ctx, &f, q, fid, presetId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *repository) GetAdaptiveUserIds(ctx context.Context) ([]string, error) {
+	const q = `SELECT user_id FROM fit_profiles WHERE adaptive_targets = TRUE AND deleted_at IS NULL;`
+
+	var res []string
+	if err := r.db.SelectContext(ctx, &res, q); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (r *repository) GetFitProfileByUser(ctx context.Context, uid string) (*FitProfile, error) {
 	const q = `SELECT ` + fitColumns + ` FROM fit_profiles WHERE user_id = $1 LIMIT 1;`
 
@@ -150,3 +282,240 @@ func (r *repository) GetFitProfileById(ctx context.Context, id string) (*FitProf
 	}
 	return &f, nil
 }
+
+const historyColumns = `
+	id, fit_id, user_id, goal, weight, calories, protein, fat, carbs, water_limit,
+	training_calories, training_protein, training_fat, training_carbs, weekly_budget,
+	source, effective_from, created_at, updated_at
+`
+
+// UpsertHistory сохраняет текущие цели профиля (вместе с тренировочными целями и режимом
+// недельного бюджета) как версию, действующую с effectiveFrom.
+// Повторное изменение в тот же день перезаписывает версию этого дня.
+func (r *repository) UpsertHistory(ctx context.Context, f FitProfile, source string, effectiveFrom time.Time) (*TargetsVersion, error) {
+	const q = `
+	INSERT INTO fit_profile_history (
+		fit_id, user_id, goal, weight, calories, protein, fat, carbs, water_limit,
+		training_calories, training_protein, training_fat, training_carbs, weekly_budget, source, effective_from
+	) VALUES (
+		:fit_id, :user_id, :goal, :weight, :calories, :protein, :fat, :carbs, :water_limit,
+		:training_calories, :training_protein, :training_fat, :training_carbs, :weekly_budget, :source, :effective_from
+	)
+	ON CONFLICT (user_id, effective_from) DO UPDATE SET
+		fit_id = EXCLUDED.fit_id,
+		goal = EXCLUDED.goal,
+		weight = EXCLUDED.weight,
+		calories = EXCLUDED.calories,
+		protein = EXCLUDED.protein,
+		fat = EXCLUDED.fat,
+		carbs = EXCLUDED.carbs,
+		water_limit = EXCLUDED.water_limit,
+		training_calories = EXCLUDED.training_calories,
+		training_protein = EXCLUDED.training_protein,
+		training_fat = EXCLUDED.training_fat,
+		training_carbs = EXCLUDED.training_carbs,
+		weekly_budget = EXCLUDED.weekly_budget,
+		source = EXCLUDED.source,
+		updated_at = now()
+	RETURNING ` + historyColumns + `;`
+
+	args := map[string]any{
+		"fit_id":            f.Id,
+		"user_id":           f.UserId,
+		"goal":              f.Goal,
+		"weight":            f.Weight,
+		"calories":          f.Calories,
+		"protein":           f.Protein,
+		"fat":               f.Fat,
+		"carbs":             f.Carbs,
+		"water_limit":       f.WaterLimit,
+		"training_calories": f.TrainingCalories,
+		"training_protein":  f.TrainingProtein,
+		"training_fat":      f.TrainingFat,
+		"training_carbs":    f.TrainingCarbs,
+		"weekly_budget":     f.WeeklyBudget,
+		"source":            source,
+		"effective_from":    effectiveFrom,
+	}
+
+	rows, err := r.db.NamedQueryContext(ctx, q, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var v TargetsVersion
+		if err := rows.StructScan(&v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+	return nil, nil
+}
+
+func (r *repository) GetHistory(ctx context.Context, uid string) ([]TargetsVersion, error) {
+	const q = `SELECT ` + historyColumns + ` FROM fit_profile_history WHERE user_id = $1 ORDER BY effective_from;`
+
+	var res []TargetsVersion
+	if err := r.db.SelectContext(ctx, &res, q, uid); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+const weekdayColumns = `id, user_id, weekday, calories, protein, fat, carbs, is_training, valid_from, created_at, updated_at`
+
+// GetWeekdayTargets возвращает действующий набор: последнюю строку каждого дня недели,
+// кроме пустых строк, которыми набор снимает переопределение.
+func (r *repository) GetWeekdayTargets(ctx context.Context, uid string) ([]WeekdayTarget, error) {
+	const q = `
+	SELECT ` + weekdayColumns + ` FROM (
+		SELECT DISTINCT ON (weekday) ` + weekdayColumns + `
+		FROM fit_weekday_targets
+		WHERE user_id = $1
+		ORDER BY weekday, valid_from DESC
+	) w
+	WHERE calories IS NOT NULL OR protein IS NOT NULL OR fat IS NOT NULL OR carbs IS NOT NULL OR is_training
+	ORDER BY weekday;`
+
+	var res []WeekdayTarget
+	if err := r.db.SelectContext(ctx, &res, q, uid); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetWeekdayTargetsHistory возвращает все версии переопределений по возрастанию valid_from.
+func (r *repository) GetWeekdayTargetsHistory(ctx context.Context, uid string) ([]WeekdayTarget, error) {
+	const q = `SELECT ` + weekdayColumns + ` FROM fit_weekday_targets WHERE user_id = $1 ORDER BY valid_from, weekday;`
+
+	var res []WeekdayTarget
+	if err := r.db.SelectContext(ctx, &res, q, uid); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReplaceWeekdayTargets сохраняет новый набор переопределений, действующий с validFrom.
+// Дни недели вне набора получают пустую строку, поэтому прошлые дни сохраняют свои версии.
+// Повторная замена в тот же день перезаписывает набор этого дня.
+func (r *repository) ReplaceWeekdayTargets(ctx context.Context, uid string, list []WeekdayTarget, validFrom time.Time) ([]WeekdayTarget, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM fit_weekday_targets WHERE user_id = $1 AND valid_from = $2`, uid, validFrom); err != nil {
+		return nil, err
+	}
+
+	const q = `
+	INSERT INTO fit_weekday_targets (user_id, weekday, calories, protein, fat, carbs, is_training, valid_from)
+	VALUES (:user_id, :weekday, :calories, :protein, :fat, :carbs, :is_training, :valid_from)
+	RETURNING ` + weekdayColumns + `;`
+
+	set := make(map[int]WeekdayTarget, len(list))
+	for _, wt := range list {
+		set[wt.Weekday] = wt
+	}
+	res := make([]WeekdayTarget, 0, len(list))
+	for weekday := 0; weekday < 7; weekday++ {
+		wt, ok := set[weekday]
+		if !ok {
+			wt = WeekdayTarget{Weekday: weekday}
+		}
+		wt.UserId = uid
+		wt.ValidFrom = validFrom
+		rows, err := tx.NamedQuery(q, wt)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var out WeekdayTarget
+			if err := rows.StructScan(&out); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if ok {
+				res = append(res, out)
+			}
+		}
+		rows.Close()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+const trainingDayColumns = `id, user_id, day, is_training, created_at, updated_at`
+
+func (r *repository) GetTrainingDays(ctx context.Context, uid string, from, to time.Time) ([]TrainingDay, error) {
+	const q = `SELECT ` + trainingDayColumns + ` FROM fit_training_days WHERE user_id = $1 AND day >= $2 AND day <= $3 ORDER BY day;`
+
+	var res []TrainingDay
+	if err := r.db.SelectContext(ctx, &res, q, uid, from, to); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *repository) UpsertTrainingDay(ctx context.Context, td TrainingDay) (*TrainingDay, error) {
+	const q = `
+	INSERT INTO fit_training_days (user_id, day, is_training)
+	VALUES (:user_id, :day, :is_training)
+	ON CONFLICT (user_id, day) DO UPDATE SET is_training = EXCLUDED.is_training, updated_at = now()
+	RETURNING ` + trainingDayColumns + `;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, td)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var out TrainingDay
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	}
+	return nil, nil
+}
+
+func (r *repository) DeleteTrainingDay(ctx context.Context, uid string, day time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM fit_training_days WHERE user_id = $1 AND day = $2`, uid, day)
+	return err
+}
+
+// GetDailyCalories суммирует калории по дням в часовом поясе пользователя.
+func (r *repository) GetDailyCalories(ctx context.Context, uid string, from, to time.Time) (map[string]float64, error) {
+	day := database.LocalDate("created_at", database.UserTimezone("$1"))
+	q := `
+	SELECT ` + day + ` AS d, COALESCE(SUM(calories), 0)::float AS v
+	FROM products
+	WHERE user_id = $1 AND ` + database.LocalDays("created_at", "$2::date", "$3::date", database.UserTimezone("$1")) + `
+	GROUP BY d`
+
+	rows, err := r.db.QueryxContext(ctx, q, uid, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[string]float64{}
+	for rows.Next() {
+		var d time.Time
+		var v float64
+		if err := rows.Scan(&d, &v); err != nil {
+			return nil, err
+		}
+		res[d.Format("2006-01-02")] = v
+	}
+	return res, rows.Err()
+}
@@ -2,25 +2,86 @@ package fit
 
 import (
 	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/database"
+	"github.com/jourloy/nutri-backend/internal/diet"
+)
+
+const (
+	SourceManual   = "manual"
+	SourceAuto     = "auto"
+	SourceAdaptive = "adaptive"
+	SourceWeight   = "weight_sync"
+	SourcePreset   = "preset"
+	SourcePlateau  = "plateau"
 )
 
+// weightDriftPct — изменение веса (в % от веса последней версии целей), после которого цели пересчитываются.
+const weightDriftPct = 3.0
+
 type Service interface {
 	CreateFitProfile(fc FitProfileCreate) (*FitProfile, error)
 	GetFitProfileByUser(uid string) (*FitProfile, error)
 	GetFitProfileById(id string) (*FitProfile, error)
 	UpdateFitProfile(ctx context.Context, fu FitProfileCreate, uid string) (*FitProfile, error)
+	Calculate(fc FitProfileCreate) (*Calculation, error)
+	ApplyTargets(ctx context.Context, uid string, m Macros, source string) (*FitProfile, error)
+	GetAdaptiveUserIds(ctx context.Context) ([]string, error)
+	SyncWeight(ctx context.Context, uid string, weight float64) (*FitProfile, error)
+	// Diet presets
+	ApplyPreset(ctx context.Context, uid string, presetId int64) (*FitProfile, error)
+	ClearPreset(ctx context.Context, uid string) (*FitProfile, error)
+	// Targets history
+	GetTargetsHistory(ctx context.Context, uid string) ([]TargetsVersion, error)
+	ResolveTargets(ctx context.Context, uid string, from, to time.Time) (map[string]Targets, error)
+	GetTargetsForDay(ctx context.Context, uid string, day time.Time) (*Targets, error)
+	// Weekday overrides & training days
+	GetWeekdayTargets(ctx context.Context, uid string) ([]WeekdayTarget, error)
+	ReplaceWeekdayTargets(ctx context.Context, uid string, list []WeekdayTarget) ([]WeekdayTarget, error)
+	GetTrainingDays(ctx context.Context, uid string, from, to time.Time) ([]TrainingDay, error)
+	SetTrainingDay(ctx context.Context, td TrainingDay) (*TrainingDay, error)
+	DeleteTrainingDay(ctx context.Context, uid string, day time.Time) error
 }
 
 type service struct {
-	repo Repository
+	repo    Repository
+	presets diet.Service
 }
 
 func NewService() Service {
-	return &service{repo: NewRepository()}
+	return &service{repo: NewRepository(), presets: diet.NewService()}
 }
 
 func (s *service) CreateFitProfile(fc FitProfileCreate) (*FitProfile, error) {
-	return s.repo.CreateFitProfile(context.Background(), fc)
+	if err := validateGoalWeight(&fc); err != nil {
+		return nil, err
+	}
+	effectiveFrom, err := s.effectiveFrom(context.Background(), fc.UserId, fc)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCaloriesFloor(fc.TrainingCalories, fc.Gender, "training"); err != nil {
+		return nil, err
+	}
+	if err := applyCalculation(&fc); err != nil {
+		return nil, err
+	}
+	if err := s.applyPresetSplit(context.Background(), &fc); err != nil {
+		return nil, err
+	}
+	warnings := applyGuardrails(&fc)
+	f, err := s.repo.CreateFitProfile(context.Background(), fc)
+	if err != nil || f == nil {
+		return f, err
+	}
+	if err := s.recordHistory(context.Background(), *f, fc, effectiveFrom); err != nil {
+		return nil, err
+	}
+	f.Warnings = warnings
+	return f, nil
 }
 
 func (s *service) GetFitProfileByUser(uid string) (*FitProfile, error) {
@@ -36,6 +97,404 @@ func (s *service) UpdateFitProfile(ctx context.Context, fu FitProfileCreate, uid
 	if err != nil {
 		return nil, err
 	}
+	if f == nil {
+		return nil, errors.New("fit profile not found")
+	}
+	if err := validateGoalWeight(&fu); err != nil {
+		return nil, err
+	}
+	effectiveFrom, err := s.effectiveFrom(ctx, uid, fu)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCaloriesFloor(fu.TrainingCalories, fu.Gender, "training"); err != nil {
+		return nil, err
+	}
+	if err := applyCalculation(&fu); err != nil {
+		return nil, err
+	}
+	if err := s.applyPresetSplit(ctx, &fu); err != nil {
+		return nil, err
+	}
+	warnings := applyGuardrails(&fu)
+
+	updated, err := s.repo.UpdateFitProfile(context.Background(), fu, uid, f.Id)
+	if err != nil || updated == nil {
+		return updated, err
+	}
+	if err := s.recordHistory(ctx, *updated, fu, effectiveFrom); err != nil {
+		return nil, err
+	}
+	updated.Warnings = warnings
+	return updated, nil
+}
+
+func (s *service) Calculate(fc FitProfileCreate) (*Calculation, error) {
+	calc, err := Calculate(fc)
+	if err != nil {
+		return nil, err
+	}
+	if fc.PresetId != nil {
+		p, err := s.preset(context.Background(), *fc.PresetId)
+		if err != nil {
+			return nil, err
+		}
+		calc.Protein, calc.Fat, calc.Carbs = p.Split(calc.Calories, fc.Weight)
+	}
+	m := Macros{Calories: calc.Calories, Protein: calc.Protein, Fat: calc.Fat, Carbs: calc.Carbs}
+	calc.Warnings = ClampMacros(&m, fc.Gender, fc.Weight)
+	calc.Calories, calc.Protein, calc.Fat, calc.Carbs = m.Calories, m.Protein, m.Fat, m.Carbs
+	return calc, nil
+}
+
+// ApplyTargets sets new calorie/macro targets from an automatic source and
+// records them in history effective from today.
+func (s *service) ApplyTargets(ctx context.Context, uid string, m Macros, source string) (*FitProfile, error) {
+	f, err := s.repo.GetFitProfileByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errors.New("fit profile not found")
+	}
+	// выбранный пресет определяет распределение БЖУ при любом пересчёте
+	if f.PresetId != nil {
+		p, err := s.preset(ctx, *f.PresetId)
+		if err != nil {
+			return nil, err
+		}
+		m.Protein, m.Fat, m.Carbs = p.Split(m.Calories, f.Weight)
+	}
+	warnings := ClampMacros(&m, f.Gender, f.Weight)
+
+	today, err := database.UserToday(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpdateTargets(ctx, f.Id, m)
+	if err != nil || updated == nil {
+		return updated, err
+	}
+	if _, err := s.repo.UpsertHistory(ctx, *updated, source, today); err != nil {
+		return nil, err
+	}
+	updated.Warnings = warnings
+	return updated, nil
+}
+
+func (s *service) GetAdaptiveUserIds(ctx context.Context) ([]string, error) {
+	return s.repo.GetAdaptiveUserIds(ctx)
+}
+
+// SyncWeight переносит сглаженный вес из журнала взвешиваний в профиль.
+// Если включён weight_recalc и вес ушёл от веса текущей версии целей больше чем на weightDriftPct,
+// цели пересчитываются и сохраняются в истории с источником weight_sync.
+func (s *service) SyncWeight(ctx context.Context, uid string, weight float64) (*FitProfile, error) {
+	f, err := s.repo.GetFitProfileByUser(ctx, uid)
+	if err != nil || f == nil {
+		return f, err
+	}
+	weight = math.Round(weight*100) / 100
+	if math.Abs(weight-f.Weight) < 0.01 {
+		return f, nil
+	}
+
+	updated, err := s.repo.UpdateWeight(ctx, f.Id, weight)
+	if err != nil || updated == nil {
+		return updated, err
+	}
+	if !updated.WeightRecalc {
+		return updated, nil
+	}
+
+	// вес, для которого были рассчитаны действующие цели
+	base := f.Weight
+	if v, err := s.currentVersion(ctx, uid); err != nil {
+		return nil, err
+	} else if v != nil && v.Weight > 0 {
+		base = v.Weight
+	}
+	if base <= 0 || math.Abs(weight-base)/base*100 < weightDriftPct {
+		return updated, nil
+	}
+
+	calc, err := Calculate(ProfileInput(*updated))
+	if err != nil {
+		// профиль без возраста/роста не пересчитываем
+		return updated, nil
+	}
+	return s.ApplyTargets(ctx, uid, Macros{Calories: calc.Calories, Protein: calc.Protein, Fat: calc.Fat, Carbs: calc.Carbs}, SourceWeight)
+}
+
+// currentVersion возвращает версию целей, действующую сегодня.
+func (s *service) currentVersion(ctx context.Context, uid string) (*TargetsVersion, error) {
+	history, err := s.repo.GetHistory(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	today, err := database.UserToday(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	var cur *TargetsVersion
+	for i := range history {
+		if history[i].EffectiveFrom.After(today) {
+			break
+		}
+		cur = &history[i]
+	}
+	return cur, nil
+}
+
+// ProfileInput собирает данные профиля для калькулятора.
+func ProfileInput(f FitProfile) FitProfileCreate {
+	return FitProfileCreate{
+		Age:           f.Age,
+		Gender:        f.Gender,
+		Height:        f.Height,
+		Weight:        f.Weight,
+		ActivityLevel: f.ActivityLevel,
+		Goal:          f.Goal,
+		BodyFat:       f.BodyFat,
+	}
+}
+
+// ApplyPreset запоминает пресет и пересчитывает БЖУ от текущих калорий профиля.
+func (s *service) ApplyPreset(ctx context.Context, uid string, presetId int64) (*FitProfile, error) {
+	p, err := s.preset(ctx, presetId)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Enabled {
+		return nil, errors.New("preset is disabled")
+	}
+	f, err := s.repo.GetFitProfileByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errors.New("fit profile not found")
+	}
+
+	if _, err := s.repo.UpdatePreset(ctx, f.Id, &p.Id); err != nil {
+		return nil, err
+	}
+	return s.ApplyTargets(ctx, uid, Macros{Calories: f.Calories}, SourcePreset)
+}
+
+// ClearPreset убирает пресет, текущие цели остаются без изменений.
+func (s *service) ClearPreset(ctx context.Context, uid string) (*FitProfile, error) {
+	f, err := s.repo.GetFitProfileByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errors.New("fit profile not found")
+	}
+	return s.repo.UpdatePreset(ctx, f.Id, nil)
+}
+
+func (s *service) preset(ctx context.Context, id int64) (*diet.Preset, error) {
+	p, err := s.presets.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, errors.New("preset not found")
+	}
+	return p, nil
+}
+
+// applyPresetSplit пересчитывает БЖУ от калорий по выбранному пресету.
+func (s *service) applyPresetSplit(ctx context.Context, fc *FitProfileCreate) error {
+	if fc.PresetId == nil {
+		return nil
+	}
+	p, err := s.preset(ctx, *fc.PresetId)
+	if err != nil {
+		return err
+	}
+	fc.Protein, fc.Fat, fc.Carbs = p.Split(fc.Calories, fc.Weight)
+	return nil
+}
+
+// validateGoalWeight проверяет целевой вес и дату (пустая дата — без дедлайна).
+func validateGoalWeight(fc *FitProfileCreate) error {
+	if fc.TargetWeight != nil && (*fc.TargetWeight < 20 || *fc.TargetWeight > 400) {
+		return errors.New("target weight must be between 20 and 400 kg")
+	}
+	if fc.TargetDate != nil && *fc.TargetDate == "" {
+		fc.TargetDate = nil
+	}
+	if fc.TargetDate != nil {
+		if _, err := time.Parse("2006-01-02", *fc.TargetDate); err != nil {
+			return errors.New("target date must be YYYY-MM-DD")
+		}
+	}
+	return nil
+}
+
+// parseEffectiveFrom возвращает день начала действия новых целей: по умолчанию сегодня.
+// Прошлые дни запрещены — иначе задним числом изменились бы цели уже прожитых дней.
+func parseEffectiveFrom(v *string, today time.Time) (time.Time, error) {
+	if v == nil || *v == "" {
+		return today, nil
+	}
+	t, err := time.Parse(dayLayout, *v)
+	if err != nil {
+		return time.Time{}, errors.New("effectiveFrom must be YYYY-MM-DD")
+	}
+	if t.Before(today) {
+		return time.Time{}, errors.New("effectiveFrom must not be in the past")
+	}
+	return t, nil
+}
+
+// effectiveFrom разбирает fc.EffectiveFrom относительно сегодняшнего дня пользователя.
+func (s *service) effectiveFrom(ctx context.Context, uid string, fc FitProfileCreate) (time.Time, error) {
+	today, err := database.UserToday(ctx, uid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseEffectiveFrom(fc.EffectiveFrom, today)
+}
+
+// recordHistory сохраняет версию целей, действующую с effectiveFrom.
+func (s *service) recordHistory(ctx context.Context, f FitProfile, fc FitProfileCreate, effectiveFrom time.Time) error {
+	source := SourceManual
+	if fc.AutoTargets {
+		source = SourceAuto
+	}
+	_, err := s.repo.UpsertHistory(ctx, f, source, effectiveFrom)
+	return err
+}
+
+func (s *service) GetTargetsHistory(ctx context.Context, uid string) ([]TargetsVersion, error) {
+	return s.repo.GetHistory(ctx, uid)
+}
+
+// ResolveTargets возвращает цели каждого дня диапазона (ключ — YYYY-MM-DD): версия из истории,
+// переопределения дня недели и тренировочного дня, поправка недельного бюджета.
+// Каждый день считается по настройкам, действовавшим в этот день.
+func (s *service) ResolveTargets(ctx context.Context, uid string, from, to time.Time) (map[string]Targets, error) {
+	versions, err := s.repo.GetHistory(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return map[string]Targets{}, nil
+	}
+
+	from = from.Truncate(24 * time.Hour)
+	to = to.Truncate(24 * time.Hour)
+	// недельный бюджет считается по целым ISO-неделям
+	rangeFrom, rangeTo := weekStart(from), weekStart(to).AddDate(0, 0, 6)
+	byDay := versionsByDay(versions, rangeFrom, rangeTo)
+	weekly := false
+	for _, v := range byDay {
+		weekly = weekly || v.WeeklyBudget
+	}
+
+	profile, err := s.repo.GetFitProfileByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	weekdays, err := s.repo.GetWeekdayTargetsHistory(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	trainingList, err := s.repo.GetTrainingDays(ctx, uid, rangeFrom, rangeTo)
+	if err != nil {
+		return nil, err
+	}
+	training := make(map[string]bool, len(trainingList))
+	for _, td := range trainingList {
+		training[td.Day.Format(dayLayout)] = td.IsTraining
+	}
+
+	all := make(map[string]Targets, len(byDay))
+	for day := rangeFrom; !day.After(rangeTo); day = day.AddDate(0, 0, 1) {
+		key := day.Format(dayLayout)
+		v := byDay[key]
+		t := baseTarget(day, v)
+		applyOverrides(&t, day, v, weekdayTargetOn(weekdays, day), training)
+		all[key] = t
+	}
+	if weekly {
+		consumed, err := s.repo.GetDailyCalories(ctx, uid, rangeFrom, rangeTo)
+		if err != nil {
+			return nil, err
+		}
+		applyWeeklyBudget(all, from, to, consumed)
+	}
+
+	res := make(map[string]Targets, len(all))
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(dayLayout)
+		t := all[key]
+		if profile != nil {
+			// поправка недельного бюджета и старые переопределения не опускают день ниже минимума
+			clampTargets(&t, profile.Gender, byDay[key].Weight)
+		}
+		res[key] = t
+	}
+	return res, nil
+}
+
+func (s *service) GetTargetsForDay(ctx context.Context, uid string, day time.Time) (*Targets, error) {
+	m, err := s.ResolveTargets(ctx, uid, day, day)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := m[day.Truncate(24*time.Hour).Format(dayLayout)]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+func (s *service) GetWeekdayTargets(ctx context.Context, uid string) ([]WeekdayTarget, error) {
+	return s.repo.GetWeekdayTargets(ctx, uid)
+}
+
+func (s *service) ReplaceWeekdayTargets(ctx context.Context, uid string, list []WeekdayTarget) ([]WeekdayTarget, error) {
+	f, err := s.repo.GetFitProfileByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errors.New("fit profile not found")
+	}
+	seen := map[int]bool{}
+	for _, wt := range list {
+		if err := checkCaloriesFloor(wt.Calories, f.Gender, "weekday"); err != nil {
+			return nil, err
+		}
+		if wt.Weekday < 0 || wt.Weekday > 6 {
+			return nil, errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		if seen[wt.Weekday] {
+			return nil, errors.New("duplicate weekday")
+		}
+		seen[wt.Weekday] = true
+	}
+	// новый набор действует с сегодняшнего дня, прошлые дни считаются по прежнему
+	today, err := database.UserToday(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ReplaceWeekdayTargets(ctx, uid, list, today)
+}
+
+func (s *service) GetTrainingDays(ctx context.Context, uid string, from, to time.Time) ([]TrainingDay, error) {
+	return s.repo.GetTrainingDays(ctx, uid, from, to)
+}
+
+func (s *service) SetTrainingDay(ctx context.Context, td TrainingDay) (*TrainingDay, error) {
+	return s.repo.UpsertTrainingDay(ctx, td)
+}
 
-	return s.repo.UpdateFitProfile(context.Background(), fu, uid, f.Id)
+func (s *service) DeleteTrainingDay(ctx context.Context, uid string, day time.Time) error {
+	return s.repo.DeleteTrainingDay(ctx, uid, day)
 }
@@ -0,0 +1,32 @@
+package fit
+
+import "testing"
+
+func TestParseEffectiveFrom(t *testing.T) {
+	today := date("2025-09-10")
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		in      *string
+		want    string
+		wantErr bool
+	}{
+		{"default is today", nil, "2025-09-10", false},
+		{"empty is today", str(""), "2025-09-10", false},
+		{"today", str("2025-09-10"), "2025-09-10", false},
+		{"future", str("2025-09-15"), "2025-09-15", false},
+		{"past is rejected", str("2025-09-09"), "", true},
+		{"bad format", str("10.09.2025"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEffectiveFrom(tt.in, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEffectiveFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Format(dayLayout) != tt.want {
+				t.Errorf("parseEffectiveFrom() = %s, want %s", got.Format(dayLayout), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package fit
+
+import (
+	"math"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// weekStart возвращает понедельник ISO-недели дня.
+func weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// versionsByDay раскладывает версии целей по дням диапазона.
+// Дни до первой версии получают первую версию: раньше неё целей не было вовсе.
+func versionsByDay(versions []TargetsVersion, from, to time.Time) map[string]TargetsVersion {
+	res := map[string]TargetsVersion{}
+	if len(versions) == 0 {
+		return res
+	}
+
+	idx := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(dayLayout)
+		for idx+1 < len(versions) && versions[idx+1].EffectiveFrom.Format(dayLayout) <= key {
+			idx++
+		}
+		res[key] = versions[idx]
+	}
+	return res
+}
+
+// baseTarget — цели дня по версии из истории, без переопределений.
+func baseTarget(day time.Time, v TargetsVersion) Targets {
+	return Targets{
+		Date:          day.Format(dayLayout),
+		Calories:      v.Calories,
+		Protein:       v.Protein,
+		Fat:           v.Fat,
+		Carbs:         v.Carbs,
+		WaterLimit:    v.WaterLimit,
+		EffectiveFrom: v.EffectiveFrom.Format(dayLayout),
+		Source:        v.Source,
+		WeeklyBudget:  v.WeeklyBudget,
+	}
+}
+
+// weekdayTargetOn возвращает переопределение дня недели, действовавшее в этот день:
+// последнюю строку с тем же днём недели и valid_from не позже дня. list отсортирован по valid_from.
+func weekdayTargetOn(list []WeekdayTarget, day time.Time) *WeekdayTarget {
+	var res *WeekdayTarget
+	key := day.Format(dayLayout)
+	for i := range list {
+		if list[i].ValidFrom.Format(dayLayout) > key {
+			break
+		}
+		if list[i].Weekday == int(day.Weekday()) {
+			res = &list[i]
+		}
+	}
+	return res
+}
+
+// applyOverrides накладывает переопределение дня недели, а в тренировочный день —
+// тренировочные цели версии. Явная отметка даты важнее настройки дня недели.
+func applyOverrides(t *Targets, day time.Time, v TargetsVersion, wt *WeekdayTarget, training map[string]bool) {
+	isTraining := false
+	if wt != nil {
+		overrideValue(&t.Calories, wt.Calories)
+		overrideValue(&t.Protein, wt.Protein)
+		overrideValue(&t.Fat, wt.Fat)
+		overrideValue(&t.Carbs, wt.Carbs)
+		isTraining = wt.IsTraining
+	}
+	if marked, ok := training[day.Format(dayLayout)]; ok {
+		isTraining = marked
+	}
+	if isTraining {
+		overrideValue(&t.Calories, v.TrainingCalories)
+		overrideValue(&t.Protein, v.TrainingProtein)
+		overrideValue(&t.Fat, v.TrainingFat)
+		overrideValue(&t.Carbs, v.TrainingCarbs)
+	}
+	t.IsTraining = isTraining
+	t.BaseCalories = t.Calories
+}
+
+func overrideValue(dst *float64, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// Коридор поправки недельного бюджета относительно плановых калорий дня.
+const (
+	weeklyBudgetMinFactor = 0.75
+	weeklyBudgetMaxFactor = 1.25
+)
+
+// applyWeeklyBudget распределяет недельный бюджет калорий: недобор/перебор прошлых
+// дней недели переносится на оставшиеся дни пропорционально их плановым калориям.
+// Дни без записей считаются выполненными по плану. Разница калорий идёт в углеводы.
+// Поправка касается только дней, версия целей которых включает недельный бюджет.
+// res должен покрывать целые ISO-недели.
+func applyWeeklyBudget(res map[string]Targets, from, to time.Time, consumed map[string]float64) {
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(dayLayout)
+		t, ok := res[key]
+		if !ok || !t.WeeklyBudget || t.BaseCalories <= 0 {
+			continue
+		}
+
+		ws := weekStart(day)
+		var banked, plannedRemaining float64
+		for d := ws; d.Before(day); d = d.AddDate(0, 0, 1) {
+			k := d.Format(dayLayout)
+			if v, ok := consumed[k]; ok {
+				banked += res[k].BaseCalories - v
+			}
+		}
+		for d := day; d.Before(ws.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+			plannedRemaining += res[d.Format(dayLayout)].BaseCalories
+		}
+		if plannedRemaining <= 0 {
+			continue
+		}
+
+		factor := (plannedRemaining + banked) / plannedRemaining
+		factor = math.Min(math.Max(factor, weeklyBudgetMinFactor), weeklyBudgetMaxFactor)
+		calories := math.Round(t.BaseCalories * factor)
+		t.Carbs = math.Max(0, math.Round(t.Carbs+(calories-t.BaseCalories)/kcalPerGramCarbs))
+		t.Calories = calories
+		res[key] = t
+	}
+}
+
+// clampTargets применяет защитные ограничения к целям дня.
+func clampTargets(t *Targets, gender string, weight float64) {
+	m := Macros{Calories: t.Calories, Protein: t.Protein, Fat: t.Fat, Carbs: t.Carbs}
+	ClampMacros(&m, gender, weight)
+	t.Calories, t.Protein, t.Fat, t.Carbs = m.Calories, m.Protein, m.Fat, m.Carbs
+}
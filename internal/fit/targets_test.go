@@ -0,0 +1,154 @@
+package fit
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse(dayLayout, s)
+	return d
+}
+
+func TestVersionsByDay(t *testing.T) {
+	versions := []TargetsVersion{
+		{Calories: 2000, EffectiveFrom: date("2025-09-03")},
+		{Calories: 1800, EffectiveFrom: date("2025-09-05")},
+	}
+	got := versionsByDay(versions, date("2025-09-01"), date("2025-09-06"))
+	want := map[string]float64{
+		"2025-09-01": 2000, // до первой версии — первая версия
+		"2025-09-03": 2000,
+		"2025-09-04": 2000,
+		"2025-09-05": 1800,
+		"2025-09-06": 1800,
+	}
+	for key, calories := range want {
+		if got[key].Calories != calories {
+			t.Errorf("versionsByDay()[%s].Calories = %v, want %v", key, got[key].Calories, calories)
+		}
+	}
+	if len(got) != 6 {
+		t.Errorf("versionsByDay() has %d days, want 6", len(got))
+	}
+}
+
+func TestWeekdayTargetOn(t *testing.T) {
+	// 2025-09-01 и 2025-09-08 — понедельники.
+	list := []WeekdayTarget{
+		{Weekday: 1, Calories: ptr(2500), ValidFrom: date("2025-09-01")},
+		{Weekday: 2, Calories: ptr(2200), ValidFrom: date("2025-09-01")},
+		{Weekday: 1, ValidFrom: date("2025-09-08")},
+	}
+	tests := []struct {
+		name     string
+		day      string
+		calories *float64
+		found    bool
+	}{
+		{"before any set", "2025-08-25", nil, false},
+		{"first set", "2025-09-01", ptr(2500), true},
+		{"other weekday", "2025-09-02", ptr(2200), true},
+		{"weekday without override", "2025-09-03", nil, false},
+		{"later set clears the override", "2025-09-08", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wt := weekdayTargetOn(list, date(tt.day))
+			if (wt != nil) != tt.found {
+				t.Fatalf("weekdayTargetOn() = %+v, found want %v", wt, tt.found)
+			}
+			if wt == nil {
+				return
+			}
+			if (wt.Calories == nil) != (tt.calories == nil) || (wt.Calories != nil && *wt.Calories != *tt.calories) {
+				t.Errorf("weekdayTargetOn().Calories = %v, want %v", wt.Calories, tt.calories)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	day := date("2025-09-01")
+	v := TargetsVersion{
+		Calories: 2000, Protein: 120, Fat: 60, Carbs: 230,
+		TrainingCalories: ptr(2400), TrainingCarbs: ptr(330),
+	}
+	tests := []struct {
+		name     string
+		wt       *WeekdayTarget
+		training map[string]bool
+		want     Targets
+	}{
+		{
+			name: "no overrides",
+			want: Targets{Calories: 2000, Protein: 120, Fat: 60, Carbs: 230, BaseCalories: 2000},
+		},
+		{
+			name: "weekday override",
+			wt:   &WeekdayTarget{Calories: ptr(1800), Carbs: ptr(180)},
+			want: Targets{Calories: 1800, Protein: 120, Fat: 60, Carbs: 180, BaseCalories: 1800},
+		},
+		{
+			name: "training weekday takes training targets",
+			wt:   &WeekdayTarget{Protein: ptr(140), IsTraining: true},
+			want: Targets{Calories: 2400, Protein: 140, Fat: 60, Carbs: 330, IsTraining: true, BaseCalories: 2400},
+		},
+		{
+			name:     "explicit rest day wins over training weekday",
+			wt:       &WeekdayTarget{IsTraining: true},
+			training: map[string]bool{"2025-09-01": false},
+			want:     Targets{Calories: 2000, Protein: 120, Fat: 60, Carbs: 230, BaseCalories: 2000},
+		},
+		{
+			name:     "explicit training day",
+			training: map[string]bool{"2025-09-01": true},
+			want:     Targets{Calories: 2400, Protein: 120, Fat: 60, Carbs: 330, IsTraining: true, BaseCalories: 2400},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Targets{Calories: v.Calories, Protein: v.Protein, Fat: v.Fat, Carbs: v.Carbs}
+			applyOverrides(&got, day, v, tt.wt, tt.training)
+			if got != tt.want {
+				t.Errorf("applyOverrides() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyWeeklyBudget(t *testing.T) {
+	from, to := date("2025-09-01"), date("2025-09-07")
+	week := func(weeklyBudget bool) map[string]Targets {
+		res := map[string]Targets{}
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			res[day.Format(dayLayout)] = Targets{Calories: 2000, Carbs: 200, BaseCalories: 2000, WeeklyBudget: weeklyBudget}
+		}
+		return res
+	}
+	tests := []struct {
+		name         string
+		weeklyBudget bool
+		consumed     map[string]float64
+		calories     float64
+		carbs        float64
+	}{
+		{"no records", true, nil, 2000, 200},
+		{"undereaten monday", true, map[string]float64{"2025-09-01": 1500}, 2083, 221},
+		{"overeaten monday is clamped", true, map[string]float64{"2025-09-01": 6000}, 1500, 75},
+		{"budget disabled", false, map[string]float64{"2025-09-01": 1500}, 2000, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := week(tt.weeklyBudget)
+			applyWeeklyBudget(res, from, to, tt.consumed)
+			if mon := res["2025-09-01"]; mon.Calories != 2000 {
+				t.Errorf("monday calories = %v, want 2000", mon.Calories)
+			}
+			tue := res["2025-09-02"]
+			if tue.Calories != tt.calories || tue.Carbs != tt.carbs {
+				t.Errorf("tuesday = %v kcal/%v carbs, want %v/%v", tue.Calories, tue.Carbs, tt.calories, tt.carbs)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+)
+
+// MinutesPerDay — число минут в сутках.
+const MinutesPerDay = 24 * 60
+
+// ClockString форматирует минуты от полуночи как "HH:MM", заворачивая значение в пределы суток.
+func ClockString(minutes float64) string {
+	m := int(math.Round(minutes)) % MinutesPerDay
+	if m < 0 {
+		m += MinutesPerDay
+	}
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
@@ -3,6 +3,8 @@ package lib
 import (
 	"errors"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/charmbracelet/log"
 )
@@ -19,6 +21,11 @@ type conf struct {
     TelegramToken         string // Optional: used to proxy Telegram avatars
     MyURL                 string
     FrontURL              string
+    BodyWorkerHour        int // Optional: hour of the daily body worker run in BodyWorkerLocation
+    BodyWorkerLocation    *time.Location // Optional: timezone of BodyWorkerHour, UTC by default
+    BodyWorkerConcurrency int // Optional: parallel users in the body worker
+    StorageDir            string // Optional: directory of the local blob storage
+    PropagateCorrections  bool // Optional: the product worker applies catalog corrections to diary entries
 }
 
 type contextKeys struct {
@@ -99,5 +106,45 @@ func ParseENV() error {
 		return errors.New("cannot find env TELEGRAM_TOKEN")
 	}
 
+	Config.BodyWorkerHour = 4
+	if env, exist := os.LookupEnv("BODY_WORKER_HOUR"); exist {
+		if v, err := strconv.Atoi(env); err == nil && v >= 0 && v < 24 {
+			Config.BodyWorkerHour = v
+		} else {
+			logger.Warn("invalid env BODY_WORKER_HOUR, using default", "value", env)
+		}
+	}
+
+	Config.BodyWorkerLocation = time.UTC
+	if env, exist := os.LookupEnv("BODY_WORKER_TZ"); exist && env != "" {
+		if loc, err := time.LoadLocation(env); err == nil {
+			Config.BodyWorkerLocation = loc
+		} else {
+			logger.Warn("invalid env BODY_WORKER_TZ, using UTC", "value", env)
+		}
+	}
+
+	Config.BodyWorkerConcurrency = 4
+	if env, exist := os.LookupEnv("BODY_WORKER_CONCURRENCY"); exist {
+		if v, err := strconv.Atoi(env); err == nil && v > 0 {
+			Config.BodyWorkerConcurrency = v
+		} else {
+			logger.Warn("invalid env BODY_WORKER_CONCURRENCY, using default", "value", env)
+		}
+	}
+
+	Config.StorageDir = "data/storage"
+	if env, exist := os.LookupEnv("STORAGE_DIR"); exist && env != "" {
+		Config.StorageDir = env
+	}
+
+	if env, exist := os.LookupEnv("SOURCE_CORRECTIONS_PROPAGATE"); exist && env != "" {
+		if v, err := strconv.ParseBool(env); err == nil {
+			Config.PropagateCorrections = v
+		} else {
+			logger.Warn("invalid env SOURCE_CORRECTIONS_PROPAGATE, reporting only", "value", env)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,370 @@
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jourloy/nutri-backend/internal/auth"
+)
+
+var (
+	logger = log.NewWithOptions(os.Stderr, log.Options{
+		Prefix: "[mtrc]",
+		Level:  log.DebugLevel,
+	})
+)
+
+type Controller struct {
+	service Service
+}
+
+func NewController() *Controller {
+	return &Controller{service: NewService()}
+}
+
+func (c *Controller) RegisterRoutes(router chi.Router) {
+	router.Route("/metric", func(r chi.Router) {
+		r.Get("/presets", c.GetPresets)
+		r.Post("/preset/{key}", c.AddPreset)
+		r.Get("/list", c.GetAll)
+		r.Post("/", c.Create)
+		r.Put("/", c.Update)
+		r.Delete("/{id}", c.Delete)
+		r.Post("/value", c.CreateValue)
+		r.Put("/value", c.UpdateValue)
+		r.Delete("/value/{id}", c.DeleteValue)
+		r.Get("/{id}/values", c.GetValues)
+		r.Get("/{id}/trend", c.GetTrend)
+	})
+
+	logger.Info("╔═════ Metric")
+	logger.Info("║    GET /presets")
+	logger.Info("║   POST /preset/{key}")
+	logger.Info("║    GET /list")
+	logger.Info("║   POST /")
+	logger.Info("║    PUT /")
+	logger.Info("║ DELETE /{id}")
+	logger.Info("║   POST /value")
+	logger.Info("║    PUT /value")
+	logger.Info("║ DELETE /value/{id}")
+	logger.Info("║    GET /{id}/values?from=&to=")
+	logger.Info("║    GET /{id}/trend?from=&to=")
+	logger.Info("╚═════")
+}
+
+func (c *Controller) GetPresets(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.UserFromContext(r.Context()); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(c.service.GetPresets())
+}
+
+func (c *Controller) AddPreset(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := c.service.AddPreset(context.Background(), u.Id, chi.URLParam(r, "key"))
+	if err != nil {
+		logger.Error("Error add preset metric", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) GetAll(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := c.service.GetAll(context.Background(), u.Id)
+	if err != nil {
+		logger.Error("Error get metrics", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) Create(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body MetricCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body.UserId = u.Id
+
+	resp, err := c.service.Create(context.Background(), body)
+	if err != nil {
+		logger.Error("Error create metric", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) Update(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Id        int64    `json:"id"`
+		Name      string   `json:"name"`
+		Unit      string   `json:"unit"`
+		TargetMin *float64 `json:"targetMin"`
+		TargetMax *float64 `json:"targetMax"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m := Metric{Id: body.Id, UserId: u.Id, Name: body.Name, Unit: body.Unit, TargetMin: body.TargetMin, TargetMax: body.TargetMax}
+	resp, err := c.service.Update(context.Background(), m)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Error update metric", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.Delete(context.Background(), id, u.Id); err != nil {
+		logger.Error("Error delete metric", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type valueBody struct {
+	Id         int64   `json:"id"`
+	MetricId   int64   `json:"metricId"`
+	Value      float64 `json:"value"`
+	MeasuredAt string  `json:"measuredAt"` // RFC3339 или YYYY-MM-DDTHH:MM, местное время; по умолчанию сейчас
+	Note       *string `json:"note"`
+}
+
+// parseLocalTime keeps the wall clock of the given time, as sleep sessions do.
+func parseLocalTime(v string) (time.Time, error) {
+	if v == "" {
+		t := time.Now()
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", v)
+}
+
+func (c *Controller) CreateValue(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body valueBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	at, err := parseLocalTime(body.MeasuredAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vc := ValueCreate{MetricId: body.MetricId, UserId: u.Id, Value: body.Value, MeasuredAt: at, Note: body.Note}
+	resp, err := c.service.CreateValue(context.Background(), vc)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Error create metric value", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) UpdateValue(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body valueBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	at, err := parseLocalTime(body.MeasuredAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := Value{Id: body.Id, UserId: u.Id, Value: body.Value, MeasuredAt: at, Note: body.Note}
+	resp, err := c.service.UpdateValue(context.Background(), v)
+	if err != nil {
+		logger.Error("Error update metric value", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) DeleteValue(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.DeleteValue(context.Background(), id, u.Id); err != nil {
+		logger.Error("Error delete metric value", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// dateRange reads optional from/to query days.
+func dateRange(r *http.Request) (from, to *time.Time) {
+	if s := r.URL.Query().Get("from"); s != "" {
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			from = &t
+		}
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			to = &t
+		}
+	}
+	return from, to
+}
+
+func (c *Controller) GetValues(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to := dateRange(r)
+
+	resp, err := c.service.GetValues(context.Background(), id, u.Id, from, to)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Error get metric values", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) GetTrend(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to := dateRange(r)
+
+	resp, err := c.service.GetTrend(context.Background(), id, u.Id, from, to)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Error get metric trend", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,77 @@
+package metric
+
+import (
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/body"
+)
+
+const (
+	ValueDecimal = "decimal"
+	ValueInteger = "integer"
+
+	StatusLow     = "low"
+	StatusInRange = "in_range"
+	StatusHigh    = "high"
+)
+
+type Metric struct {
+	Id        int64     `json:"id" db:"id"`
+	UserId    string    `json:"-" db:"user_id"`
+	PresetKey *string   `json:"presetKey,omitempty" db:"preset_key"`
+	Name      string    `json:"name" db:"name"`
+	Unit      string    `json:"unit" db:"unit"`
+	ValueType string    `json:"valueType" db:"value_type"`
+	TargetMin *float64  `json:"targetMin,omitempty" db:"target_min"`
+	TargetMax *float64  `json:"targetMax,omitempty" db:"target_max"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type MetricCreate struct {
+	UserId    string   `json:"-" db:"user_id"`
+	PresetKey *string  `json:"-" db:"preset_key"`
+	Name      string   `json:"name" db:"name"`
+	Unit      string   `json:"unit" db:"unit"`
+	ValueType string   `json:"valueType" db:"value_type"`
+	TargetMin *float64 `json:"targetMin" db:"target_min"`
+	TargetMax *float64 `json:"targetMax" db:"target_max"`
+}
+
+type Value struct {
+	Id         int64     `json:"id" db:"id"`
+	MetricId   int64     `json:"metricId" db:"metric_id"`
+	UserId     string    `json:"-" db:"user_id"`
+	Value      float64   `json:"value" db:"value"`
+	MeasuredAt time.Time `json:"measuredAt" db:"measured_at"`
+	Note       *string   `json:"note,omitempty" db:"note"`
+	Status     string    `json:"status,omitempty" db:"-"` // low | in_range | high, если задан целевой диапазон
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type ValueCreate struct {
+	MetricId   int64     `db:"metric_id"`
+	UserId     string    `db:"user_id"`
+	Value      float64   `db:"value"`
+	MeasuredAt time.Time `db:"measured_at"`
+	Note       *string   `db:"note"`
+}
+
+// Preset — заранее описанная метрика, которую пользователь может добавить себе.
+type Preset struct {
+	Key       string   `json:"key"`
+	Name      string   `json:"name"`
+	Unit      string   `json:"unit"`
+	ValueType string   `json:"valueType"`
+	TargetMin *float64 `json:"targetMin,omitempty"`
+	TargetMax *float64 `json:"targetMax,omitempty"`
+}
+
+// Trend — дневной ряд метрики (среднее за день) со сглаживанием.
+type Trend struct {
+	Metric *Metric `json:"metric"`
+	*body.Trend
+	Readings   int      `json:"readings"`
+	InRangePct *float64 `json:"inRangePct,omitempty"` // доля измерений в целевом диапазоне
+}
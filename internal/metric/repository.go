@@ -0,0 +1,207 @@
+package metric
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jourloy/nutri-backend/internal/database"
+)
+
+type Repository interface {
+	// Metrics
+	Create(ctx context.Context, mc MetricCreate) (*Metric, error)
+	Update(ctx context.Context, m Metric) (*Metric, error)
+	Delete(ctx context.Context, id int64, userId string) error
+	GetById(ctx context.Context, id int64, userId string) (*Metric, error)
+	GetAll(ctx context.Context, userId string) ([]Metric, error)
+
+	// Values
+	CreateValue(ctx context.Context, vc ValueCreate) (*Value, error)
+	UpdateValue(ctx context.Context, v Value) (*Value, error)
+	DeleteValue(ctx context.Context, id int64, userId string) error
+	GetValueById(ctx context.Context, id int64, userId string) (*Value, error)
+	GetValues(ctx context.Context, metricId int64, userId string, from, to *time.Time) ([]Value, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository() Repository {
+	return &repository{db: database.Database}
+}
+
+const (
+	metricColumns = `id, user_id, preset_key, name, unit, value_type, target_min, target_max, created_at, updated_at`
+	valueColumns  = `id, metric_id, user_id, value, measured_at, note, created_at, updated_at`
+)
+
+func (r *repository) Create(ctx context.Context, mc MetricCreate) (*Metric, error) {
+	const q = `
+	INSERT INTO health_metrics (user_id, preset_key, name, unit, value_type, target_min, target_max)
+	VALUES (:user_id, :preset_key, :name, :unit, :value_type, :target_min, :target_max)
+	RETURNING ` + metricColumns + `;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, mc)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var out Metric
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	}
+	return nil, nil
+}
+
+// Update changes the name, unit and target range; the value type is fixed once values exist.
+func (r *repository) Update(ctx context.Context, m Metric) (*Metric, error) {
+	const q = `
+	UPDATE health_metrics
+	SET name = :name, unit = :unit, target_min = :target_min, target_max = :target_max, updated_at = NOW()
+	WHERE id = :id AND user_id = :user_id
+	RETURNING ` + metricColumns + `;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, m)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out Metric
+	if rows.Next() {
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
+
+// Delete removes the metric together with its values.
+func (r *repository) Delete(ctx context.Context, id int64, userId string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM health_metric_values WHERE metric_id = $1 AND user_id = $2`, id, userId); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM health_metrics WHERE id = $1 AND user_id = $2`, id, userId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *repository) GetById(ctx context.Context, id int64, userId string) (*Metric, error) {
+	var m Metric
+	err := r.db.GetContext(ctx, &m, `SELECT `+metricColumns+` FROM health_metrics WHERE id = $1 AND user_id = $2`, id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repository) GetAll(ctx context.Context, userId string) ([]Metric, error) {
+	res := []Metric{}
+	err := r.db.SelectContext(ctx, &res, `SELECT `+metricColumns+` FROM health_metrics WHERE user_id = $1 ORDER BY created_at, id`, userId)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *repository) CreateValue(ctx context.Context, vc ValueCreate) (*Value, error) {
+	const q = `
+	INSERT INTO health_metric_values (metric_id, user_id, value, measured_at, note)
+	VALUES (:metric_id, :user_id, :value, :measured_at, :note)
+	RETURNING ` + valueColumns + `;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, vc)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var out Value
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	}
+	return nil, nil
+}
+
+func (r *repository) UpdateValue(ctx context.Context, v Value) (*Value, error) {
+	const q = `
+	UPDATE health_metric_values
+	SET value = :value, measured_at = :measured_at, note = :note, updated_at = NOW()
+	WHERE id = :id AND user_id = :user_id
+	RETURNING ` + valueColumns + `;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, v)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out Value
+	if rows.Next() {
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
+
+func (r *repository) DeleteValue(ctx context.Context, id int64, userId string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM health_metric_values WHERE id = $1 AND user_id = $2`, id, userId)
+	return err
+}
+
+func (r *repository) GetValueById(ctx context.Context, id int64, userId string) (*Value, error) {
+	var v Value
+	err := r.db.GetContext(ctx, &v, `SELECT `+valueColumns+` FROM health_metric_values WHERE id = $1 AND user_id = $2`, id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetValues returns values of the metric; from and to are days, to is inclusive.
+func (r *repository) GetValues(ctx context.Context, metricId int64, userId string, from, to *time.Time) ([]Value, error) {
+	q := `SELECT ` + valueColumns + ` FROM health_metric_values WHERE metric_id = $1 AND user_id = $2`
+	args := []any{metricId, userId}
+	if from != nil {
+		q += fmt.Sprintf(" AND measured_at >= $%d", len(args)+1)
+		args = append(args, *from)
+	}
+	if to != nil {
+		q += fmt.Sprintf(" AND measured_at < $%d", len(args)+1)
+		args = append(args, to.AddDate(0, 0, 1))
+	}
+	q += ` ORDER BY measured_at, id`
+
+	res := []Value{}
+	if err := r.db.SelectContext(ctx, &res, q, args...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,283 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/body"
+	"github.com/jourloy/nutri-backend/internal/database"
+)
+
+const (
+	maxNameLen        = 64
+	trendDefaultRange = 90
+)
+
+var ErrNotFound = errors.New("metric not found")
+
+func ptr(v float64) *float64 { return &v }
+
+// presets — часто отслеживаемые показатели с общепринятыми целевыми диапазонами для взрослых;
+// пользователь может изменить диапазон после добавления.
+var presets = []Preset{
+	{Key: "bp_systolic", Name: "Blood pressure (systolic)", Unit: "mmHg", ValueType: ValueInteger, TargetMin: ptr(90), TargetMax: ptr(120)},
+	{Key: "bp_diastolic", Name: "Blood pressure (diastolic)", Unit: "mmHg", ValueType: ValueInteger, TargetMin: ptr(60), TargetMax: ptr(80)},
+	{Key: "glucose", Name: "Blood glucose", Unit: "mmol/L", ValueType: ValueDecimal, TargetMin: ptr(3.9), TargetMax: ptr(7.8)},
+	{Key: "resting_hr", Name: "Resting heart rate", Unit: "bpm", ValueType: ValueInteger, TargetMin: ptr(50), TargetMax: ptr(90)},
+}
+
+type Service interface {
+	GetPresets() []Preset
+	AddPreset(ctx context.Context, userId, key string) (*Metric, error)
+
+	Create(ctx context.Context, mc MetricCreate) (*Metric, error)
+	Update(ctx context.Context, m Metric) (*Metric, error)
+	Delete(ctx context.Context, id int64, userId string) error
+	GetAll(ctx context.Context, userId string) ([]Metric, error)
+
+	CreateValue(ctx context.Context, vc ValueCreate) (*Value, error)
+	UpdateValue(ctx context.Context, v Value) (*Value, error)
+	DeleteValue(ctx context.Context, id int64, userId string) error
+	GetValues(ctx context.Context, metricId int64, userId string, from, to *time.Time) ([]Value, error)
+	GetTrend(ctx context.Context, metricId int64, userId string, from, to *time.Time) (*Trend, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService() Service {
+	return &service{repo: NewRepository()}
+}
+
+func (s *service) GetPresets() []Preset {
+	return presets
+}
+
+// AddPreset creates the user's own copy of a preset metric.
+func (s *service) AddPreset(ctx context.Context, userId, key string) (*Metric, error) {
+	for _, p := range presets {
+		if p.Key != key {
+			continue
+		}
+		list, err := s.repo.GetAll(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range list {
+			if m.PresetKey != nil && *m.PresetKey == key {
+				return nil, errors.New("metric is already added")
+			}
+		}
+		return s.repo.Create(ctx, MetricCreate{UserId: userId, PresetKey: &p.Key, Name: p.Name, Unit: p.Unit,
+			ValueType: p.ValueType, TargetMin: p.TargetMin, TargetMax: p.TargetMax})
+	}
+	return nil, errors.New("unknown preset")
+}
+
+func (s *service) Create(ctx context.Context, mc MetricCreate) (*Metric, error) {
+	mc.Name, mc.Unit = strings.TrimSpace(mc.Name), strings.TrimSpace(mc.Unit)
+	if mc.ValueType == "" {
+		mc.ValueType = ValueDecimal
+	}
+	if mc.ValueType != ValueDecimal && mc.ValueType != ValueInteger {
+		return nil, errors.New("valueType must be decimal or integer")
+	}
+	if err := validateMetric(mc.Name, mc.TargetMin, mc.TargetMax); err != nil {
+		return nil, err
+	}
+	mc.PresetKey = nil
+	return s.repo.Create(ctx, mc)
+}
+
+func (s *service) Update(ctx context.Context, m Metric) (*Metric, error) {
+	m.Name, m.Unit = strings.TrimSpace(m.Name), strings.TrimSpace(m.Unit)
+	if err := validateMetric(m.Name, m.TargetMin, m.TargetMax); err != nil {
+		return nil, err
+	}
+	old, err := s.repo.GetById(ctx, m.Id, m.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, ErrNotFound
+	}
+	return s.repo.Update(ctx, m)
+}
+
+func validateMetric(name string, min, max *float64) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len([]rune(name)) > maxNameLen {
+		return errors.New("name is too long")
+	}
+	if min != nil && max != nil && *min > *max {
+		return errors.New("targetMin must not exceed targetMax")
+	}
+	return nil
+}
+
+func (s *service) Delete(ctx context.Context, id int64, userId string) error {
+	return s.repo.Delete(ctx, id, userId)
+}
+
+func (s *service) GetAll(ctx context.Context, userId string) ([]Metric, error) {
+	return s.repo.GetAll(ctx, userId)
+}
+
+func (s *service) CreateValue(ctx context.Context, vc ValueCreate) (*Value, error) {
+	m, err := s.repo.GetById(ctx, vc.MetricId, vc.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotFound
+	}
+	if err := validateValue(m, vc.Value); err != nil {
+		return nil, err
+	}
+	v, err := s.repo.CreateValue(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	return withStatus(v, m), nil
+}
+
+func (s *service) UpdateValue(ctx context.Context, v Value) (*Value, error) {
+	old, err := s.repo.GetValueById(ctx, v.Id, v.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, errors.New("value not found")
+	}
+	m, err := s.repo.GetById(ctx, old.MetricId, v.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotFound
+	}
+	if err := validateValue(m, v.Value); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.UpdateValue(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return withStatus(res, m), nil
+}
+
+func validateValue(m *Metric, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return errors.New("value must be a number")
+	}
+	if m.ValueType == ValueInteger && v != math.Trunc(v) {
+		return errors.New("value must be an integer")
+	}
+	return nil
+}
+
+func (s *service) DeleteValue(ctx context.Context, id int64, userId string) error {
+	return s.repo.DeleteValue(ctx, id, userId)
+}
+
+func (s *service) GetValues(ctx context.Context, metricId int64, userId string, from, to *time.Time) ([]Value, error) {
+	m, err := s.repo.GetById(ctx, metricId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotFound
+	}
+	list, err := s.repo.GetValues(ctx, metricId, userId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		withStatus(&list[i], m)
+	}
+	return list, nil
+}
+
+// GetTrend averages the readings of each day and builds the same daily trend as for weight.
+func (s *service) GetTrend(ctx context.Context, metricId int64, userId string, from, to *time.Time) (*Trend, error) {
+	m, err := s.repo.GetById(ctx, metricId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotFound
+	}
+	end, err := database.UserToday(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if to != nil {
+		end = *to
+	}
+	start := end.AddDate(0, 0, -trendDefaultRange+1)
+	if from != nil {
+		start = *from
+	}
+	if end.Sub(start) >= body.TrendMaxRange*24*time.Hour {
+		return nil, body.ErrTrendRange
+	}
+	list, err := s.repo.GetValues(ctx, metricId, userId, &start, &end)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []body.DatedValue
+	var counts []int
+	var inRange, withTarget int
+	for _, v := range list {
+		day := time.Date(v.MeasuredAt.Year(), v.MeasuredAt.Month(), v.MeasuredAt.Day(), 0, 0, 0, 0, time.UTC)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(day) {
+			days = append(days, body.DatedValue{Date: day})
+			counts = append(counts, 0)
+		}
+		days[len(days)-1].Value += v.Value
+		counts[len(counts)-1]++
+		if st := status(m, v.Value); st != "" {
+			withTarget++
+			if st == StatusInRange {
+				inRange++
+			}
+		}
+	}
+	for i := range days {
+		days[i].Value /= float64(counts[i])
+	}
+
+	res := &Trend{Metric: m, Trend: body.DailyTrend(days, start, end), Readings: len(list)}
+	if withTarget > 0 {
+		pct := math.Round(float64(inRange)/float64(withTarget)*1000) / 10
+		res.InRangePct = &pct
+	}
+	return res, nil
+}
+
+func withStatus(v *Value, m *Metric) *Value {
+	if v != nil {
+		v.Status = status(m, v.Value)
+	}
+	return v
+}
+
+// status compares a value with the metric's target range ("" — no range set).
+func status(m *Metric, v float64) string {
+	switch {
+	case m.TargetMin == nil && m.TargetMax == nil:
+		return ""
+	case m.TargetMin != nil && v < *m.TargetMin:
+		return StatusLow
+	case m.TargetMax != nil && v > *m.TargetMax:
+		return StatusHigh
+	}
+	return StatusInRange
+}
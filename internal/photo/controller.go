@@ -0,0 +1,232 @@
+package photo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jourloy/nutri-backend/internal/auth"
+)
+
+var (
+	logger = log.NewWithOptions(os.Stderr, log.Options{
+		Prefix: "[phto]",
+		Level:  log.DebugLevel,
+	})
+)
+
+type Controller struct {
+	service Service
+}
+
+func NewController() *Controller {
+	return &Controller{service: NewService()}
+}
+
+func (c *Controller) RegisterRoutes(router chi.Router) {
+	router.Route("/photo", func(r chi.Router) {
+		r.Post("/", c.Upload)
+		r.Get("/list", c.GetAll)
+		r.Get("/compare", c.Compare)
+		r.Get("/{id}/image", c.GetImage)
+		r.Get("/{id}/thumb", c.GetThumb)
+		r.Delete("/{id}", c.Delete)
+	})
+
+	logger.Info("╔═════ Photo")
+	logger.Info("║   POST / (multipart: file, pose, takenAt, note)")
+	logger.Info("║    GET /list?pose=&from=&to=")
+	logger.Info("║    GET /compare?before=&after=")
+	logger.Info("║    GET /{id}/image")
+	logger.Info("║    GET /{id}/thumb")
+	logger.Info("║ DELETE /{id}")
+	logger.Info("╚═════")
+}
+
+func (c *Controller) Upload(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadBytes+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxUploadBytes+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) > MaxUploadBytes {
+		http.Error(w, "file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	pc := PhotoCreate{UserId: u.Id, Pose: r.FormValue("pose"), TakenAt: time.Now()}
+	if v := r.FormValue("takenAt"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "takenAt must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		pc.TakenAt = t
+	}
+	if v := r.FormValue("note"); v != "" {
+		pc.Note = &v
+	}
+
+	resp, err := c.service.Upload(context.Background(), pc, data)
+	if err != nil {
+		logger.Error("Error upload photo", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) GetAll(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var from, to *time.Time
+	if s := r.URL.Query().Get("from"); s != "" {
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			from = &t
+		}
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			to = &t
+		}
+	}
+
+	resp, err := c.service.GetAll(context.Background(), u.Id, r.URL.Query().Get("pose"), from, to)
+	if err != nil {
+		logger.Error("Error get photos", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// Compare — пары снимков по позам; по умолчанию самый ранний против самого свежего.
+func (c *Controller) Compare(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	before, after := time.Time{}, time.Now()
+	if s := r.URL.Query().Get("before"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			http.Error(w, "before must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		before = t
+	}
+	if s := r.URL.Query().Get("after"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			http.Error(w, "after must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		after = t
+	}
+
+	resp, err := c.service.Compare(context.Background(), u.Id, before, after)
+	if err != nil {
+		logger.Error("Error compare photos", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) GetImage(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, false)
+}
+
+func (c *Controller) GetThumb(w http.ResponseWriter, r *http.Request) {
+	c.serveFile(w, r, true)
+}
+
+func (c *Controller) serveFile(w http.ResponseWriter, r *http.Request, thumb bool) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rc, err := c.service.Open(r.Context(), id, u.Id, thumb)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Error open photo", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	// личные фото: не кешировать на общих прокси
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, rc)
+}
+
+func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.Delete(context.Background(), id, u.Id); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,47 @@
+package photo
+
+import "time"
+
+const (
+	PoseFront = "front"
+	PoseSide  = "side"
+	PoseBack  = "back"
+)
+
+var Poses = []string{PoseFront, PoseSide, PoseBack}
+
+type Photo struct {
+	Id        int64     `json:"id" db:"id"`
+	UserId    string    `json:"-" db:"user_id"`
+	Pose      string    `json:"pose" db:"pose"`
+	TakenAt   time.Time `json:"takenAt" db:"taken_at"`
+	Note      *string   `json:"note,omitempty" db:"note"`
+	Width     int       `json:"width" db:"width"`
+	Height    int       `json:"height" db:"height"`
+	SizeBytes int64     `json:"sizeBytes" db:"size_bytes"`
+	ImageKey  string    `json:"-" db:"image_key"`
+	ThumbKey  string    `json:"-" db:"thumb_key"`
+	ImageURL  string    `json:"imageUrl" db:"-"`
+	ThumbURL  string    `json:"thumbUrl" db:"-"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type PhotoCreate struct {
+	UserId    string    `db:"user_id"`
+	Pose      string    `db:"pose"`
+	TakenAt   time.Time `db:"taken_at"`
+	Note      *string   `db:"note"`
+	Width     int       `db:"width"`
+	Height    int       `db:"height"`
+	SizeBytes int64     `db:"size_bytes"`
+	ImageKey  string    `db:"image_key"`
+	ThumbKey  string    `db:"thumb_key"`
+}
+
+// ComparePair — фото одной позы на две даты для сравнения «до/после».
+type ComparePair struct {
+	Pose   string `json:"pose"`
+	Before *Photo `json:"before,omitempty"`
+	After  *Photo `json:"after,omitempty"`
+	Days   *int   `json:"days,omitempty"` // дней между снимками
+}
@@ -0,0 +1,112 @@
+package photo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/jourloy/nutri-backend/internal/database"
+)
+
+type Repository interface {
+	Create(ctx context.Context, p PhotoCreate) (*Photo, error)
+	Delete(ctx context.Context, id int64, userId string) error
+	GetById(ctx context.Context, id int64, userId string) (*Photo, error)
+	GetAll(ctx context.Context, userId string, pose string, from, to *time.Time) ([]Photo, error)
+	GetNearest(ctx context.Context, userId, pose string, day time.Time) (*Photo, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository() Repository {
+	return &repository{db: database.Database}
+}
+
+const photoColumns = `id, user_id, pose, taken_at, note, width, height, size_bytes, image_key, thumb_key, created_at`
+
+func (r *repository) Create(ctx context.Context, p PhotoCreate) (*Photo, error) {
+	const q = `
+	INSERT INTO progress_photos (user_id, pose, taken_at, note, width, height, size_bytes, image_key, thumb_key)
+	VALUES (:user_id, :pose, :taken_at, :note, :width, :height, :size_bytes, :image_key, :thumb_key)
+	RETURNING ` + photoColumns + `;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, p)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var out Photo
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	}
+	return nil, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64, userId string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM progress_photos WHERE id = $1 AND user_id = $2`, id, userId)
+	return err
+}
+
+func (r *repository) GetById(ctx context.Context, id int64, userId string) (*Photo, error) {
+	var p Photo
+	err := r.db.GetContext(ctx, &p, `SELECT `+photoColumns+` FROM progress_photos WHERE id = $1 AND user_id = $2`, id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repository) GetAll(ctx context.Context, userId string, pose string, from, to *time.Time) ([]Photo, error) {
+	q := `SELECT ` + photoColumns + ` FROM progress_photos WHERE user_id = $1`
+	args := []any{userId}
+	if pose != "" {
+		q += fmt.Sprintf(" AND pose = $%d", len(args)+1)
+		args = append(args, pose)
+	}
+	if from != nil {
+		q += fmt.Sprintf(" AND taken_at >= $%d", len(args)+1)
+		args = append(args, *from)
+	}
+	if to != nil {
+		q += fmt.Sprintf(" AND taken_at <= $%d", len(args)+1)
+		args = append(args, *to)
+	}
+	q += ` ORDER BY taken_at, id`
+
+	res := []Photo{}
+	if err := r.db.SelectContext(ctx, &res, q, args...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetNearest returns the photo of the pose closest to the day (the later one on ties).
+func (r *repository) GetNearest(ctx context.Context, userId, pose string, day time.Time) (*Photo, error) {
+	var p Photo
+	err := r.db.GetContext(ctx, &p, `
+	SELECT `+photoColumns+`
+	FROM progress_photos
+	WHERE user_id = $1 AND pose = $2
+	ORDER BY ABS(taken_at - $3::date), taken_at DESC, id DESC
+	LIMIT 1`, userId, pose, day)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,165 @@
+package photo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/jourloy/nutri-backend/internal/storage"
+)
+
+var ErrNotFound = errors.New("photo not found")
+
+type Service interface {
+	Upload(ctx context.Context, pc PhotoCreate, data []byte) (*Photo, error)
+	Delete(ctx context.Context, id int64, userId string) error
+	GetAll(ctx context.Context, userId string, pose string, from, to *time.Time) ([]Photo, error)
+	Open(ctx context.Context, id int64, userId string, thumb bool) (io.ReadCloser, error)
+	Compare(ctx context.Context, userId string, before, after time.Time) ([]ComparePair, error)
+}
+
+type service struct {
+	repo  Repository
+	blobs storage.Storage
+}
+
+func NewService() Service {
+	return &service{repo: NewRepository(), blobs: storage.Default}
+}
+
+// Upload очищает фото от метаданных, сохраняет его с миниатюрой и создаёт запись.
+func (s *service) Upload(ctx context.Context, pc PhotoCreate, data []byte) (*Photo, error) {
+	if !slices.Contains(Poses, pc.Pose) {
+		return nil, errors.New("pose must be front, side or back")
+	}
+	img, err := process(data)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	pc.ImageKey = fmt.Sprintf("photos/%s/%s.jpg", pc.UserId, name)
+	pc.ThumbKey = fmt.Sprintf("photos/%s/%s_thumb.jpg", pc.UserId, name)
+	pc.Width, pc.Height, pc.SizeBytes = img.width, img.height, int64(len(img.image))
+
+	if err := s.blobs.Put(ctx, pc.ImageKey, bytes.NewReader(img.image)); err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(ctx, pc.ThumbKey, bytes.NewReader(img.thumb)); err != nil {
+		s.removeBlobs(ctx, pc.ImageKey)
+		return nil, err
+	}
+
+	p, err := s.repo.Create(ctx, pc)
+	if err != nil {
+		s.removeBlobs(ctx, pc.ImageKey, pc.ThumbKey)
+		return nil, err
+	}
+	return withURLs(p), nil
+}
+
+func (s *service) Delete(ctx context.Context, id int64, userId string) error {
+	p, err := s.repo.GetById(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrNotFound
+	}
+	if err := s.repo.Delete(ctx, id, userId); err != nil {
+		return err
+	}
+	s.removeBlobs(ctx, p.ImageKey, p.ThumbKey)
+	return nil
+}
+
+func (s *service) GetAll(ctx context.Context, userId string, pose string, from, to *time.Time) ([]Photo, error) {
+	list, err := s.repo.GetAll(ctx, userId, pose, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		withURLs(&list[i])
+	}
+	return list, nil
+}
+
+// Open отдаёт файл фото только его владельцу.
+func (s *service) Open(ctx context.Context, id int64, userId string, thumb bool) (io.ReadCloser, error) {
+	p, err := s.repo.GetById(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotFound
+	}
+	key := p.ImageKey
+	if thumb {
+		key = p.ThumbKey
+	}
+	rc, err := s.blobs.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return rc, err
+}
+
+// Compare подбирает для каждой позы снимки, ближайшие к датам «до» и «после».
+func (s *service) Compare(ctx context.Context, userId string, before, after time.Time) ([]ComparePair, error) {
+	res := make([]ComparePair, 0, len(Poses))
+	for _, pose := range Poses {
+		pair := ComparePair{Pose: pose}
+		b, err := s.repo.GetNearest(ctx, userId, pose, before)
+		if err != nil {
+			return nil, err
+		}
+		a, err := s.repo.GetNearest(ctx, userId, pose, after)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			pair.Before = withURLs(b)
+		}
+		// один и тот же снимок не сравниваем сам с собой
+		if a != nil && (b == nil || a.Id != b.Id) {
+			pair.After = withURLs(a)
+		}
+		if pair.Before != nil && pair.After != nil {
+			days := int(pair.After.TakenAt.Sub(pair.Before.TakenAt).Hours() / 24)
+			pair.Days = &days
+		}
+		res = append(res, pair)
+	}
+	return res, nil
+}
+
+func (s *service) removeBlobs(ctx context.Context, keys ...string) {
+	for _, k := range keys {
+		if err := s.blobs.Delete(ctx, k); err != nil {
+			logger.Warn("delete blob", "key", k, "err", err)
+		}
+	}
+}
+
+func withURLs(p *Photo) *Photo {
+	p.ImageURL = fmt.Sprintf("/photo/%d/image", p.Id)
+	p.ThumbURL = fmt.Sprintf("/photo/%d/thumb", p.Id)
+	return p
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
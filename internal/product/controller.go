@@ -39,6 +39,10 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
 		r.Get("/all", c.GetAll)
 		r.Get("/today", c.GetAllByToday)
 		r.Get("/search", c.Search)
+
+		// Admin: catalog corrections
+		r.Get("/source/corrections", c.GetSourceCorrections)
+		r.Post("/source/corrections/propagate", c.PropagateSourceCorrections)
 	})
 
 	logger.Info("╔═════ Product")
@@ -48,6 +52,8 @@ func (c *Controller) RegisterRoutes(router chi.Router) {
 	logger.Info("║    GET /all")
 	logger.Info("║    GET /today")
 	logger.Info("║    GET /search?name=")
+	logger.Info("║    GET /source/corrections")
+	logger.Info("║   POST /source/corrections/propagate")
 	logger.Info("╚═════")
 }
 
@@ -200,3 +206,59 @@ func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (c *Controller) GetSourceCorrections(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	resp, err := c.service.GetSourceCorrections(context.Background())
+	if err != nil {
+		logger.Error("Error get source corrections", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) PropagateSourceCorrections(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Пустое тело — применить исправления всех шаблонов
+	var body struct {
+		TemplateId *int64 `json:"templateId"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	updated, err := c.service.PropagateSourceCorrections(context.Background(), body.TemplateId)
+	if err != nil {
+		logger.Error("Error propagate source corrections", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("Catalog corrections propagated", "template", body.TemplateId, "updated", updated)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"updated": updated})
+}
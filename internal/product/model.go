@@ -16,6 +16,9 @@ type Product struct {
 	BasicFat      float64   `json:"basicFat" db:"basic_fat"`
 	BasicCarbs    float64   `json:"basicCarbs" db:"basic_carbs"`
 	IsWater       bool      `json:"isWater" db:"is_water"`
+	TemplateId    *int64    `json:"templateId,omitempty" db:"template_id"`
+	SourceId      *int64    `json:"sourceProductId,omitempty" db:"source_product_id"`
+	IsDiverged    bool      `json:"isDiverged" db:"is_diverged"`
 	UserId        string    `json:"-" db:"user_id"`
 	FitId         string    `json:"-" db:"fit_id"`
 	CreatedAt     time.Time `json:"-" db:"created_at"`
@@ -35,6 +38,22 @@ type ProductCreate struct {
 	BasicFat      float64 `json:"basicFat" db:"basic_fat"`
 	BasicCarbs    float64 `json:"basicCarbs" db:"basic_carbs"`
 	IsWater       bool    `json:"isWater" db:"is_water"`
+	TemplateId    *int64  `json:"templateId" db:"template_id"`
+	SourceId      *int64  `json:"sourceProductId" db:"source_product_id"`
+	IsDiverged    bool    `json:"-" db:"is_diverged"`
 	UserId        string  `json:"-" db:"user_id"`
 	FitId         string  `json:"-" db:"fit_id"`
 }
+
+// SourceCorrection describes catalog changes not yet applied to diary entries
+// that were logged from a template.
+type SourceCorrection struct {
+	TemplateId      int64   `json:"templateId" db:"template_id"`
+	TemplateName    string  `json:"templateName" db:"template_name"`
+	AffectedEntries int64   `json:"affectedEntries" db:"affected_entries"`
+	AffectedUsers   int64   `json:"affectedUsers" db:"affected_users"`
+	Calories        float64 `json:"calories" db:"calories"`
+	Protein         float64 `json:"protein" db:"protein"`
+	Fat             float64 `json:"fat" db:"fat"`
+	Carbs           float64 `json:"carbs" db:"carbs"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 
@@ -17,8 +18,13 @@ type Repository interface {
 	GetCount(ctx context.Context, fid string, uid string) (int, error)
 	GetCountByToday(ctx context.Context, fid string, uid string) (int, error)
 	GetLikeName(ctx context.Context, name string, fid string, uid string) ([]Product, error)
+	GetById(ctx context.Context, pid int64, uid string) (*Product, error)
 	UpdateProduct(ctx context.Context, pu Product, fid string, uid string) (*Product, error)
 	DeleteProduct(ctx context.Context, pid int64, fid string, uid string) error
+
+	// Catalog corrections
+	GetSourceCorrections(ctx context.Context) ([]SourceCorrection, error)
+	PropagateSourceCorrections(ctx context.Context, templateId *int64) (int64, error)
 }
 
 type repository struct {
@@ -34,16 +40,19 @@ func (r *repository) CreateProduct(ctx context.Context, pc ProductCreate) (*Prod
 	INSERT INTO products (
 		name, amount, unit, calories, protein, fat, carbs,
 		basic_calories, basic_protein, basic_fat, basic_carbs,
-		is_water, user_id, fit_id
+		is_water, template_id, source_product_id, is_diverged,
+		user_id, fit_id
 	) VALUES (
 		:name, :amount, :unit, :calories, :protein, :fat, :carbs,
 		:basic_calories, :basic_protein, :basic_fat, :basic_carbs,
-		:is_water, :user_id, :fit_id
+		:is_water, :template_id, :source_product_id, :is_diverged,
+		:user_id, :fit_id
 	)
 	RETURNING
 		id, name, amount, unit, calories, protein, fat, carbs,
 		basic_calories, basic_protein, basic_fat, basic_carbs,
-		is_water, user_id, fit_id, created_at, updated_at;`
+		is_water, template_id, source_product_id, is_diverged,
+		user_id, fit_id, created_at, updated_at;`
 
 	rows, err := r.db.NamedQueryContext(ctx, q, pc)
 	if err != nil {
@@ -66,7 +75,8 @@ func (r *repository) GetAll(ctx context.Context, fid, uid string) ([]Product, er
 	SELECT
 		id, name, amount, unit, calories, protein, fat, carbs,
 		basic_calories, basic_protein, basic_fat, basic_carbs,
-		is_water, user_id, fit_id, created_at, updated_at
+		is_water, template_id, source_product_id, is_diverged,
+		user_id, fit_id, created_at, updated_at
 	FROM products
 	WHERE user_id = $1 AND fit_id = $2
 	ORDER BY created_at DESC`
@@ -79,14 +89,18 @@ func (r *repository) GetAll(ctx context.Context, fid, uid string) ([]Product, er
 	return ps, nil
 }
 
+// todayCondition — запись сделана сегодня по часовому поясу пользователя ($1).
+var todayCondition = database.LocalDays("created_at", database.LocalToday(database.UserTimezone("$1")), database.LocalToday(database.UserTimezone("$1")), database.UserTimezone("$1"))
+
 func (r *repository) GetAllByToday(ctx context.Context, fid string, uid string) ([]Product, error) {
-	const q = `
+	q := `
 	SELECT
 		id, name, amount, unit, calories, protein, fat, carbs,
 		basic_calories, basic_protein, basic_fat, basic_carbs,
-		is_water, user_id, fit_id, created_at, updated_at
+		is_water, template_id, source_product_id, is_diverged,
+		user_id, fit_id, created_at, updated_at
 	FROM products
-	WHERE user_id = $1 AND fit_id = $2 AND created_at >= CURRENT_DATE AND created_at < CURRENT_DATE + INTERVAL '1 day'
+	WHERE user_id = $1 AND fit_id = $2 AND ` + todayCondition + `
 	ORDER BY created_at DESC`
 
 	var ps []Product
@@ -111,9 +125,9 @@ func (r *repository) GetCount(ctx context.Context, fid, uid string) (int, error)
 }
 
 func (r *repository) GetCountByToday(ctx context.Context, fid, uid string) (int, error) {
-	const q = `
+	q := `
 	SELECT COUNT(*) FROM products
-	WHERE user_id = $1 AND fit_id = $2 AND created_at >= CURRENT_DATE AND created_at < CURRENT_DATE + INTERVAL '1 day'`
+	WHERE user_id = $1 AND fit_id = $2 AND ` + todayCondition
 
 	var count int
 	if err := r.db.GetContext(ctx, &count, q, uid, fid); err != nil {
@@ -129,7 +143,8 @@ func (r *repository) GetLikeName(ctx context.Context, name, fid, uid string) ([]
 	SELECT DISTINCT ON (p.name)
 		p.id, p.name, p.amount, p.unit, p.calories, p.protein, p.fat, p.carbs,
 		p.basic_calories, p.basic_protein, p.basic_fat, p.basic_carbs,
-		p.is_water, p.user_id, p.fit_id, p.created_at, p.updated_at
+		p.is_water, p.template_id, p.source_product_id, p.is_diverged,
+		p.user_id, p.fit_id, p.created_at, p.updated_at
 	FROM products p
 	WHERE p.name ILIKE $1 AND p.user_id = $2 AND p.fit_id = $3 AND basic_calories != 0
 	ORDER BY p.name, p.created_at DESC
@@ -142,6 +157,26 @@ func (r *repository) GetLikeName(ctx context.Context, name, fid, uid string) ([]
 	return res, nil
 }
 
+func (r *repository) GetById(ctx context.Context, pid int64, uid string) (*Product, error) {
+	const q = `
+	SELECT
+		id, name, amount, unit, calories, protein, fat, carbs,
+		basic_calories, basic_protein, basic_fat, basic_carbs,
+		is_water, template_id, source_product_id, is_diverged,
+		user_id, fit_id, created_at, updated_at
+	FROM products
+	WHERE id = $1 AND user_id = $2`
+
+	var p Product
+	if err := r.db.GetContext(ctx, &p, q, pid, uid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
 func (r *repository) UpdateProduct(ctx context.Context, pu Product, fid, uid string) (*Product, error) {
 	const q = `
 	UPDATE products
@@ -153,17 +188,20 @@ func (r *repository) UpdateProduct(ctx context.Context, pu Product, fid, uid str
 		protein = :protein,
 		fat = :fat,
 		carbs = :carbs,
+		is_diverged = :is_diverged,
 		updated_at = now()
 	WHERE id = :id AND fit_id = :fit_id AND user_id = :user_id
 	RETURNING
 		id, name, amount, unit, calories, protein, fat, carbs,
 		basic_calories, basic_protein, basic_fat, basic_carbs,
-		is_water, user_id, fit_id, created_at, updated_at;`
+		is_water, template_id, source_product_id, is_diverged,
+		user_id, fit_id, created_at, updated_at;`
 
 	args := map[string]any{
 		"id": pu.Id, "fit_id": fid, "user_id": uid,
 		"name": pu.Name, "amount": pu.Amount, "unit": pu.Unit,
 		"calories": pu.Calories, "protein": pu.Protein, "fat": pu.Fat, "carbs": pu.Carbs,
+		"is_diverged": pu.IsDiverged,
 	}
 
 	rows, err := r.db.NamedQueryContext(ctx, q, args)
@@ -197,3 +235,56 @@ func (r *repository) DeleteProduct(ctx context.Context, pid int64, fid, uid stri
 	}
 	return nil
 }
+
+// sourceDriftCondition — запись из каталога, не изменённая пользователем,
+// чьи значения на 100г отличаются от текущих значений шаблона больше, чем на macroEpsilon.
+var sourceDriftCondition = fmt.Sprintf(`
+	p.template_id = t.id AND p.is_diverged = FALSE AND (
+		abs(p.basic_calories - t.calories) > %[1]g OR abs(p.basic_protein - t.protein) > %[1]g OR
+		abs(p.basic_fat - t.fat) > %[1]g OR abs(p.basic_carbs - t.carbs) > %[1]g
+	)`, macroEpsilon)
+
+func (r *repository) GetSourceCorrections(ctx context.Context) ([]SourceCorrection, error) {
+	q := `
+	SELECT
+		t.id AS template_id, t.name AS template_name,
+		COUNT(*) AS affected_entries, COUNT(DISTINCT p.user_id) AS affected_users,
+		t.calories::float AS calories, t.protein::float AS protein,
+		t.fat::float AS fat, t.carbs::float AS carbs
+	FROM products p
+	JOIN templates t ON ` + sourceDriftCondition + `
+	GROUP BY t.id, t.name, t.calories, t.protein, t.fat, t.carbs
+	ORDER BY affected_entries DESC`
+
+	var res []SourceCorrection
+	if err := r.db.SelectContext(ctx, &res, q); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PropagateSourceCorrections переносит исправления каталога в записи дневника.
+// Итоговые значения масштабируются пропорционально, поэтому единица измерения не важна.
+func (r *repository) PropagateSourceCorrections(ctx context.Context, templateId *int64) (int64, error) {
+	q := `
+	UPDATE products p
+	SET
+		calories = CASE WHEN p.basic_calories <> 0 THEN p.calories * t.calories / p.basic_calories ELSE t.calories * p.amount / 100 END,
+		protein = CASE WHEN p.basic_protein <> 0 THEN p.protein * t.protein / p.basic_protein ELSE t.protein * p.amount / 100 END,
+		fat = CASE WHEN p.basic_fat <> 0 THEN p.fat * t.fat / p.basic_fat ELSE t.fat * p.amount / 100 END,
+		carbs = CASE WHEN p.basic_carbs <> 0 THEN p.carbs * t.carbs / p.basic_carbs ELSE t.carbs * p.amount / 100 END,
+		basic_calories = t.calories,
+		basic_protein = t.protein,
+		basic_fat = t.fat,
+		basic_carbs = t.carbs,
+		updated_at = now()
+	FROM templates t
+	WHERE ` + sourceDriftCondition + `
+	  AND ($1::bigint IS NULL OR t.id = $1)`
+
+	res, err := r.db.ExecContext(ctx, q, templateId)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
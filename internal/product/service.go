@@ -3,8 +3,10 @@ package product
 import (
 	"context"
 	"errors"
+	"math"
 
 	"github.com/jourloy/nutri-backend/internal/fit"
+	"github.com/jourloy/nutri-backend/internal/template"
 )
 
 type Service interface {
@@ -14,15 +16,26 @@ type Service interface {
 	GetLikeName(ctx context.Context, name string, uid string) ([]Product, error)
 	UpdateProduct(ctx context.Context, pu Product, uid string) (*Product, error)
 	DeleteProduct(ctx context.Context, id int64, uid string) error
+	// Catalog corrections (admin)
+	GetSourceCorrections(ctx context.Context) ([]SourceCorrection, error)
+	PropagateSourceCorrections(ctx context.Context, templateId *int64) (int64, error)
 }
 
 type service struct {
-	repo       Repository
-	fitService fit.Service
+	repo            Repository
+	fitService      fit.Service
+	templateService template.Service
 }
 
 func NewService() Service {
-	return &service{repo: NewRepository(), fitService: fit.NewService()}
+	return &service{repo: NewRepository(), fitService: fit.NewService(), templateService: template.NewService()}
+}
+
+// macroEpsilon — допустимое расхождение значений (округление на клиенте).
+const macroEpsilon = 0.05
+
+func macrosDiffer(a, b float64) bool {
+	return math.Abs(a-b) > macroEpsilon
 }
 
 func (s *service) CreateProduct(ctx context.Context, pc ProductCreate) (*Product, error) {
@@ -40,9 +53,43 @@ func (s *service) CreateProduct(ctx context.Context, pc ProductCreate) (*Product
 		return nil, errors.New("you have reached the maximum number of products for today")
 	}
 
+	diverged, err := s.divergedFromSource(ctx, pc)
+	if err != nil {
+		return nil, err
+	}
+	pc.IsDiverged = diverged
+
 	return s.repo.CreateProduct(ctx, pc)
 }
 
+// divergedFromSource сравнивает значения на 100г с источником записи.
+// Неизвестный шаблон или продукт-источник — ошибка: висячие ссылки не сохраняются.
+func (s *service) divergedFromSource(ctx context.Context, pc ProductCreate) (bool, error) {
+	if pc.TemplateId != nil {
+		t, err := s.templateService.GetById(ctx, *pc.TemplateId)
+		if err != nil {
+			return false, err
+		}
+		if t == nil {
+			return false, errors.New("template not found")
+		}
+		return macrosDiffer(pc.BasicCalories, t.Calories) || macrosDiffer(pc.BasicProtein, t.Protein) ||
+			macrosDiffer(pc.BasicFat, t.Fat) || macrosDiffer(pc.BasicCarbs, t.Carbs), nil
+	}
+	if pc.SourceId != nil {
+		src, err := s.repo.GetById(ctx, *pc.SourceId, pc.UserId)
+		if err != nil {
+			return false, err
+		}
+		if src == nil {
+			return false, errors.New("source product not found")
+		}
+		return macrosDiffer(pc.BasicCalories, src.BasicCalories) || macrosDiffer(pc.BasicProtein, src.BasicProtein) ||
+			macrosDiffer(pc.BasicFat, src.BasicFat) || macrosDiffer(pc.BasicCarbs, src.BasicCarbs), nil
+	}
+	return false, nil
+}
+
 func (s *service) GetAll(ctx context.Context, uid string) ([]Product, error) {
 	f, err := s.fitService.GetFitProfileByUser(uid)
 	if err != nil {
@@ -76,6 +123,25 @@ func (s *service) UpdateProduct(ctx context.Context, pu Product, uid string) (*P
 		return nil, err
 	}
 
+	existing, err := s.repo.GetById(ctx, pu.Id, uid)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	// Изменение только количества масштабирует значения пропорционально;
+	// любая другая правка значит, что запись разошлась с источником.
+	pu.IsDiverged = existing.IsDiverged
+	if (existing.TemplateId != nil || existing.SourceId != nil) && existing.Amount > 0 {
+		k := float64(pu.Amount) / float64(existing.Amount)
+		if macrosDiffer(pu.Calories, existing.Calories*k) || macrosDiffer(pu.Protein, existing.Protein*k) ||
+			macrosDiffer(pu.Fat, existing.Fat*k) || macrosDiffer(pu.Carbs, existing.Carbs*k) {
+			pu.IsDiverged = true
+		}
+	}
+
 	return s.repo.UpdateProduct(ctx, pu, f.Id, uid)
 }
 
@@ -87,3 +153,11 @@ func (s *service) DeleteProduct(ctx context.Context, id int64, uid string) error
 
 	return s.repo.DeleteProduct(ctx, id, f.Id, uid)
 }
+
+func (s *service) GetSourceCorrections(ctx context.Context) ([]SourceCorrection, error) {
+	return s.repo.GetSourceCorrections(ctx)
+}
+
+func (s *service) PropagateSourceCorrections(ctx context.Context, templateId *int64) (int64, error) {
+	return s.repo.PropagateSourceCorrections(ctx, templateId)
+}
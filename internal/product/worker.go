@@ -0,0 +1,49 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/jourloy/nutri-backend/internal/lib"
+)
+
+// StartWorker раз в сутки сообщает о расхождениях записей дневника с каталогом,
+// а при SOURCE_CORRECTIONS_PROPAGATE=true сразу переносит исправления в записи.
+func StartWorker() {
+	go func() {
+		logger := log.WithPrefix("[prodw]")
+		repo := NewRepository()
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			// run immediately on start and then every 24h
+			if err := runSourceCorrections(context.Background(), logger, repo, lib.Config.PropagateCorrections); err != nil {
+				logger.Error("source corrections", "err", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func runSourceCorrections(ctx context.Context, logger *log.Logger, repo Repository, propagate bool) error {
+	corrections, err := repo.GetSourceCorrections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range corrections {
+		logger.Info("catalog correction pending", "template", c.TemplateId, "name", c.TemplateName,
+			"entries", c.AffectedEntries, "users", c.AffectedUsers)
+	}
+	if !propagate || len(corrections) == 0 {
+		return nil
+	}
+
+	updated, err := repo.PropagateSourceCorrections(ctx, nil)
+	if err != nil {
+		return err
+	}
+	logger.Info("catalog corrections propagated", "entries", updated)
+	return nil
+}
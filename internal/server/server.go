@@ -15,12 +15,16 @@ import (
     "github.com/jourloy/nutri-backend/internal/body"
     "github.com/jourloy/nutri-backend/internal/auth"
     "github.com/jourloy/nutri-backend/internal/database"
+    "github.com/jourloy/nutri-backend/internal/diet"
     "github.com/jourloy/nutri-backend/internal/feature"
     "github.com/jourloy/nutri-backend/internal/fit"
+    "github.com/jourloy/nutri-backend/internal/metric"
     "github.com/jourloy/nutri-backend/internal/middlewares"
+    "github.com/jourloy/nutri-backend/internal/photo"
 	"github.com/jourloy/nutri-backend/internal/plan"
 	"github.com/jourloy/nutri-backend/internal/order"
 	"github.com/jourloy/nutri-backend/internal/product"
+	"github.com/jourloy/nutri-backend/internal/storage"
 	"github.com/jourloy/nutri-backend/internal/subscription"
 	"github.com/jourloy/nutri-backend/internal/template"
 	"github.com/jourloy/nutri-backend/internal/telegram"
@@ -41,6 +45,7 @@ func Start() error {
 	r := chi.NewRouter()
 
 	database.Connect()
+	storage.Connect()
 	logger.Debug("Repositories initialized", "latency", time.Since(tempTime))
 	tempTime = time.Now()
 
@@ -70,10 +75,14 @@ func Start() error {
     achievement.NewController().RegisterRoutes(r)
     analytics.NewController().RegisterRoutes(r)
     body.NewController().RegisterRoutes(r)
+    diet.NewController().RegisterRoutes(r)
+    photo.NewController().RegisterRoutes(r)
+    metric.NewController().RegisterRoutes(r)
 
     // Background workers
     order.StartWorker()
     body.StartWorker()
+    product.StartWorker()
 
 	logger.Debug("Handlers initialized", "latency", time.Since(tempTime))
 
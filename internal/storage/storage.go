@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/jourloy/nutri-backend/internal/lib"
+)
+
+var (
+	logger = log.NewWithOptions(os.Stderr, log.Options{
+		Prefix: "[stor]",
+		Level:  log.DebugLevel,
+	})
+
+	// Default — хранилище, выбранное при старте сервера.
+	Default Storage
+
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Storage хранит бинарные объекты (фото и т.п.) по ключу вида "photos/<user>/<name>.jpg".
+// Реализации должны быть безопасны для параллельного использования.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Connect инициализирует Default по конфигурации.
+func Connect() {
+	s, err := NewLocal(lib.Config.StorageDir)
+	if err != nil {
+		logger.Fatal("Cannot init storage", "dir", lib.Config.StorageDir, "error", err)
+	}
+	Default = s
+}
@@ -11,6 +11,7 @@ type TelegramProfile struct {
     TelegramAvatar   *string    `json:"telegramAvatar" db:"telegram_avatar"`
     NotifyDaily      bool       `json:"notifyDaily" db:"notify_daily"`
     NotifyStory      bool       `json:"notifyStory" db:"notify_story"`
+    NotifyHealth     bool       `json:"notifyHealth" db:"notify_health"`
     UserId           string     `json:"-" db:"user_id"`
     ConnectedAt      *time.Time `json:"connectedAt,omitempty" db:"connected_at"`
     CreatedAt        time.Time  `json:"-" db:"created_at"`
@@ -34,6 +35,7 @@ type LinkRequest struct {
 
 // NotifyUpdate allows partial update of notify flags.
 type NotifyUpdate struct {
-    NotifyDaily *bool `json:"notifyDaily"`
-    NotifyStory *bool `json:"notifyStory"`
+    NotifyDaily  *bool `json:"notifyDaily"`
+    NotifyStory  *bool `json:"notifyStory"`
+    NotifyHealth *bool `json:"notifyHealth"`
 }
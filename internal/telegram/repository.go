@@ -27,7 +27,7 @@ func NewRepository() Repository {
 
 const columns = `
     id, token, telegram_id, telegram_username, telegram_avatar,
-    notify_daily, notify_story, user_id, connected_at, created_at, updated_at
+    notify_daily, notify_story, notify_health, user_id, connected_at, created_at, updated_at
 `
 
 // CreateOrGetTicket creates a profile for the user if it doesn't exist and returns it (with token).
@@ -109,12 +109,13 @@ func (r *repository) UpdateNotifyByUserId(ctx context.Context, userId string, up
         UPDATE telegram_profiles
         SET notify_daily = COALESCE($2, notify_daily),
             notify_story = COALESCE($3, notify_story),
+            notify_health = COALESCE($4, notify_health),
             updated_at   = NOW()
         WHERE user_id = $1
         RETURNING ` + columns + `;`
 
     var tp TelegramProfile
-    if err := r.db.GetContext(ctx, &tp, q, userId, upd.NotifyDaily, upd.NotifyStory); err != nil {
+    if err := r.db.GetContext(ctx, &tp, q, userId, upd.NotifyDaily, upd.NotifyStory, upd.NotifyHealth); err != nil {
         return nil, err
     }
     return &tp, nil
@@ -1,6 +1,14 @@
 package telegram
 
-import "context"
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+
+    "github.com/jourloy/nutri-backend/internal/lib"
+)
 
 type Service interface {
     CreateTicket(ctx context.Context, userId string) (*TelegramProfile, error)
@@ -9,6 +17,7 @@ type Service interface {
     GetPublicByUserId(ctx context.Context, userId string) (*TelegramPublic, error)
     DeleteByUserId(ctx context.Context, userId string) error
     UpdateNotifyByUserId(ctx context.Context, userId string, upd NotifyUpdate) (*TelegramProfile, error)
+    SendMessage(ctx context.Context, chatId string, text string) error
 }
 
 type service struct {
@@ -42,3 +51,27 @@ func (s *service) DeleteByUserId(ctx context.Context, userId string) error {
 func (s *service) UpdateNotifyByUserId(ctx context.Context, userId string, upd NotifyUpdate) (*TelegramProfile, error) {
     return s.repo.UpdateNotifyByUserId(ctx, userId, upd)
 }
+
+// SendMessage sends a plain text message to a linked chat via Bot API.
+func (s *service) SendMessage(ctx context.Context, chatId string, text string) error {
+    if lib.Config.TelegramToken == "" {
+        return fmt.Errorf("telegram not configured")
+    }
+    body, _ := json.Marshal(map[string]string{"chat_id": chatId, "text": text})
+    url := "https://api.telegram.org/bot" + lib.Config.TelegramToken + "/sendMessage"
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("telegram sendMessage: status %d", resp.StatusCode)
+    }
+    return nil
+}
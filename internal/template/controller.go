@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/charmbracelet/log"
 	"github.com/go-chi/chi/v5"
@@ -31,27 +32,39 @@ func NewController() *Controller {
 func (c *Controller) RegisterRoutes(router chi.Router) {
 	router.Route("/template", func(r chi.Router) {
 		r.Get("/search", c.Search)
+		r.Get("/{id}/translations", c.GetTranslations)
+		r.Put("/translation", c.UpsertTranslation)
+		r.Delete("/translation/{id}", c.DeleteTranslation)
 	})
 
 	logger.Info("╔═════ Template")
-	logger.Info("║    GET /search?name=")
+	logger.Info("║    GET /search?name=&lang=")
+	logger.Info("║    GET /{id}/translations")
+	logger.Info("║    PUT /translation")
+	logger.Info("║ DELETE /translation/{id}")
 	logger.Info("╚═════")
 }
 
 func (c *Controller) Search(w http.ResponseWriter, r *http.Request) {
-	_, ok := auth.UserFromContext(r.Context())
+	u, ok := auth.UserFromContext(r.Context())
 	if !ok {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
+	// Язык: явный ?lang= важнее настройки пользователя
+	locale := u.Locale
+	if v := r.URL.Query().Get("lang"); v != "" {
+		locale = v
+	}
+
 	var name *string
 	if r.URL.Query().Get("name") != "" {
 		v := r.URL.Query().Get("name")
 		name = &v
 	}
 
-	resp, err := c.service.GetLikeName(context.Background(), *name)
+	resp, err := c.service.GetLikeName(context.Background(), *name, locale)
 	if err != nil {
 		logger.Error("Error search by name", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -61,3 +74,85 @@ func (c *Controller) Search(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+func (c *Controller) GetTranslations(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.GetTranslations(context.Background(), id)
+	if err != nil {
+		logger.Error("Error get translations", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) UpsertTranslation(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var t Translation
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.service.UpsertTranslation(context.Background(), t)
+	if err != nil {
+		logger.Error("Error upsert translation", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (c *Controller) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !u.IsAdmin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.service.DeleteTranslation(context.Background(), id); err != nil {
+		logger.Error("Error delete translation", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,14 +1,30 @@
 package template
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type Template struct {
-	Id        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Calories  float64   `json:"calories"`
-	Protein   float64   `json:"protein"`
-	Fat       float64   `json:"fat"`
-	Carbs     float64   `json:"carbs"`
-	CreatedAt time.Time `json:"-"`
-	UpdatedAt time.Time `json:"-"`
+	Id           int64     `json:"id"`
+	Name         string    `json:"name"`
+	OriginalName string    `json:"originalName"`
+	Calories     float64   `json:"calories"`
+	Protein      float64   `json:"protein"`
+	Fat          float64   `json:"fat"`
+	Carbs        float64   `json:"carbs"`
+	CreatedAt    time.Time `json:"-"`
+	UpdatedAt    time.Time `json:"-"`
+}
+
+// Translation holds a localized name and search aliases of a template.
+type Translation struct {
+	Id         int64          `json:"id" db:"id"`
+	TemplateId int64          `json:"templateId" db:"template_id"`
+	Locale     string         `json:"locale" db:"locale"`
+	Name       string         `json:"name" db:"name"`
+	Aliases    pq.StringArray `json:"aliases" db:"aliases"`
+	CreatedAt  time.Time      `json:"-" db:"created_at"`
+	UpdatedAt  time.Time      `json:"-" db:"updated_at"`
 }
@@ -2,6 +2,8 @@ package template
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
 	"github.com/jmoiron/sqlx"
 
@@ -9,7 +11,11 @@ import (
 )
 
 type Repository interface {
-	GetLikeName(ctx context.Context, name string) ([]Template, error)
+	GetLikeName(ctx context.Context, name string, locale string) ([]Template, error)
+	GetById(ctx context.Context, id int64) (*Template, error)
+	GetTranslations(ctx context.Context, templateId int64) ([]Translation, error)
+	UpsertTranslation(ctx context.Context, t Translation) (*Translation, error)
+	DeleteTranslation(ctx context.Context, id int64) error
 }
 
 type repository struct {
@@ -20,15 +26,23 @@ func NewRepository() Repository {
 	return &repository{db: database.Database}
 }
 
-func (r *repository) GetLikeName(ctx context.Context, name string) ([]Template, error) {
+// GetLikeName ищет по исходному имени, переводам и алиасам на любом языке,
+// а имя возвращает на языке locale (если перевода нет — исходное).
+func (r *repository) GetLikeName(ctx context.Context, name string, locale string) ([]Template, error) {
 	pattern := "%" + name + "%"
 	query := `
-	  SELECT id, name, calories, protein, fat, carbs, created_at, updated_at
-	  FROM templates
-	  WHERE name ILIKE $1
-	  ORDER BY name
+	  SELECT t.id, COALESCE(tr.name, t.name) AS name, t.name, t.calories, t.protein, t.fat, t.carbs, t.created_at, t.updated_at
+	  FROM templates t
+	  LEFT JOIN template_translations tr ON tr.template_id = t.id AND tr.locale = $2
+	  WHERE t.name ILIKE $1
+	     OR EXISTS (
+	        SELECT 1 FROM template_translations x
+	        WHERE x.template_id = t.id
+	          AND (x.name ILIKE $1 OR EXISTS (SELECT 1 FROM unnest(x.aliases) a WHERE a ILIKE $1))
+	     )
+	  ORDER BY 2
 	  LIMIT 10`
-	rows, err := r.db.QueryContext(ctx, query, pattern)
+	rows, err := r.db.QueryContext(ctx, query, pattern, locale)
 	if err != nil {
 		return nil, err
 	}
@@ -37,10 +51,72 @@ func (r *repository) GetLikeName(ctx context.Context, name string) ([]Template,
 	var res []Template
 	for rows.Next() {
 		var p Template
-		if err := rows.Scan(&p.Id, &p.Name, &p.Calories, &p.Protein, &p.Fat, &p.Carbs, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.Id, &p.Name, &p.OriginalName, &p.Calories, &p.Protein, &p.Fat, &p.Carbs, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		res = append(res, p)
 	}
 	return res, rows.Err()
 }
+
+func (r *repository) GetById(ctx context.Context, id int64) (*Template, error) {
+	const q = `
+	  SELECT id, name, name, calories, protein, fat, carbs, created_at, updated_at
+	  FROM templates
+	  WHERE id = $1`
+
+	var p Template
+	err := r.db.QueryRowContext(ctx, q, id).Scan(&p.Id, &p.Name, &p.OriginalName, &p.Calories, &p.Protein, &p.Fat, &p.Carbs, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *repository) GetTranslations(ctx context.Context, templateId int64) ([]Translation, error) {
+	const q = `
+	  SELECT id, template_id, locale, name, aliases, created_at, updated_at
+	  FROM template_translations
+	  WHERE template_id = $1
+	  ORDER BY locale`
+
+	var res []Translation
+	if err := r.db.SelectContext(ctx, &res, q, templateId); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *repository) UpsertTranslation(ctx context.Context, t Translation) (*Translation, error) {
+	if t.Aliases == nil {
+		t.Aliases = []string{}
+	}
+	const q = `
+	  INSERT INTO template_translations (template_id, locale, name, aliases)
+	  VALUES (:template_id, :locale, :name, :aliases)
+	  ON CONFLICT (template_id, locale) DO UPDATE SET name=EXCLUDED.name, aliases=EXCLUDED.aliases, updated_at=now()
+	  RETURNING id, template_id, locale, name, aliases, created_at, updated_at;`
+
+	rows, err := r.db.NamedQueryContext(ctx, q, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var out Translation
+		if err := rows.StructScan(&out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	}
+	return nil, nil
+}
+
+func (r *repository) DeleteTranslation(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM template_translations WHERE id = $1`, id)
+	return err
+}
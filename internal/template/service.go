@@ -2,10 +2,18 @@ package template
 
 import (
 	"context"
+	"errors"
+	"strings"
+
+	"github.com/jourloy/nutri-backend/internal/user"
 )
 
 type Service interface {
-	GetLikeName(ctx context.Context, name string) ([]Template, error)
+	GetLikeName(ctx context.Context, name string, locale string) ([]Template, error)
+	GetById(ctx context.Context, id int64) (*Template, error)
+	GetTranslations(ctx context.Context, templateId int64) ([]Translation, error)
+	UpsertTranslation(ctx context.Context, t Translation) (*Translation, error)
+	DeleteTranslation(ctx context.Context, id int64) error
 }
 
 type service struct {
@@ -16,6 +24,39 @@ func NewService() Service {
 	return &service{repo: NewRepository()}
 }
 
-func (s *service) GetLikeName(ctx context.Context, name string) ([]Template, error) {
-	return s.repo.GetLikeName(context.Background(), name)
+func (s *service) GetLikeName(ctx context.Context, name string, locale string) ([]Template, error) {
+	return s.repo.GetLikeName(context.Background(), name, strings.ToLower(locale))
+}
+
+func (s *service) GetById(ctx context.Context, id int64) (*Template, error) {
+	return s.repo.GetById(ctx, id)
+}
+
+func (s *service) GetTranslations(ctx context.Context, templateId int64) ([]Translation, error) {
+	return s.repo.GetTranslations(ctx, templateId)
+}
+
+func (s *service) UpsertTranslation(ctx context.Context, t Translation) (*Translation, error) {
+	t.Locale = strings.ToLower(strings.TrimSpace(t.Locale))
+	t.Name = strings.TrimSpace(t.Name)
+	if t.TemplateId == 0 || t.Locale == "" || t.Name == "" {
+		return nil, errors.New("templateId, locale and name are required")
+	}
+	if !user.IsLocale(t.Locale) {
+		return nil, errors.New("locale must be one of: " + strings.Join(user.Locales, ", "))
+	}
+
+	aliases := make([]string, 0, len(t.Aliases))
+	for _, a := range t.Aliases {
+		if a = strings.TrimSpace(a); a != "" {
+			aliases = append(aliases, a)
+		}
+	}
+	t.Aliases = aliases
+
+	return s.repo.UpsertTranslation(ctx, t)
+}
+
+func (s *service) DeleteTranslation(ctx context.Context, id int64) error {
+	return s.repo.DeleteTranslation(ctx, id)
 }
@@ -1,15 +1,24 @@
 package user
 
 import (
+	"slices"
 	"time"
 )
 
+// Locales — поддерживаемые языки интерфейса и названий шаблонов.
+var Locales = []string{"ru", "en"}
+
+// IsLocale сообщает, поддерживается ли язык.
+func IsLocale(locale string) bool { return slices.Contains(Locales, locale) }
+
 // User представляет структуру пользователя в системе.
 type User struct {
     Id              string     `json:"id" db:"id"`
     Username        string     `json:"username" db:"username"`
     PasswordHash    string     `json:"-" db:"password_hash"`
     Email           *string    `json:"email,omitempty" db:"email"`
+    Locale          string     `json:"locale" db:"locale"`
+    Timezone        string     `json:"timezone" db:"timezone"` // IANA, для границ дней в аналитике
     IsAcceptTerms   bool       `json:"-" db:"is_accept_terms"`
     IsAcceptPrivacy bool       `json:"-" db:"is_accept_privacy"`
     Is18            bool       `json:"-" db:"is_18"`
@@ -17,6 +17,8 @@ type Repository interface {
     UpdateLogin(ctx context.Context, uid string) error
     DeleteUser(ctx context.Context, id string) (*User, error)
     UpdateEmail(ctx context.Context, uid string, email string) (*User, error)
+    UpdateLocale(ctx context.Context, uid string, locale string) (*User, error)
+    UpdateTimezone(ctx context.Context, uid string, timezone string) (*User, error)
 }
 
 type repository struct {
@@ -30,7 +32,7 @@ func NewRepository() Repository {
 // единый список колонок — не используем SELECT *
 const userColumns = `
     id, username, password_hash,
-    email,
+    email, locale, timezone,
     is_accept_terms, is_accept_privacy, is_18, is_admin, 
     token_version, view_updates, view_tutorial,
     logined_at, created_at, updated_at, deleted_at
@@ -171,3 +173,39 @@ func (r *repository) UpdateEmail(ctx context.Context, uid string, email string)
     }
     return &u, nil
 }
+
+func (r *repository) UpdateLocale(ctx context.Context, uid string, locale string) (*User, error) {
+    const q = `
+        UPDATE users
+        SET locale = $2,
+            updated_at = now()
+        WHERE id = $1
+        RETURNING ` + userColumns + `;`
+
+    var u User
+    if err := r.db.GetContext(ctx, &u, q, uid, locale); err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return &u, nil
+}
+
+func (r *repository) UpdateTimezone(ctx context.Context, uid string, timezone string) (*User, error) {
+    const q = `
+        UPDATE users
+        SET timezone = $2,
+            updated_at = now()
+        WHERE id = $1
+        RETURNING ` + userColumns + `;`
+
+    var u User
+    if err := r.db.GetContext(ctx, &u, q, uid, timezone); err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return &u, nil
+}
@@ -11,6 +11,8 @@ type Service interface {
 	IncreaseViewUpdates(ctx context.Context, uid string) (*User, error)
 	UpdateLogin(ctx context.Context, uid string) error
 	DeleteUser(ctx context.Context, id string) (*User, error)
+	UpdateLocale(ctx context.Context, uid string, locale string) (*User, error)
+	UpdateTimezone(ctx context.Context, uid string, timezone string) (*User, error)
 }
 
 type service struct {
@@ -44,3 +46,11 @@ func (s *service) UpdateLogin(ctx context.Context, uid string) error {
 func (s *service) DeleteUser(ctx context.Context, id string) (*User, error) {
 	return s.repo.DeleteUser(ctx, id)
 }
+
+func (s *service) UpdateLocale(ctx context.Context, uid string, locale string) (*User, error) {
+	return s.repo.UpdateLocale(ctx, uid, locale)
+}
+
+func (s *service) UpdateTimezone(ctx context.Context, uid string, timezone string) (*User, error) {
+	return s.repo.UpdateTimezone(ctx, uid, timezone)
+}
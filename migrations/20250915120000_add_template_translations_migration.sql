@@ -0,0 +1,18 @@
+-- Preferred interface/catalog language of the user
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT 'ru';
+
+-- Per-locale names and search aliases for templates.
+-- Missing translation falls back to templates.name.
+CREATE TABLE IF NOT EXISTS template_translations (
+    id BIGSERIAL PRIMARY KEY,
+    template_id BIGINT NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    locale TEXT NOT NULL, -- 'en', 'ru', ...
+    name TEXT NOT NULL, -- Название на языке locale
+    aliases TEXT[] NOT NULL DEFAULT '{}', -- Дополнительные варианты для поиска
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_template_translations_template_locale ON template_translations(template_id, locale);
+CREATE INDEX IF NOT EXISTS ix_template_translations_locale ON template_translations(locale);
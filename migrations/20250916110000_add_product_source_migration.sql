@@ -0,0 +1,7 @@
+-- Reference from diary entries to the catalog item they were copied from
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS template_id BIGINT REFERENCES templates(id) ON DELETE SET NULL, -- Шаблон из каталога
+    ADD COLUMN IF NOT EXISTS source_product_id BIGINT REFERENCES products(id) ON DELETE SET NULL, -- Собственный продукт пользователя (повтор из поиска)
+    ADD COLUMN IF NOT EXISTS is_diverged BOOLEAN NOT NULL DEFAULT FALSE; -- Значения изменены относительно источника
+
+CREATE INDEX IF NOT EXISTS ix_products_template_id ON products(template_id) WHERE template_id IS NOT NULL;
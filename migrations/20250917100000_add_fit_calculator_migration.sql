@@ -0,0 +1,4 @@
+-- Inputs/options of the target calculator
+ALTER TABLE fit_profiles
+    ADD COLUMN IF NOT EXISTS body_fat NUMERIC(4,1), -- Процент жира (если известен — формула Кэтча-МакАрдла)
+    ADD COLUMN IF NOT EXISTS auto_targets BOOLEAN NOT NULL DEFAULT FALSE; -- Цели рассчитаны сервером
@@ -0,0 +1,27 @@
+-- Versioned fit profile targets: each row is valid from effective_from
+-- until the next row of the same user.
+CREATE TABLE IF NOT EXISTS fit_profile_history (
+    id BIGSERIAL PRIMARY KEY,
+    fit_id UUID NOT NULL REFERENCES fit_profiles(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    goal TEXT NOT NULL, -- Цель на момент версии
+    weight NUMERIC(6,2) NOT NULL, -- Вес на момент версии
+    calories NUMERIC(6,1) NOT NULL, -- Цель по калориям
+    protein NUMERIC(6,1) NOT NULL, -- Цель по белкам
+    fat NUMERIC(6,1) NOT NULL, -- Цель по жирам
+    carbs NUMERIC(6,1) NOT NULL, -- Цель по углеводам
+    water_limit INT, -- Цель по воде
+    source TEXT NOT NULL DEFAULT 'manual', -- 'initial' | 'manual' | 'auto'
+    effective_from DATE NOT NULL, -- С какого дня действуют цели
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_fit_profile_history_user_day ON fit_profile_history(user_id, effective_from);
+
+-- Existing profiles: current targets become the first version
+INSERT INTO fit_profile_history (fit_id, user_id, goal, weight, calories, protein, fat, carbs, water_limit, source, effective_from)
+SELECT id, user_id, goal, weight, calories, protein, fat, carbs, water_limit, 'initial', created_at::date
+FROM fit_profiles
+WHERE deleted_at IS NULL
+ON CONFLICT (user_id, effective_from) DO NOTHING;
@@ -0,0 +1,35 @@
+-- Training-day targets and weekly calorie budget mode
+ALTER TABLE fit_profiles
+    ADD COLUMN IF NOT EXISTS training_calories NUMERIC(6,1), -- Калории в тренировочный день
+    ADD COLUMN IF NOT EXISTS training_protein NUMERIC(6,1), -- Белки в тренировочный день
+    ADD COLUMN IF NOT EXISTS training_fat NUMERIC(6,1), -- Жиры в тренировочный день
+    ADD COLUMN IF NOT EXISTS training_carbs NUMERIC(6,1), -- Углеводы в тренировочный день
+    ADD COLUMN IF NOT EXISTS weekly_budget BOOLEAN NOT NULL DEFAULT FALSE; -- Недельный бюджет калорий
+
+-- Per-weekday overrides. NULL value keeps the base target.
+CREATE TABLE IF NOT EXISTS fit_weekday_targets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6), -- 0 — воскресенье
+    calories NUMERIC(6,1),
+    protein NUMERIC(6,1),
+    fat NUMERIC(6,1),
+    carbs NUMERIC(6,1),
+    is_training BOOLEAN NOT NULL DEFAULT FALSE, -- Тренировочный день по умолчанию
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_fit_weekday_targets_user_weekday ON fit_weekday_targets(user_id, weekday);
+
+-- Explicit training-day toggle per date (wins over the weekday default)
+CREATE TABLE IF NOT EXISTS fit_training_days (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    day DATE NOT NULL,
+    is_training BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_fit_training_days_user_day ON fit_training_days(user_id, day);
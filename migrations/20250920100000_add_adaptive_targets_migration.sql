@@ -0,0 +1,3 @@
+-- Weekly auto-apply of calorie targets from the adaptive expenditure estimate
+ALTER TABLE fit_profiles
+    ADD COLUMN IF NOT EXISTS adaptive_targets BOOLEAN NOT NULL DEFAULT FALSE; -- Применять оценку расхода раз в неделю
@@ -0,0 +1,4 @@
+-- Goal weight and optional deadline for progress forecasting
+ALTER TABLE fit_profiles
+    ADD COLUMN IF NOT EXISTS target_weight NUMERIC(5,2), -- Целевой вес, кг
+    ADD COLUMN IF NOT EXISTS target_date   DATE;         -- Желаемая дата достижения цели
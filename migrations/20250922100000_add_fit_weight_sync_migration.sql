@@ -0,0 +1,4 @@
+-- Profile weight follows the smoothed body weight log
+ALTER TABLE fit_profiles
+    ALTER COLUMN weight TYPE NUMERIC(6,2) USING weight::numeric,
+    ADD COLUMN IF NOT EXISTS weight_recalc BOOLEAN NOT NULL DEFAULT FALSE; -- Пересчитывать цели при заметном изменении веса
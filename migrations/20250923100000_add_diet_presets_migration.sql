@@ -0,0 +1,28 @@
+-- Diet presets (macro distribution rules)
+CREATE TABLE IF NOT EXISTS diet_presets (
+    id BIGSERIAL PRIMARY KEY,
+    key CITEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    mode TEXT NOT NULL DEFAULT 'percent', -- percent | per_kg
+    protein_pct NUMERIC(5,2),              -- Доля калорий, %
+    fat_pct NUMERIC(5,2),
+    carbs_pct NUMERIC(5,2),
+    protein_per_kg NUMERIC(4,2),           -- г на кг веса
+    fat_per_kg NUMERIC(4,2),
+    limits JSONB NOT NULL DEFAULT '{}'::jsonb, -- Ограничения по нутриентам
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    position BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE fit_profiles
+    ADD COLUMN IF NOT EXISTS preset_id BIGINT REFERENCES diet_presets(id) ON DELETE SET NULL; -- Выбранный пресет
+
+INSERT INTO diet_presets (key, name, description, mode, protein_pct, fat_pct, carbs_pct, protein_per_kg, fat_per_kg, limits, position) VALUES
+    ('balanced', 'Сбалансированное', 'Классическое распределение 30/30/40', 'percent', 30, 30, 40, NULL, NULL, '{}'::jsonb, 10),
+    ('high_protein', 'Высокобелковое', '2.2 г белка и 0.8 г жира на кг, остальное — углеводы', 'per_kg', NULL, NULL, NULL, 2.2, 0.8, '{}'::jsonb, 20),
+    ('keto', 'Кето', 'Минимум углеводов, основная энергия из жиров', 'percent', 20, 75, 5, NULL, NULL, '{"maxCarbs":50}'::jsonb, 30),
+    ('mediterranean', 'Средиземноморское', 'Умеренные жиры из оливкового масла, рыбы и орехов', 'percent', 20, 35, 45, NULL, NULL, '{"maxSaturatedFatPct":10,"minFiber":25}'::jsonb, 40)
+ON CONFLICT (key) DO NOTHING;
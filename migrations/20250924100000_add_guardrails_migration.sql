@@ -0,0 +1,12 @@
+-- Health warnings: opt-in telegram notifications and dedup of sent alerts
+ALTER TABLE telegram_profiles
+    ADD COLUMN IF NOT EXISTS notify_health BOOLEAN NOT NULL DEFAULT FALSE; -- Отправлять ли предупреждения о здоровье
+
+CREATE TABLE IF NOT EXISTS body_guardrail_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    code TEXT NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_body_guardrail_alerts_user_code ON body_guardrail_alerts(user_id, code, sent_at DESC);
@@ -0,0 +1,7 @@
+-- Extended circumferences and directly measured body fat
+ALTER TABLE body_measurements
+    ADD COLUMN IF NOT EXISTS neck NUMERIC(6,1),     -- cm
+    ADD COLUMN IF NOT EXISTS arm NUMERIC(6,1),      -- cm
+    ADD COLUMN IF NOT EXISTS thigh NUMERIC(6,1),    -- cm
+    ADD COLUMN IF NOT EXISTS calf NUMERIC(6,1),     -- cm
+    ADD COLUMN IF NOT EXISTS body_fat NUMERIC(4,1); -- %, измерен напрямую (весы, калипер, DEXA)
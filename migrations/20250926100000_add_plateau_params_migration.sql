@@ -0,0 +1,19 @@
+-- Plateau detection parameters: global defaults, per-plan overrides and per-user tuning.
+-- params holds only overridden keys, the rest comes from the less specific level.
+CREATE TABLE IF NOT EXISTS body_plateau_params (
+    id BIGSERIAL PRIMARY KEY,
+    scope TEXT NOT NULL, -- global | plan | user
+    plan_id BIGINT REFERENCES plans(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id),
+    params JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_body_plateau_params_global ON body_plateau_params(scope) WHERE scope = 'global';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_body_plateau_params_plan ON body_plateau_params(plan_id) WHERE scope = 'plan';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_body_plateau_params_user ON body_plateau_params(user_id) WHERE scope = 'user';
+
+-- Parameters used for each evaluation
+ALTER TABLE body_plateau_events
+    ADD COLUMN IF NOT EXISTS params JSONB;
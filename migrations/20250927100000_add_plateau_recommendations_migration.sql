@@ -0,0 +1,5 @@
+-- Structured recommendations generated for a plateau and the one accepted by the user
+ALTER TABLE body_plateau_events
+    ADD COLUMN IF NOT EXISTS recommendations JSONB,
+    ADD COLUMN IF NOT EXISTS accepted_recommendation TEXT,
+    ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMP;
@@ -0,0 +1,25 @@
+-- One plateau event per user and window: keep the latest row of duplicates
+DELETE FROM body_plateau_events e
+USING body_plateau_events d
+WHERE e.user_id = d.user_id
+  AND e.window_end = d.window_end
+  AND (e.created_at, e.id) < (d.created_at, d.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_body_plateau_events_user_window ON body_plateau_events(user_id, window_end);
+
+ALTER TABLE body_plateau_events
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Background worker run metrics
+CREATE TABLE IF NOT EXISTS body_worker_runs (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    users_total INT NOT NULL,
+    users_ok INT NOT NULL,
+    users_failed INT NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS ix_body_worker_runs_started_at ON body_worker_runs(started_at DESC);
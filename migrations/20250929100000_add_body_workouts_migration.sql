@@ -0,0 +1,21 @@
+-- Workouts with estimated energy expenditure
+CREATE TABLE IF NOT EXISTS body_workouts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    type TEXT NOT NULL, -- walking, running, cycling, ...
+    duration_min INT NOT NULL, -- minutes
+    intensity TEXT NOT NULL DEFAULT 'moderate',
+    distance_km NUMERIC(7,2),
+    met NUMERIC(5,2) NOT NULL,
+    weight NUMERIC(6,2) NOT NULL, -- kg used for the estimate
+    calories NUMERIC(7,1) NOT NULL, -- kcal above resting expenditure
+    source TEXT NOT NULL DEFAULT 'estimate', -- estimate | manual
+    logged_at DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ix_body_workouts_user_day ON body_workouts(user_id, logged_at);
+
+ALTER TABLE fit_profiles
+    ADD COLUMN IF NOT EXISTS exercise_add_back BOOLEAN NOT NULL DEFAULT FALSE; -- Добавлять калории тренировок к дневному бюджету
@@ -0,0 +1,5 @@
+-- Imported workouts keep the id from the health app, so re-imports don't duplicate them
+ALTER TABLE body_workouts
+    ADD COLUMN IF NOT EXISTS external_id TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_body_workouts_user_external ON body_workouts(user_id, external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Progress photos; files live in blob storage, the table keeps their keys
+CREATE TABLE IF NOT EXISTS progress_photos (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    pose TEXT NOT NULL CHECK (pose IN ('front', 'side', 'back')),
+    taken_at DATE NOT NULL,
+    note TEXT,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    image_key TEXT NOT NULL,
+    thumb_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ix_progress_photos_user_pose_taken ON progress_photos(user_id, pose, taken_at);
@@ -0,0 +1,17 @@
+-- Sleep sessions (night sleep and naps); times are the user's local wall clock
+CREATE TABLE IF NOT EXISTS body_sleep (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP NOT NULL,
+    sleep_date DATE NOT NULL, -- wake-up day
+    duration_min INT NOT NULL,
+    quality SMALLINT CHECK (quality BETWEEN 1 AND 5),
+    is_nap BOOLEAN NOT NULL DEFAULT FALSE,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS ix_body_sleep_user_date ON body_sleep(user_id, sleep_date);
@@ -0,0 +1,31 @@
+-- User-defined health metrics (blood pressure, glucose, resting heart rate, ...)
+CREATE TABLE IF NOT EXISTS health_metrics (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    preset_key TEXT, -- set when the metric was added from a preset
+    name TEXT NOT NULL,
+    unit TEXT NOT NULL DEFAULT '',
+    value_type TEXT NOT NULL CHECK (value_type IN ('decimal', 'integer')),
+    target_min DOUBLE PRECISION,
+    target_max DOUBLE PRECISION,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (target_min IS NULL OR target_max IS NULL OR target_min <= target_max)
+);
+
+CREATE INDEX IF NOT EXISTS ix_health_metrics_user ON health_metrics(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_health_metrics_user_preset ON health_metrics(user_id, preset_key) WHERE preset_key IS NOT NULL;
+
+-- Logged values; measured_at is the user's local wall clock
+CREATE TABLE IF NOT EXISTS health_metric_values (
+    id BIGSERIAL PRIMARY KEY,
+    metric_id BIGINT NOT NULL REFERENCES health_metrics(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    value DOUBLE PRECISION NOT NULL,
+    measured_at TIMESTAMP NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ix_health_metric_values_metric_measured ON health_metric_values(metric_id, measured_at);
@@ -0,0 +1,3 @@
+-- User timezone (IANA name) for day, week and month boundaries in analytics
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,29 @@
+-- Training-day targets and weekly budget are versioned with the targets
+ALTER TABLE fit_profile_history
+    ADD COLUMN IF NOT EXISTS training_calories NUMERIC(6,1), -- Калории в тренировочный день
+    ADD COLUMN IF NOT EXISTS training_protein NUMERIC(6,1), -- Белки в тренировочный день
+    ADD COLUMN IF NOT EXISTS training_fat NUMERIC(6,1), -- Жиры в тренировочный день
+    ADD COLUMN IF NOT EXISTS training_carbs NUMERIC(6,1), -- Углеводы в тренировочный день
+    ADD COLUMN IF NOT EXISTS weekly_budget BOOLEAN NOT NULL DEFAULT FALSE; -- Недельный бюджет калорий
+
+-- Existing versions: current settings are the best known values
+UPDATE fit_profile_history h
+SET training_calories = f.training_calories,
+    training_protein = f.training_protein,
+    training_fat = f.training_fat,
+    training_carbs = f.training_carbs,
+    weekly_budget = f.weekly_budget
+FROM fit_profiles f
+WHERE f.id = h.fit_id;
+
+-- Weekday overrides are kept per set: each replace adds rows valid from that day.
+-- A row without values and not training clears the weekday.
+ALTER TABLE fit_weekday_targets
+    ADD COLUMN IF NOT EXISTS valid_from DATE; -- С какого дня действует переопределение
+
+UPDATE fit_weekday_targets SET valid_from = created_at::date WHERE valid_from IS NULL;
+
+ALTER TABLE fit_weekday_targets ALTER COLUMN valid_from SET NOT NULL;
+
+DROP INDEX IF EXISTS ux_fit_weekday_targets_user_weekday;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_fit_weekday_targets_user_weekday_from ON fit_weekday_targets(user_id, weekday, valid_from);
@@ -0,0 +1,12 @@
+-- Daily total of sleep sessions, kept apart from manual or imported sleep_min
+ALTER TABLE body_activity
+    ADD COLUMN IF NOT EXISTS sleep_sessions_min INT; -- Сумма сессий сна за день
+
+UPDATE body_activity a
+SET sleep_sessions_min = s.total
+FROM (
+    SELECT user_id, sleep_date, SUM(duration_min) AS total
+    FROM body_sleep
+    GROUP BY user_id, sleep_date
+) s
+WHERE a.user_id = s.user_id AND a.logged_at = s.sleep_date;
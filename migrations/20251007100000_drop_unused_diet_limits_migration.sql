@@ -0,0 +1,6 @@
+-- Products track only calories and macros, so sugar, sodium, saturated fat
+-- and fiber limits of presets could never be checked
+UPDATE diet_presets
+SET limits = limits - 'maxSugar' - 'maxSodium' - 'maxSaturatedFatPct' - 'minFiber',
+    updated_at = NOW()
+WHERE limits ?| ARRAY['maxSugar', 'maxSodium', 'maxSaturatedFatPct', 'minFiber'];
@@ -0,0 +1,5 @@
+-- Diary days are filtered as a created_at range in the user's timezone
+CREATE INDEX IF NOT EXISTS ix_products_user_created_at ON products(user_id, created_at);
+
+-- "Local" is the server's zone, Postgres does not know it
+UPDATE users SET timezone = 'UTC' WHERE timezone = 'Local';
@@ -0,0 +1,2 @@
+-- Only ru and en are supported; earlier any string could be saved as the locale
+UPDATE users SET locale = 'ru', updated_at = NOW() WHERE locale NOT IN ('ru', 'en');